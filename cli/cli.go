@@ -0,0 +1,225 @@
+// Package cli implements the headless quest_line subcommands. They run
+// against the same storage as the TUI and every one accepts --json.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"quest_line/domain"
+)
+
+// command is a headless subcommand
+type command struct {
+	usage string
+	help  string
+	run   func(env *env, args []string) error
+}
+
+// env is the state shared by a single subcommand invocation
+type env struct {
+	storage  domain.Storage
+	store    *domain.Store
+	undoFile string
+	activity domain.ActivityLog
+	stdout   io.Writer
+	json     bool
+}
+
+var commands = map[string]command{
+	"add-project":  {"add-project [--auto-complete] [--progress count|effort] <name>", "create a project", runAddProject},
+	"add-quest":    {"add-quest [--project ID|NAME] [--parent QUEST-ID] [--desc TEXT] [--priority N] [--deadline YYYY-MM-DD] [--estimate 4h] [--repeat RULE] [--tags a,b] <title>", "create a quest", runAddQuest},
+	"add-task":     {"add-task [--priority N] [--deadline YYYY-MM-DD] [--estimate 1h30m] [--notes TEXT] [--repeat RULE] [--tags a,b] <quest-id> <description>", "add a task to a quest", runAddTask},
+	"progress":     {"progress <project> count|effort", "weight a project's progress by task count or by estimates", runProgressMode},
+	"done":         {"done [--undo] <task-id>", "mark a task done", runDone},
+	"start":        {"start [--note TEXT] <task-id>", "start the timer on a task, stopping any other", runStart},
+	"stop":         {"stop [--note TEXT]", "stop the running timer", runStop},
+	"timesheet":    {"timesheet [--project ID|NAME] [--from YYYY-MM-DD] [--to YYYY-MM-DD]", "print logged time by day", runTimesheet},
+	"achievements": {"achievements", "list achievements and progress towards them", runAchievements},
+	"character":    {"character [--recent N]", "show level, XP and lifetime stats", runCharacter},
+	"board":        {"board [--project ID|NAME]", "show a project's quests by board column", runBoard},
+	"move-card":    {"move-card <quest-id> <column>", "move a quest to a board column, changing its state", runMoveCard},
+	"columns":      {"columns <project> [Name=state,...|default]", "show or set a project's board columns (states: todo, in-progress, done, cancelled)", runColumns},
+	"begin":        {"begin <quest-id>", "mark a quest in progress", questStateCommand("begin", (*domain.Store).StartQuest)},
+	"calendar":     {"calendar [--project ID|NAME] [--from YYYY-MM-DD] [--to YYYY-MM-DD]", "list quests and tasks by due day", runCalendar},
+	"timeline":     {"timeline [--project ID|NAME]", "list open quests with their start and deadline", runTimeline},
+	"stats":        {"stats [--project ID|NAME] [--days N]", "count completed tasks, open tasks and overdue items from the event log", runStats},
+	"log":          {"log [--project ID|NAME] [--quest ID] [--limit N]", "show the activity log, newest first", runLog},
+	"export":       {"export [--format md|todo.txt|taskwarrior] [--project ID|NAME] [--output FILE]", "write projects as Markdown, todo.txt or Taskwarrior JSON", runExport},
+	"import":       {"import [--format md|todo.txt|taskwarrior] [--project ID|NAME] [FILE]", "merge projects or tasks from a file, or standard input, by ID", runImport},
+	"reschedule":   {"reschedule <id> <YYYY-MM-DD>", "move the deadline of a quest or task", runReschedule},
+	"complete":     {"complete <quest-id>", "mark a quest completed", questStateCommand("complete", (*domain.Store).CompleteQuest)},
+	"cancel":       {"cancel <quest-id>", "mark a quest cancelled", questStateCommand("cancel", (*domain.Store).CancelQuest)},
+	"reopen":       {"reopen <quest-id>", "make a finished or archived quest active again", questStateCommand("reopen", (*domain.Store).ReopenQuest)},
+	"archive":      {"archive <quest-id>", "archive a completed or cancelled quest", questStateCommand("archive", (*domain.Store).ArchiveQuest)},
+	"block":        {"block <id> <blocker-id>", "make a quest or task wait on another in its project", blockCommand("block", (*domain.Store).AddQuestBlocker, (*domain.Store).AddTaskBlocker)},
+	"unblock":      {"unblock <id> <blocker-id>", "remove a blocker from a quest or task", blockCommand("unblock", (*domain.Store).RemoveQuestBlocker, (*domain.Store).RemoveTaskBlocker)},
+	"ls":           {"ls [--project ID|NAME] [--all] [--tag '+tag -tag']", "list projects and quests", runList},
+	"search":       {"search <query>", "fuzzy-search projects, quests and tasks", runSearch},
+	"show":         {"show <quest-id>", "show a quest with its sub-quests and tasks", runShow},
+	"restore":      {"restore [number|name]", "list backups or roll back to one", runRestore},
+	"workspaces":   {"workspaces", "list named workspaces", runWorkspaces},
+	"undo":         {"undo", "revert the last change (needs --keep-undo)", runUndo},
+	"redo":         {"redo", "re-apply the last undone change (needs --keep-undo)", runRedo},
+}
+
+// IsCommand reports whether name is a headless subcommand
+func IsCommand(name string) bool {
+	if name == "help" || name == "-h" || name == "--help" {
+		return true
+	}
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the subcommand named by args[0]. When undoFile is set,
+// changes are recorded there so they can be undone later. Changes are also
+// appended to the activity log.
+func Run(storage domain.Storage, undoFile string, activity domain.ActivityLog, args []string, stdout io.Writer) error {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		return fmt.Errorf("unknown command; run 'quest_line help'")
+	}
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage(stdout)
+		return nil
+	}
+
+	data, err := storage.Load()
+	if err != nil {
+		return err
+	}
+	e := &env{
+		storage:  storage,
+		store:    domain.NewStore(data.Projects),
+		undoFile: undoFile,
+		activity: activity,
+		stdout:   stdout,
+	}
+	e.store.SetXPLog(data.XPLog)
+	e.store.SetActor(activity.Actor)
+	if undoFile != "" {
+		history, err := domain.LoadHistory(undoFile, e.store.Projects())
+		if err != nil {
+			return err
+		}
+		e.store.SetHistory(history)
+	}
+	if err := cmd.run(e, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stdout, "usage: quest_line %s\n", cmd.usage)
+			return nil
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if !e.json {
+		for _, a := range e.store.TakeUnlocked() {
+			fmt.Fprintf(stdout, "Achievement unlocked: %s - %s (+%d XP)\n", a.Name, a.Description, a.XP)
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "usage: quest_line [--data PATH | --workspace NAME] [--backend json|bolt] [--keep-undo] [command] [--json] [args]")
+	fmt.Fprintln(w, "\nWithout a command the interactive TUI starts.\n\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].help)
+		fmt.Fprintf(w, "               quest_line %s\n", commands[name].usage)
+	}
+}
+
+// newFlagSet creates a flag set with the shared --json flag
+func (e *env) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&e.json, "json", false, "print machine-readable JSON")
+	return fs
+}
+
+// parseArgs parses flags that may appear before, between or after
+// positional arguments and returns the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// save persists the store, its new activity, and the undo history if it is
+// kept, after a mutation
+func (e *env) save() error {
+	data, err := domain.EncodeData(e.store.Data())
+	if err != nil {
+		return err
+	}
+	if err := e.storage.Save(data); err != nil {
+		return err
+	}
+	if err := e.activity.Append(e.store.TakeActivity()); err != nil {
+		return err
+	}
+	if e.undoFile != "" {
+		return domain.SaveHistory(e.undoFile, e.store.History())
+	}
+	return nil
+}
+
+// print writes v as indented JSON in --json mode, or text otherwise
+func (e *env) print(v interface{}, text string) error {
+	if e.json {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	_, err := fmt.Fprint(e.stdout, text)
+	return err
+}
+
+// findProject resolves a project by ID or, failing that, by name
+func (e *env) findProject(ref string) (*domain.Project, error) {
+	if p, err := e.store.Project(ref); err == nil {
+		return p, nil
+	}
+	projects := e.store.Projects()
+	for i := range projects {
+		if strings.EqualFold(projects[i].Name, ref) {
+			return &projects[i], nil
+		}
+	}
+	return nil, &domain.NotFoundError{Kind: domain.KindProject, ID: ref}
+}
+
+// defaultProject returns the only project when no --project is given
+func (e *env) defaultProject(ref string) (*domain.Project, error) {
+	if ref != "" {
+		return e.findProject(ref)
+	}
+	projects := e.store.Projects()
+	if len(projects) == 1 {
+		return &projects[0], nil
+	}
+	return nil, fmt.Errorf("--project is required when there are %d projects", len(projects))
+}
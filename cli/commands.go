@@ -0,0 +1,1182 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"quest_line/config"
+	"quest_line/domain"
+)
+
+func runAddProject(e *env, args []string) error {
+	fs := e.newFlagSet("add-project")
+	autoComplete := fs.Bool("auto-complete", false, "complete quests once all their tasks are done")
+	progress := fs.String("progress", domain.ProgressByCount, "progress mode: count or effort")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(strings.Join(positional, " "))
+	if name == "" {
+		return fmt.Errorf("a project name is required")
+	}
+	p, err := e.store.CreateProject(domain.ProjectInput{Name: name, AutoComplete: *autoComplete, ProgressMode: *progress})
+	if err != nil {
+		return err
+	}
+	created := *p
+	if err := e.save(); err != nil {
+		return err
+	}
+	return e.print(created, fmt.Sprintf("Created project %s: %s\n", created.ID, created.Name))
+}
+
+func runProgressMode(e *env, args []string) error {
+	fs := e.newFlagSet("progress")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: progress <project> count|effort")
+	}
+	p, err := e.findProject(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := e.store.SetProgressMode(p.ID, positional[1]); err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	p, _ = e.store.Project(p.ID)
+	return e.print(*p, fmt.Sprintf("%s now tracks progress by %s: %.1f%%\n", p.Name, positional[1], p.Progress))
+}
+
+func runAddQuest(e *env, args []string) error {
+	fs := e.newFlagSet("add-quest")
+	projectRef := fs.String("project", "", "project ID or name")
+	parentID := fs.String("parent", "", "parent quest ID for a sub-quest")
+	desc := fs.String("desc", "", "description")
+	priority := fs.Int("priority", 0, "priority (0-10)")
+	deadline := fs.String("deadline", "", "deadline (YYYY-MM-DD)")
+	estimate := fs.String("estimate", "", "estimate such as 4h; without one the task estimates are added up")
+	tags := fs.String("tags", "", "comma-separated tags")
+	repeat := fs.String("repeat", "", "repeat rule such as \"weekly on mon\" or an RRULE")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSpace(strings.Join(positional, " "))
+	if title == "" {
+		return fmt.Errorf("a quest title is required")
+	}
+
+	input := domain.QuestInput{Title: title, Description: *desc, Priority: *priority, Tags: domain.ParseTags(*tags)}
+	if input.Deadline, err = parseDeadline(*deadline); err != nil {
+		return err
+	}
+	if input.Estimate, err = domain.ParseEstimate(*estimate); err != nil {
+		return err
+	}
+	if input.Recur, err = domain.ParseRecurrence(*repeat); err != nil {
+		return err
+	}
+
+	var project *domain.Project
+	if *parentID != "" && *projectRef == "" {
+		project, err = e.store.QuestProject(*parentID)
+	} else {
+		project, err = e.defaultProject(*projectRef)
+	}
+	if err != nil {
+		return err
+	}
+	q, err := e.store.CreateQuest(project.ID, *parentID, input)
+	if err != nil {
+		return err
+	}
+	created := *q
+	if err := e.save(); err != nil {
+		return err
+	}
+	return e.print(created, fmt.Sprintf("Created quest %s: %s\n", created.ID, created.Title))
+}
+
+func runAddTask(e *env, args []string) error {
+	fs := e.newFlagSet("add-task")
+	tags := fs.String("tags", "", "comma-separated tags")
+	priority := fs.Int("priority", 0, "priority (0-10)")
+	deadline := fs.String("deadline", "", "deadline (YYYY-MM-DD)")
+	estimate := fs.String("estimate", "", "estimate such as 45m or 1h30m")
+	notes := fs.String("notes", "", "notes")
+	repeat := fs.String("repeat", "", "repeat rule such as \"daily\" or \"after 7 days\"")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return fmt.Errorf("usage: add-task <quest-id> <description>")
+	}
+	input := domain.TaskInput{
+		Description: strings.TrimSpace(strings.Join(positional[1:], " ")),
+		Tags:        domain.ParseTags(*tags),
+		Priority:    *priority,
+		Notes:       *notes,
+	}
+	if input.Deadline, err = parseDeadline(*deadline); err != nil {
+		return err
+	}
+	if input.Estimate, err = domain.ParseEstimate(*estimate); err != nil {
+		return err
+	}
+	if input.Recur, err = domain.ParseRecurrence(*repeat); err != nil {
+		return err
+	}
+	t, err := e.store.CreateTask(positional[0], input)
+	if err != nil {
+		return err
+	}
+	created := *t
+	if err := e.save(); err != nil {
+		return err
+	}
+	return e.print(created, fmt.Sprintf("Created task %s: %s\n", created.ID, created.Description))
+}
+
+func runDone(e *env, args []string) error {
+	fs := e.newFlagSet("done")
+	undo := fs.Bool("undo", false, "mark the task not done instead")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: done <task-id>")
+	}
+	if err := e.store.SetTaskDone(positional[0], !*undo); err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	t, _ := e.store.Task(positional[0])
+	q, _ := e.store.TaskQuest(positional[0])
+	result := struct {
+		Task          domain.Task `json:"task"`
+		QuestID       string      `json:"quest_id"`
+		QuestProgress float64     `json:"quest_progress"`
+	}{*t, q.ID, q.Progress}
+	return e.print(result, fmt.Sprintf("%s %s (%s now %.1f%% complete)\n",
+		checkbox(t.Done), t.Description, q.Title, q.Progress))
+}
+
+func runStart(e *env, args []string) error {
+	fs := e.newFlagSet("start")
+	note := fs.String("note", "", "note for the time entry")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: start <task-id>")
+	}
+	if err := e.store.StartTimer(positional[0], *note); err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	t, _ := e.store.Task(positional[0])
+	return e.print(*t, fmt.Sprintf("Started timer on %s\n", t.Description))
+}
+
+func runStop(e *env, args []string) error {
+	fs := e.newFlagSet("stop")
+	note := fs.String("note", "", "note for the time entry")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: stop [--note TEXT]")
+	}
+	t, err := e.store.StopTimer(*note)
+	if err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	entry := t.TimeEntries[len(t.TimeEntries)-1]
+	return e.print(t, fmt.Sprintf("Stopped timer on %s after %s (%s total)\n",
+		t.Description, domain.FormatDuration(entry.Duration(time.Now())), domain.FormatDuration(t.TimeSpent(time.Now()))))
+}
+
+func runTimesheet(e *env, args []string) error {
+	fs := e.newFlagSet("timesheet")
+	projectRef := fs.String("project", "", "only this project (ID or name)")
+	fromFlag := fs.String("from", "", "first day (YYYY-MM-DD, default 6 days ago)")
+	toFlag := fs.String("to", "", "last day (YYYY-MM-DD, default today)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, err := parseDay(*fromFlag, today.AddDate(0, 0, -6))
+	if err != nil {
+		return err
+	}
+	to, err := parseDay(*toFlag, today)
+	if err != nil {
+		return err
+	}
+	var projectID string
+	if *projectRef != "" {
+		p, err := e.findProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		projectID = p.ID
+	}
+
+	rows := domain.Timesheet(e.store.Projects(), projectID, from, to.AddDate(0, 0, 1), now)
+	var b strings.Builder
+	var total, dayTotal time.Duration
+	day := ""
+	for _, r := range rows {
+		start := r.Start.Local()
+		if d := start.Format("2006-01-02 Mon"); d != day {
+			if day != "" {
+				fmt.Fprintf(&b, "  %s total\n\n", domain.FormatDuration(dayTotal))
+			}
+			day, dayTotal = d, 0
+			b.WriteString(day + "\n")
+		}
+		end := "now  "
+		if r.End != nil {
+			end = r.End.Local().Format("15:04")
+		}
+		fmt.Fprintf(&b, "  %s-%s  %6s  %s › %s › %s", start.Format("15:04"), end, domain.FormatDuration(r.Duration), r.ProjectName, r.QuestTitle, r.Task)
+		if r.Note != "" {
+			b.WriteString("  - " + r.Note)
+		}
+		b.WriteString("\n")
+		dayTotal += r.Duration
+		total += r.Duration
+	}
+	if day != "" {
+		fmt.Fprintf(&b, "  %s total\n\n", domain.FormatDuration(dayTotal))
+	}
+	fmt.Fprintf(&b, "%s to %s: %s\n", from.Format("2006-01-02"), to.Format("2006-01-02"), domain.FormatDuration(total))
+
+	result := struct {
+		From         string                `json:"from"`
+		To           string                `json:"to"`
+		TotalSeconds int64                 `json:"total_seconds"`
+		Entries      []domain.TimesheetRow `json:"entries"`
+	}{from.Format("2006-01-02"), to.Format("2006-01-02"), int64(total / time.Second), rows}
+	if result.Entries == nil {
+		result.Entries = []domain.TimesheetRow{}
+	}
+	return e.print(result, b.String())
+}
+
+func runCharacter(e *env, args []string) error {
+	fs := e.newFlagSet("character")
+	recent := fs.Int("recent", 10, "how many recent XP awards to list")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *recent < 0 {
+		return fmt.Errorf("--recent must not be negative")
+	}
+	c := domain.CharacterSheet(e.store.Projects(), e.store.XPLog(), time.Now(), *recent)
+	if c.Recent == nil {
+		c.Recent = []domain.XPAward{}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Level %d  %d/%d XP (%d to level %d)\n", c.Level, c.LevelXP, c.NextLevelXP, c.NextLevelXP-c.LevelXP, c.Level+1)
+	fmt.Fprintf(&b, "Total XP: %d\n", c.XP)
+	fmt.Fprintf(&b, "Tasks done: %d | Quests completed: %d | Achievements: %d\n", c.TasksDone, c.QuestsDone, c.Achievements)
+	fmt.Fprintf(&b, "Streak: %s (best %d)\n", domain.FormatStreak(c.Streak), c.BestStreak)
+	fmt.Fprintf(&b, "Time logged: %s\n", domain.FormatDuration(c.TimeLogged))
+	if c.Since != nil {
+		fmt.Fprintf(&b, "Adventuring since: %s\n", c.Since.Local().Format("2006-01-02"))
+	}
+	if len(c.Recent) > 0 {
+		b.WriteString("\nRecent XP:\n")
+		for _, award := range c.Recent {
+			// Achievements and awards from deleted projects have no project to name
+			project := ""
+			if award.Project != "" {
+				project = " (" + award.Project + ")"
+			}
+			fmt.Fprintf(&b, "  +%d XP  %s %q%s  %s\n", award.XP, award.Kind, award.Title, project, award.At.Local().Format("2006-01-02 15:04"))
+		}
+	}
+	return e.print(c, b.String())
+}
+
+func runAchievements(e *env, args []string) error {
+	fs := e.newFlagSet("achievements")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	list := domain.AchievementProgress(e.store.Projects(), e.store.XPLog(), time.Now())
+	var b strings.Builder
+	for _, a := range list {
+		if a.UnlockedAt != nil {
+			fmt.Fprintf(&b, "[x] %s - %s  +%d XP  %s\n", a.Name, a.Description, a.XP, a.UnlockedAt.Local().Format("2006-01-02"))
+		} else {
+			fmt.Fprintf(&b, "[ ] %s - %s  %d/%d\n", a.Name, a.Description, a.Progress, a.Target)
+		}
+	}
+	return e.print(list, b.String())
+}
+
+func runBoard(e *env, args []string) error {
+	fs := e.newFlagSet("board")
+	projectRef := fs.String("project", "", "project ID or name")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	p, err := e.defaultProject(*projectRef)
+	if err != nil {
+		return err
+	}
+	board := domain.BuildBoard(*p, time.Now())
+	var b strings.Builder
+	for i, column := range board.Columns {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s (%d)\n", column.Name, len(board.Cards[i]))
+		for _, card := range board.Cards[i] {
+			fmt.Fprintf(&b, "  [%s] %s %.0f%%", card.ID, card.Title, card.Progress)
+			if card.Path != "" {
+				fmt.Fprintf(&b, " (in %s)", card.Path)
+			}
+			if card.Deadline != nil {
+				fmt.Fprintf(&b, " due %s", card.Deadline.Format("2006-01-02"))
+				if card.Overdue {
+					b.WriteString(" OVERDUE")
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	return e.print(board, b.String())
+}
+
+func runMoveCard(e *env, args []string) error {
+	fs := e.newFlagSet("move-card")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: move-card <quest-id> <column>")
+	}
+	if err := e.store.MoveToColumn(positional[0], positional[1]); err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	q, _ := e.store.Quest(positional[0])
+	return e.print(*q, fmt.Sprintf("%s moved to %s (%s)\n", q.Title, q.Column, q.State))
+}
+
+func runColumns(e *env, args []string) error {
+	fs := e.newFlagSet("columns")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 && len(positional) != 2 {
+		return fmt.Errorf("usage: columns <project> [Name=state,...|default]")
+	}
+	p, err := e.findProject(positional[0])
+	if err != nil {
+		return err
+	}
+	if len(positional) == 2 {
+		var columns []domain.BoardColumn
+		if positional[1] != "default" {
+			if columns, err = domain.ParseBoardColumns(positional[1]); err != nil {
+				return err
+			}
+		}
+		if err := e.store.SetBoardColumns(p.ID, columns); err != nil {
+			return err
+		}
+		if err := e.save(); err != nil {
+			return err
+		}
+		p, _ = e.store.Project(p.ID)
+	}
+	columns := p.Columns()
+	return e.print(columns, fmt.Sprintf("%s: %s\n", p.Name, domain.FormatBoardColumns(columns)))
+}
+
+func runCalendar(e *env, args []string) error {
+	fs := e.newFlagSet("calendar")
+	projectRef := fs.String("project", "", "only this project (ID or name)")
+	fromFlag := fs.String("from", "", "first day (YYYY-MM-DD, default the 1st of this month)")
+	toFlag := fs.String("to", "", "last day (YYYY-MM-DD, default the end of the month)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	now := time.Now()
+	from, err := parseDay(*fromFlag, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		return err
+	}
+	to, err := parseDay(*toFlag, time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		return err
+	}
+	var projectID string
+	if *projectRef != "" {
+		p, err := e.findProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		projectID = p.ID
+	}
+
+	items := domain.CalendarItems(e.store.Projects(), projectID, domain.Day(from), domain.Day(to), now)
+	var b strings.Builder
+	day := ""
+	for _, item := range items {
+		if d := item.Due.Format("2006-01-02 Mon"); d != day {
+			if day != "" {
+				b.WriteString("\n")
+			}
+			day = d
+			b.WriteString(day)
+			if item.Due.Equal(domain.Day(now)) {
+				b.WriteString(" (today)")
+			}
+			b.WriteString("\n")
+		}
+		if item.Kind == domain.KindQuest {
+			fmt.Fprintf(&b, "  quest [%s] %s", item.ID, item.Title)
+		} else {
+			fmt.Fprintf(&b, "  task  [%s] %s (%s)", item.ID, item.Title, item.QuestTitle)
+		}
+		switch {
+		case item.Done:
+			b.WriteString(" done")
+		case item.Overdue:
+			b.WriteString(" OVERDUE")
+		}
+		b.WriteString("\n")
+	}
+	if len(items) == 0 {
+		fmt.Fprintf(&b, "Nothing due from %s to %s\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	if items == nil {
+		items = []domain.CalendarItem{}
+	}
+	return e.print(items, b.String())
+}
+
+func runReschedule(e *env, args []string) error {
+	fs := e.newFlagSet("reschedule")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: reschedule <quest-or-task-id> <YYYY-MM-DD>")
+	}
+	day, err := parseDay(positional[1], time.Time{})
+	if err != nil {
+		return err
+	}
+	if day.IsZero() {
+		return fmt.Errorf("usage: reschedule <quest-or-task-id> <YYYY-MM-DD>")
+	}
+	if err := e.store.Reschedule(positional[0], domain.Day(day)); err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	if q, err := e.store.Quest(positional[0]); err == nil {
+		return e.print(*q, fmt.Sprintf("%s is now due %s\n", q.Title, day.Format("2006-01-02")))
+	}
+	t, _ := e.store.Task(positional[0])
+	return e.print(*t, fmt.Sprintf("%s is now due %s\n", t.Description, day.Format("2006-01-02")))
+}
+
+func runTimeline(e *env, args []string) error {
+	fs := e.newFlagSet("timeline")
+	projectRef := fs.String("project", "", "only this project (ID or name)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	var projectID string
+	if *projectRef != "" {
+		p, err := e.findProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		projectID = p.ID
+	}
+
+	groups := domain.Timeline(e.store.Projects(), projectID, time.Now())
+	var b strings.Builder
+	for i, group := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(group.Project + "\n")
+		for _, bar := range group.Bars {
+			fmt.Fprintf(&b, "  [%s] %s  %s → %s  %.0f%%", bar.QuestID, bar.Title, bar.Start.Format("2006-01-02"), bar.End.Format("2006-01-02"), bar.Progress)
+			if bar.Overdue {
+				b.WriteString(" OVERDUE")
+			}
+			var waits []string
+			for _, dep := range bar.BlockedBy {
+				if !dep.Done {
+					waits = append(waits, dep.Title)
+				}
+			}
+			if len(waits) > 0 {
+				b.WriteString("  waits on " + strings.Join(waits, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(groups) == 0 {
+		b.WriteString("No open quests with deadlines\n")
+		groups = []domain.TimelineGroup{}
+	}
+	return e.print(groups, b.String())
+}
+
+func runStats(e *env, args []string) error {
+	fs := e.newFlagSet("stats")
+	projectRef := fs.String("project", "", "only this project (ID or name)")
+	days := fs.Int("days", 14, "how many days, up to today, to count")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	var projectID string
+	if *projectRef != "" {
+		p, err := e.findProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		projectID = p.ID
+	}
+
+	now := time.Now()
+	to := domain.Day(now)
+	r := domain.BuildReport(e.store.Projects(), projectID, to.AddDate(0, 0, 1-*days), to, now)
+	var b strings.Builder
+	b.WriteString("Completed per day\n")
+	for _, c := range r.CompletedPerDay {
+		fmt.Fprintf(&b, "  %s  %d\n", c.Day.Format("2006-01-02 Mon"), c.Count)
+	}
+	b.WriteString("Completed per week\n")
+	for _, c := range r.CompletedPerWeek {
+		fmt.Fprintf(&b, "  %s  %d\n", c.Day.Format("2006-01-02"), c.Count)
+	}
+	b.WriteString("Open tasks\n")
+	for _, p := range r.Projects {
+		fmt.Fprintf(&b, "  %s  %d", p.Project, p.OpenTasks)
+		if len(p.Burndown) > 0 {
+			fmt.Fprintf(&b, " (%d on %s)", p.Burndown[0].Count, p.Burndown[0].Day.Format("2006-01-02"))
+		}
+		b.WriteString("\n")
+	}
+	if r.QuestsMeasured > 0 {
+		fmt.Fprintf(&b, "Average quest time: %s over %d quests\n", domain.FormatSpan(r.AvgQuestTime), r.QuestsMeasured)
+	}
+	fmt.Fprintf(&b, "Overdue: %d quests, %d tasks\n", r.OverdueQuests, r.OverdueTasks)
+	return e.print(r, b.String())
+}
+
+func runLog(e *env, args []string) error {
+	fs := e.newFlagSet("log")
+	projectRef := fs.String("project", "", "only this project (ID or name)")
+	questID := fs.String("quest", "", "only this quest and what is inside it")
+	limit := fs.Int("limit", 20, "how many entries to show; 0 shows all")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	var projectID string
+	if *projectRef != "" {
+		p, err := e.findProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		projectID = p.ID
+	}
+	entries, err := e.activity.Read()
+	if err != nil {
+		return err
+	}
+
+	entries = domain.FilterActivity(entries, projectID, *questID)
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+	// Newest first, like the Activity screen
+	newest := make([]domain.Activity, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		newest = append(newest, entries[i])
+	}
+	var b strings.Builder
+	for _, a := range newest {
+		fmt.Fprintf(&b, "%s  %s  %s [%s]  (%s)\n", a.At.Local().Format("2006-01-02 15:04:05"), a.Actor, a.Summary(), a.ID, a.Action)
+		for _, c := range a.Changes {
+			b.WriteString("    " + c.String() + "\n")
+		}
+	}
+	if len(newest) == 0 {
+		b.WriteString("No activity recorded\n")
+	}
+	return e.print(newest, b.String())
+}
+
+// exportFormats names the formats export and import understand
+const exportFormats = "md, todo.txt or taskwarrior"
+
+// isListFormat reports whether a format holds the tasks of a single
+// project, as todo.txt and Taskwarrior do
+func isListFormat(format string) bool {
+	switch format {
+	case "todo.txt", "todotxt", "taskwarrior", "tw":
+		return true
+	}
+	return false
+}
+
+func runExport(e *env, args []string) error {
+	fs := e.newFlagSet("export")
+	format := fs.String("format", "md", "output format: "+exportFormats)
+	projectRef := fs.String("project", "", "only this project (ID or name); required by todo.txt and taskwarrior with several projects")
+	output := fs.String("output", "", "write to this file instead of standard output")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	projects := e.store.Projects()
+	if *projectRef != "" || isListFormat(*format) {
+		p, err := e.defaultProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		projects = []domain.Project{*p}
+	}
+
+	var text string
+	var err error
+	switch *format {
+	case "md", "markdown":
+		text = domain.FormatMarkdown(projects)
+	case "todo.txt", "todotxt":
+		text = domain.FormatTodoTxt(projects[0])
+	case "taskwarrior", "tw":
+		text, err = domain.FormatTaskwarrior(projects[0])
+	default:
+		return fmt.Errorf("unknown format %q (use %s)", *format, exportFormats)
+	}
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(text), 0644); err != nil {
+			return err
+		}
+		return e.print(projects, fmt.Sprintf("Exported %d projects to %s\n", len(projects), *output))
+	}
+	return e.print(projects, text)
+}
+
+func runImport(e *env, args []string) error {
+	fs := e.newFlagSet("import")
+	format := fs.String("format", "md", "input format: "+exportFormats)
+	projectRef := fs.String("project", "", "project to import todo.txt or taskwarrior tasks into (ID or name, created if missing)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return fmt.Errorf("usage: import [--format md|todo.txt|taskwarrior] [--project ID|NAME] [FILE]")
+	}
+	in := os.Stdin
+	if len(positional) == 1 && positional[0] != "-" {
+		f, err := os.Open(positional[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// Flat task lists are read into one project, a new one when --project
+	// names none
+	var into domain.Project
+	if isListFormat(*format) {
+		p, err := e.defaultProject(*projectRef)
+		switch {
+		case err == nil:
+			into = *p
+		case *projectRef != "":
+			into = domain.Project{Name: *projectRef}
+		default:
+			return err
+		}
+	}
+
+	var projects []domain.Project
+	switch *format {
+	case "md", "markdown":
+		projects, err = domain.ParseMarkdown(in)
+	case "todo.txt", "todotxt":
+		var p domain.Project
+		p, err = domain.ParseTodoTxt(in, into)
+		projects = []domain.Project{p}
+	case "taskwarrior", "tw":
+		var p domain.Project
+		p, err = domain.ParseTaskwarrior(in, into)
+		projects = []domain.Project{p}
+	default:
+		return fmt.Errorf("unknown format %q (use %s)", *format, exportFormats)
+	}
+	if err != nil {
+		return err
+	}
+	sum, err := e.store.ImportProjects(projects)
+	if err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	text := fmt.Sprintf("Imported %d projects (%d new): %d quests and %d tasks added, %d quests and %d tasks merged\n",
+		len(sum.Projects), sum.AddedProjects, sum.AddedQuests, sum.AddedTasks, sum.MergedQuests, sum.MergedTasks)
+	return e.print(sum, text)
+}
+
+// parseDeadline reads a --deadline flag as the calendar day deadlines are
+// stored as, or nil for an empty flag
+func parseDeadline(value string) (*time.Time, error) {
+	d, err := parseDay(value, time.Time{})
+	if err != nil || d.IsZero() {
+		return nil, err
+	}
+	due := domain.Day(d)
+	return &due, nil
+}
+
+// parseDay reads a local YYYY-MM-DD date, or returns def for an empty string
+func parseDay(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD)", value)
+	}
+	return d, nil
+}
+
+// questStateCommand builds a subcommand that applies a lifecycle action to a quest
+func questStateCommand(name string, action func(*domain.Store, string) error) func(*env, []string) error {
+	return func(e *env, args []string) error {
+		fs := e.newFlagSet(name)
+		positional, err := parseArgs(fs, args)
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: %s <quest-id>", name)
+		}
+		if err := action(e.store, positional[0]); err != nil {
+			return err
+		}
+		if err := e.save(); err != nil {
+			return err
+		}
+		q, _ := e.store.Quest(positional[0])
+		return e.print(*q, fmt.Sprintf("%s is now %s\n", q.Title, q.State))
+	}
+}
+
+// blockCommand adds or removes a blocker on a quest or task, whichever the
+// first ID names
+func blockCommand(name string, questAction, taskAction func(*domain.Store, string, string) error) func(*env, []string) error {
+	return func(e *env, args []string) error {
+		fs := e.newFlagSet(name)
+		positional, err := parseArgs(fs, args)
+		if err != nil {
+			return err
+		}
+		if len(positional) != 2 {
+			return fmt.Errorf("usage: %s <id> <blocker-id>", name)
+		}
+		id, blockerID := positional[0], positional[1]
+		if _, err := e.store.Quest(id); err == nil {
+			if err := questAction(e.store, id, blockerID); err != nil {
+				return err
+			}
+			if err := e.save(); err != nil {
+				return err
+			}
+			q, _ := e.store.Quest(id)
+			blockers, _ := e.store.QuestBlockers(id)
+			names := make([]string, len(blockers))
+			for i, blocker := range blockers {
+				names[i] = blocker.Title
+			}
+			return e.print(*q, blockedByText(q.Title, names))
+		}
+		if err := taskAction(e.store, id, blockerID); err != nil {
+			return err
+		}
+		if err := e.save(); err != nil {
+			return err
+		}
+		t, _ := e.store.Task(id)
+		blockers, _ := e.store.TaskBlockers(id)
+		names := make([]string, len(blockers))
+		for i, blocker := range blockers {
+			names[i] = blocker.Description
+		}
+		return e.print(*t, blockedByText(t.Description, names))
+	}
+}
+
+// blockedByText describes what an item is now blocked by
+func blockedByText(name string, names []string) string {
+	if len(names) == 0 {
+		return fmt.Sprintf("%s is not blocked\n", name)
+	}
+	return fmt.Sprintf("%s is blocked by %s\n", name, strings.Join(names, ", "))
+}
+
+func runList(e *env, args []string) error {
+	fs := e.newFlagSet("ls")
+	projectRef := fs.String("project", "", "only list this project (ID or name)")
+	all := fs.Bool("all", false, "include completed and cancelled quests")
+	tagExpr := fs.String("tag", "", "tag filter such as '+backend -blocked'")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	filter := domain.ParseTagFilter(*tagExpr)
+	index := domain.EffectiveTags(e.store.Projects())
+
+	projects := e.store.Projects()
+	if *projectRef != "" {
+		p, err := e.findProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		projects = []domain.Project{*p}
+	}
+
+	blocked := domain.BlockedItems(e.store.Projects())
+	listed := make([]domain.Project, 0, len(projects))
+	var b strings.Builder
+	for _, p := range projects {
+		p.Quests = filterQuests(p.Quests, *all, filter, index)
+		listed = append(listed, p)
+		fmt.Fprintf(&b, "%s  %.1f%%", p.Name, p.Progress)
+		if p.ProgressMode == domain.ProgressByEffort {
+			b.WriteString(" by effort")
+		}
+		if spent := p.TimeSpent(time.Now()); spent > 0 {
+			fmt.Fprintf(&b, "  %s logged", domain.FormatDuration(spent))
+		}
+		fmt.Fprintf(&b, "  (%s)\n", p.ID)
+		writeQuestTree(&b, p.Quests, 1, blocked)
+	}
+	if len(listed) == 0 {
+		b.WriteString("No projects.\n")
+	}
+	return e.print(listed, b.String())
+}
+
+func runShow(e *env, args []string) error {
+	fs := e.newFlagSet("show")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: show <quest-id>")
+	}
+	q, err := e.store.Quest(positional[0])
+	if err != nil {
+		return err
+	}
+	p, _ := e.store.QuestProject(q.ID)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  (%s)\n", q.Title, q.ID)
+	fmt.Fprintf(&b, "Project: %s\n", p.Name)
+	if q.Description != "" {
+		fmt.Fprintf(&b, "%s\n", q.Description)
+	}
+	fmt.Fprintf(&b, "Progress: %.1f%% | Priority: %d | Status: %s\n", q.Progress, q.Priority, q.State)
+	if q.Deadline != nil {
+		fmt.Fprintf(&b, "Deadline: %s\n", q.Deadline.Format("2006-01-02"))
+	}
+	if q.Recur != nil {
+		fmt.Fprintf(&b, "Repeats: %s\n", q.Recur)
+	}
+	if q.Estimate > 0 {
+		fmt.Fprintf(&b, "Estimate: %s\n", domain.FormatEstimate(q.Estimate))
+	} else if effort := q.Effort(); effort > 0 {
+		fmt.Fprintf(&b, "Estimate: %s (from tasks)\n", domain.FormatEstimate(effort))
+	}
+	if len(q.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n", domain.HashTags(q.Tags))
+	}
+	if spent := q.TimeSpent(time.Now()); spent > 0 {
+		fmt.Fprintf(&b, "Time logged: %s\n", domain.FormatDuration(spent))
+	}
+	if blockers, _ := e.store.QuestBlockers(q.ID); len(blockers) > 0 {
+		b.WriteString("Blocked by:\n")
+		writeQuestTree(&b, blockers, 1, nil)
+	}
+	if dependents, _ := e.store.QuestDependents(q.ID); len(dependents) > 0 {
+		b.WriteString("Unblocks:\n")
+		writeQuestTree(&b, dependents, 1, nil)
+	}
+	blocked := domain.BlockedItems(e.store.Projects())
+	if len(q.SubQuests) > 0 {
+		b.WriteString("\nSub-quests:\n")
+		writeQuestTree(&b, q.SubQuests, 1, blocked)
+	}
+	if len(q.Tasks) > 0 {
+		b.WriteString("\nTasks:\n")
+		for _, t := range q.Tasks {
+			details := taskDetails(t)
+			if blocked[t.ID] && !t.Done {
+				details += "  [blocked]"
+			}
+			fmt.Fprintf(&b, "  %s %s%s%s  (%s)\n", checkbox(t.Done), t.Description, details, tagSuffix(t.Tags), t.ID)
+			if t.Notes != "" {
+				for _, line := range strings.Split(t.Notes, "\n") {
+					fmt.Fprintf(&b, "      %s\n", line)
+				}
+			}
+		}
+	}
+
+	result := struct {
+		ProjectID string       `json:"project_id"`
+		Quest     domain.Quest `json:"quest"`
+	}{p.ID, *q}
+	return e.print(result, b.String())
+}
+
+func runSearch(e *env, args []string) error {
+	fs := e.newFlagSet("search")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	query := strings.Join(positional, " ")
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("a search query is required")
+	}
+	results := domain.Search(e.store.Projects(), query)
+
+	var b strings.Builder
+	if len(results) == 0 {
+		b.WriteString("No matches.\n")
+	}
+	for _, r := range results {
+		id := r.ProjectID
+		if r.TaskID != "" {
+			id = r.TaskID
+		} else if r.QuestID != "" {
+			id = r.QuestID
+		}
+		fmt.Fprintf(&b, "%-7s %s  (%s)\n        %s\n", r.Kind, r.Text, id, r.Path)
+	}
+	return e.print(results, b.String())
+}
+
+func runRestore(e *env, args []string) error {
+	fs := e.newFlagSet("restore")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	bs, ok := e.storage.(domain.BackupStorage)
+	if !ok {
+		return domain.ErrBackupsUnsupported
+	}
+	backups, err := bs.Backups()
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		var b strings.Builder
+		if len(backups) == 0 {
+			b.WriteString("No backups yet.\n")
+		}
+		for i, backup := range backups {
+			fmt.Fprintf(&b, "%2d. %s  %s  %d bytes\n", i+1, backup.Time.Local().Format("2006-01-02 15:04:05"), backup.Name, backup.Size)
+		}
+		if len(backups) > 0 {
+			b.WriteString("\nRun 'quest_line restore <number|name>' to roll back.\n")
+		}
+		return e.print(backups, b.String())
+	}
+
+	name := positional[0]
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 1 || n > len(backups) {
+			return fmt.Errorf("no backup number %d", n)
+		}
+		name = backups[n-1].Name
+	}
+	if err := bs.Restore(name); err != nil {
+		return err
+	}
+	return e.print(map[string]string{"restored": name}, fmt.Sprintf("Restored %s\n", name))
+}
+
+func runWorkspaces(e *env, args []string) error {
+	fs := e.newFlagSet("workspaces")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	names, err := config.Workspaces(os.Getenv)
+	if err != nil {
+		return err
+	}
+	return e.print(names, strings.Join(names, "\n")+"\n")
+}
+
+func runUndo(e *env, args []string) error {
+	return e.replay(args, "undo", "Undid", e.store.Undo)
+}
+
+func runRedo(e *env, args []string) error {
+	return e.replay(args, "redo", "Redid", e.store.Redo)
+}
+
+// replay runs an undo or redo against the history kept in the undo file
+func (e *env) replay(args []string, name, verb string, step func() (domain.Change, error)) error {
+	fs := e.newFlagSet(name)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if e.undoFile == "" {
+		return fmt.Errorf("undo history is only kept between runs with --keep-undo or %s=1", config.EnvKeepUndo)
+	}
+	c, err := step()
+	if err != nil {
+		return err
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	result := struct {
+		Label string    `json:"label"`
+		At    time.Time `json:"at"`
+	}{c.Label, c.At}
+	return e.print(result, fmt.Sprintf("%s %s\n", verb, c.Label))
+}
+
+// filterQuests drops finished quests unless all is set, and quests that fail
+// the tag filter unless one of their sub-quests passes it
+func filterQuests(quests []domain.Quest, all bool, filter domain.TagFilter, index map[string][]string) []domain.Quest {
+	filtered := []domain.Quest{}
+	for _, q := range quests {
+		if !all && !q.State.IsOpen() {
+			continue
+		}
+		q.SubQuests = filterQuests(q.SubQuests, all, filter, index)
+		if len(q.SubQuests) == 0 && !filter.Matches(index[q.ID]) {
+			continue
+		}
+		filtered = append(filtered, q)
+	}
+	return filtered
+}
+
+// writeQuestTree writes one line per quest, indented by depth, flagging
+// the active quests in blocked
+func writeQuestTree(b *strings.Builder, quests []domain.Quest, depth int, blocked map[string]bool) {
+	indent := strings.Repeat("  ", depth)
+	for _, q := range quests {
+		fmt.Fprintf(b, "%s[%s] %s  %.1f%%  p%d", indent, q.State, q.Title, q.Progress, q.Priority)
+		if blocked[q.ID] && q.State.IsOpen() {
+			b.WriteString("  [blocked]")
+		}
+		if q.Deadline != nil {
+			fmt.Fprintf(b, "  due %s", q.Deadline.Format("2006-01-02"))
+		}
+		if q.Recur != nil {
+			fmt.Fprintf(b, "  repeats %s", q.Recur)
+		}
+		if effort := q.Effort(); effort > 0 {
+			fmt.Fprintf(b, "  ~%s", domain.FormatEstimate(effort))
+		}
+		b.WriteString(tagSuffix(q.Tags))
+		fmt.Fprintf(b, "  (%s)\n", q.ID)
+		writeQuestTree(b, q.SubQuests, depth+1, blocked)
+	}
+}
+
+// taskDetails renders a task's priority, deadline and estimate, if set
+func taskDetails(t domain.Task) string {
+	var s string
+	if t.Priority != 0 {
+		s += fmt.Sprintf("  p%d", t.Priority)
+	}
+	if t.Deadline != nil {
+		s += "  due " + t.Deadline.Format("2006-01-02")
+		if !t.Done && domain.IsOverdue(t.Deadline, time.Now()) {
+			s += " (overdue)"
+		}
+	}
+	if t.Estimate != 0 {
+		s += "  ~" + domain.FormatEstimate(t.Estimate)
+	}
+	if t.Recur != nil {
+		s += "  repeats " + t.Recur.String()
+	}
+	if len(t.TimeEntries) > 0 {
+		s += "  logged " + domain.FormatDuration(t.TimeSpent(time.Now()))
+		if t.Running() != nil {
+			s += " (running)"
+		}
+	}
+	return s
+}
+
+// tagSuffix renders tags after an item, or nothing without tags
+func tagSuffix(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "  " + domain.HashTags(tags)
+}
+
+func checkbox(done bool) string {
+	if done {
+		return "[✓]"
+	}
+	return "[ ]"
+}
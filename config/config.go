@@ -0,0 +1,201 @@
+// Package config resolves where quest_line keeps its data from global
+// command-line flags, environment variables and the XDG base directories.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"quest_line/domain"
+)
+
+// DefaultWorkspace is used when no workspace is named
+const DefaultWorkspace = "default"
+
+// appDir is the directory name under the XDG data home
+const appDir = "quest_line"
+
+// Environment variables read by Parse
+const (
+	EnvData      = "QUEST_LINE_DATA"
+	EnvWorkspace = "QUEST_LINE_WORKSPACE"
+	EnvBackend   = "QUEST_LINE_BACKEND"
+	EnvBackups   = "QUEST_LINE_BACKUPS"
+	EnvKeepUndo  = "QUEST_LINE_KEEP_UNDO"
+	EnvActor     = "QUEST_LINE_ACTOR"
+)
+
+// Suffixes appended to the data file path to name the files kept beside it
+const (
+	undoSuffix     = ".undo"
+	activitySuffix = ".activity.jsonl"
+)
+
+// Config holds the settings resolved for one run
+type Config struct {
+	Storage   domain.StorageConfig
+	Workspace string
+	UndoFile  string // empty keeps undo history for the session only
+	Activity  domain.ActivityLog
+}
+
+// Parse resolves the configuration from global flags at the front of args
+// and from the environment. Flags win over environment variables, so a
+// workspace flag overrides QUEST_LINE_DATA and --data overrides
+// QUEST_LINE_WORKSPACE; from the environment alone, the data path wins. It
+// returns the remaining arguments, which start with the subcommand if there
+// is one.
+func Parse(args []string, getenv func(string) string) (Config, []string, error) {
+	fs := flag.NewFlagSet("quest_line", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	data := fs.String("data", getenv(EnvData), "data file path")
+	workspace := fs.String("workspace", getenv(EnvWorkspace), "named workspace")
+	fs.StringVar(workspace, "w", *workspace, "named workspace (shorthand)")
+	backend := fs.String("backend", getenv(EnvBackend), "storage backend (json or bolt)")
+	keepUndo, err := envBool(getenv, EnvKeepUndo)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	fs.BoolVar(&keepUndo, "keep-undo", keepUndo, "keep undo history across restarts")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return Config{}, []string{"help"}, nil
+		}
+		return Config{}, nil, err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	workspaceFlag := set["workspace"] || set["w"]
+	if set["data"] && workspaceFlag {
+		return Config{}, nil, fmt.Errorf("--data and --workspace cannot be combined")
+	}
+
+	cfg := Config{
+		Storage:   domain.StorageConfig{Backend: *backend},
+		Workspace: *workspace,
+		Activity:  domain.ActivityLog{Actor: actor(getenv)},
+	}
+	if backups := getenv(EnvBackups); backups != "" {
+		n, err := strconv.Atoi(backups)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("%s must be a number, got %q", EnvBackups, backups)
+		}
+		cfg.Storage.Backups = n
+	}
+
+	if *data != "" && !workspaceFlag {
+		cfg.Workspace = ""
+		cfg.Storage.Path = *data
+		cfg.setUndoFile(keepUndo)
+		cfg.Activity.Path = cfg.Storage.Path + activitySuffix
+		return cfg, fs.Args(), nil
+	}
+
+	if cfg.Workspace == "" {
+		cfg.Workspace = DefaultWorkspace
+	}
+	dir, err := WorkspaceDir(getenv, cfg.Workspace)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	fileName := domain.DefaultDataFile
+	if cfg.Storage.Backend == domain.BackendBolt {
+		fileName = domain.DefaultBoltFile
+	}
+	cfg.Storage.Path = filepath.Join(dir, fileName)
+	cfg.setUndoFile(keepUndo)
+	cfg.Activity.Path = cfg.Storage.Path + activitySuffix
+	return cfg, fs.Args(), nil
+}
+
+// setUndoFile places the undo history next to the data file when it is kept
+func (c *Config) setUndoFile(keep bool) {
+	if keep {
+		c.UndoFile = c.Storage.Path + undoSuffix
+	}
+}
+
+// actor names who is making changes: QUEST_LINE_ACTOR, else the login name
+func actor(getenv func(string) string) string {
+	for _, name := range []string{EnvActor, "USER", "USERNAME"} {
+		if v := getenv(name); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
+
+// envBool reads a boolean environment variable, treating unset as false
+func envBool(getenv func(string) string, name string) (bool, error) {
+	v := getenv(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, v)
+	}
+	return b, nil
+}
+
+// DataDir returns $XDG_DATA_HOME/quest_line, falling back to
+// ~/.local/share/quest_line when XDG_DATA_HOME is unset
+func DataDir(getenv func(string) string) (string, error) {
+	base := getenv("XDG_DATA_HOME")
+	if base == "" || !filepath.IsAbs(base) {
+		home := getenv("HOME")
+		if home == "" {
+			var err error
+			if home, err = os.UserHomeDir(); err != nil {
+				return "", fmt.Errorf("cannot locate data directory: %w", err)
+			}
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(base, appDir), nil
+}
+
+// WorkspaceDir returns the directory for a workspace. The default workspace
+// lives directly in the data directory; named ones under workspaces/<name>.
+func WorkspaceDir(getenv func(string) string, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid workspace name %q", name)
+	}
+	dir, err := DataDir(getenv)
+	if err != nil {
+		return "", err
+	}
+	if name == DefaultWorkspace {
+		return dir, nil
+	}
+	return filepath.Join(dir, "workspaces", name), nil
+}
+
+// Workspaces lists the named workspaces that exist, plus the default one
+func Workspaces(getenv func(string) string) ([]string, error) {
+	dir, err := DataDir(getenv)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{DefaultWorkspace}
+	entries, err := os.ReadDir(filepath.Join(dir, "workspaces"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const xdg = "/xdg"
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		wantPath      string
+		wantWorkspace string
+		wantUndo      string
+		wantArgs      []string
+		wantErr       bool
+	}{
+		{
+			name:          "default workspace",
+			args:          []string{"ls"},
+			wantPath:      filepath.Join(xdg, "quest_line", "quests.json"),
+			wantWorkspace: DefaultWorkspace,
+			wantArgs:      []string{"ls"},
+		},
+		{
+			name:          "workspace flag",
+			args:          []string{"-w", "personal", "ls"},
+			wantPath:      filepath.Join(xdg, "quest_line", "workspaces", "personal", "quests.json"),
+			wantWorkspace: "personal",
+			wantArgs:      []string{"ls"},
+		},
+		{
+			name:          "workspace flag overrides the data variable",
+			args:          []string{"-w", "personal", "ls"},
+			env:           map[string]string{EnvData: "/env/quests.json"},
+			wantPath:      filepath.Join(xdg, "quest_line", "workspaces", "personal", "quests.json"),
+			wantWorkspace: "personal",
+			wantArgs:      []string{"ls"},
+		},
+		{
+			name:     "data flag overrides the workspace variable",
+			args:     []string{"--data", "/flag/quests.json", "ls"},
+			env:      map[string]string{EnvWorkspace: "work"},
+			wantPath: "/flag/quests.json",
+			wantArgs: []string{"ls"},
+		},
+		{
+			name:     "data flag overrides the data variable",
+			args:     []string{"--data", "/flag/quests.json"},
+			env:      map[string]string{EnvData: "/env/quests.json"},
+			wantPath: "/flag/quests.json",
+		},
+		{
+			name:     "data variable wins over the workspace variable",
+			env:      map[string]string{EnvData: "/env/quests.json", EnvWorkspace: "work"},
+			wantPath: "/env/quests.json",
+		},
+		{
+			name:          "workspace variable",
+			env:           map[string]string{EnvWorkspace: "work"},
+			wantPath:      filepath.Join(xdg, "quest_line", "workspaces", "work", "quests.json"),
+			wantWorkspace: "work",
+		},
+		{
+			name:          "workspace flag overrides the workspace variable",
+			args:          []string{"--workspace", "personal"},
+			env:           map[string]string{EnvWorkspace: "work"},
+			wantPath:      filepath.Join(xdg, "quest_line", "workspaces", "personal", "quests.json"),
+			wantWorkspace: "personal",
+		},
+		{
+			name:          "bolt backend names its own file",
+			args:          []string{"--backend", "bolt"},
+			wantPath:      filepath.Join(xdg, "quest_line", "quests.db"),
+			wantWorkspace: DefaultWorkspace,
+		},
+		{
+			name:     "kept undo history sits beside the data file",
+			args:     []string{"--data", "/flag/quests.json", "--keep-undo"},
+			wantPath: "/flag/quests.json",
+			wantUndo: "/flag/quests.json.undo",
+		},
+		{
+			name:     "undo variable",
+			env:      map[string]string{EnvData: "/env/quests.json", EnvKeepUndo: "1"},
+			wantPath: "/env/quests.json",
+			wantUndo: "/env/quests.json.undo",
+		},
+		{
+			name:    "data and workspace flags together",
+			args:    []string{"--data", "/flag/quests.json", "-w", "personal"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid workspace name",
+			args:    []string{"-w", "../up"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid undo variable",
+			env:     map[string]string{EnvKeepUndo: "sometimes"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid backup count",
+			env:     map[string]string{EnvBackups: "many"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"--colour", "red"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		env := map[string]string{"XDG_DATA_HOME": xdg, "HOME": "/home/someone"}
+		for k, v := range tt.env {
+			env[k] = v
+		}
+		cfg, args, err := Parse(tt.args, func(name string) string { return env[name] })
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Parse succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Parse failed: %v", tt.name, err)
+			continue
+		}
+		if cfg.Storage.Path != tt.wantPath || cfg.Workspace != tt.wantWorkspace || cfg.UndoFile != tt.wantUndo {
+			t.Errorf("%s: path %q, workspace %q, undo %q; want %q, %q, %q", tt.name,
+				cfg.Storage.Path, cfg.Workspace, cfg.UndoFile, tt.wantPath, tt.wantWorkspace, tt.wantUndo)
+		}
+		if cfg.Activity.Path != cfg.Storage.Path+activitySuffix {
+			t.Errorf("%s: activity log %q, want it beside %q", tt.name, cfg.Activity.Path, cfg.Storage.Path)
+		}
+		if len(args) == 0 {
+			args = nil
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%s: remaining args %q, want %q", tt.name, args, tt.wantArgs)
+		}
+	}
+}
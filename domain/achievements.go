@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// KindAchievement marks XP log entries for unlocked achievements
+const KindAchievement = "achievement"
+
+// HighPriority is the lowest priority that counts as high priority
+const HighPriority = 7
+
+// Stats are the lifetime numbers achievement rules are checked against
+type Stats struct {
+	TasksDone          int
+	QuestsCompleted    int
+	HighPriorityQuests int // completed quests of HighPriority or more
+	Level              int
+	CurrentStreak      int // days in a row with a completed task, up to today
+	LongestStreak      int
+	TimeLogged         time.Duration
+	Overdue            int // open overdue tasks and quests
+	OverdueCleared     int // 1 once a change finishing work left nothing overdue
+}
+
+// Achievement is a goal unlocked once Metric reaches Target
+type Achievement struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	XP          int             `json:"xp"`
+	Target      int             `json:"target"`
+	Metric      func(Stats) int `json:"-"`
+}
+
+// Achievements is the rule table checked after every change
+var Achievements = []Achievement{
+	{ID: "first-task", Name: "First Steps", Description: "Complete your first task", XP: 10, Target: 1,
+		Metric: func(s Stats) int { return s.TasksDone }},
+	{ID: "tasks-50", Name: "Taskmaster", Description: "Complete 50 tasks", XP: 50, Target: 50,
+		Metric: func(s Stats) int { return s.TasksDone }},
+	{ID: "tasks-250", Name: "Unflappable", Description: "Complete 250 tasks", XP: 150, Target: 250,
+		Metric: func(s Stats) int { return s.TasksDone }},
+	{ID: "first-quest", Name: "Quest Complete", Description: "Complete your first quest", XP: 25, Target: 1,
+		Metric: func(s Stats) int { return s.QuestsCompleted }},
+	{ID: "high-priority-10", Name: "High Stakes", Description: "Finish 10 high-priority quests (priority 7+)", XP: 100, Target: 10,
+		Metric: func(s Stats) int { return s.HighPriorityQuests }},
+	{ID: "streak-3", Name: "On a Roll", Description: "Complete tasks 3 days in a row", XP: 15, Target: 3,
+		Metric: func(s Stats) int { return s.LongestStreak }},
+	{ID: "streak-7", Name: "Unstoppable", Description: "Complete tasks 7 days in a row", XP: 50, Target: 7,
+		Metric: func(s Stats) int { return s.LongestStreak }},
+	{ID: "streak-30", Name: "Creature of Habit", Description: "Complete tasks 30 days in a row", XP: 200, Target: 30,
+		Metric: func(s Stats) int { return s.LongestStreak }},
+	{ID: "overdue-cleared", Name: "All Caught Up", Description: "Clear all overdue work", XP: 25, Target: 1,
+		Metric: func(s Stats) int { return s.OverdueCleared }},
+	{ID: "level-5", Name: "Seasoned Adventurer", Description: "Reach level 5", XP: 0, Target: 5,
+		Metric: func(s Stats) int { return s.Level }},
+	{ID: "time-10h", Name: "Deep Work", Description: "Log 10 hours on tasks", XP: 50, Target: 10,
+		Metric: func(s Stats) int { return int(s.TimeLogged / time.Hour) }},
+}
+
+// FindAchievement returns the achievement with the given ID
+func FindAchievement(id string) (Achievement, bool) {
+	for _, a := range Achievements {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return Achievement{}, false
+}
+
+// Streak returns the current and longest runs of days with at least one
+// completed task. The current streak still counts when today has nothing
+// done yet but yesterday did.
+func Streak(log []XPEvent, now time.Time) (current, longest int) {
+	days := make(map[time.Time]bool)
+	for _, e := range log {
+		if e.Kind == KindTask {
+			days[civilDate(e.At.Local())] = true
+		}
+	}
+	sorted := make([]time.Time, 0, len(days))
+	for d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	run := 0
+	for i, d := range sorted {
+		if i > 0 && sorted[i-1].AddDate(0, 0, 1).Equal(d) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	today := civilDate(now.Local())
+	for d := today; days[d] || d.Equal(today); d = d.AddDate(0, 0, -1) {
+		if days[d] {
+			current++
+		}
+	}
+	return current, longest
+}
+
+// FormatStreak renders a streak as e.g. "3 days"
+func FormatStreak(days int) string {
+	switch days {
+	case 0:
+		return "no streak yet"
+	case 1:
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// ComputeStats gathers the stats of the XP log and every project.
+// OverdueCleared is only known for a change and is left for the caller to
+// set.
+func ComputeStats(projects []Project, log []XPEvent, now time.Time) Stats {
+	var s Stats
+	xp := 0
+	for _, e := range log {
+		xp += e.XP()
+		switch e.Kind {
+		case KindTask:
+			s.TasksDone++
+		case KindQuest:
+			s.QuestsCompleted++
+			if e.Priority >= HighPriority {
+				s.HighPriorityQuests++
+			}
+		case KindAchievement:
+			if e.ItemID == "overdue-cleared" {
+				s.OverdueCleared = 1
+			}
+		}
+	}
+	for _, p := range projects {
+		s.TimeLogged += p.TimeSpent(now)
+		quests, tasks := overdueCounts(p.Quests, now)
+		s.Overdue += quests + tasks
+	}
+	s.Level, _, _ = LevelFor(xp)
+	s.CurrentStreak, s.LongestStreak = Streak(log, now)
+	return s
+}
+
+// unlockedIDs returns the IDs of the achievements already unlocked
+func unlockedIDs(log []XPEvent) map[string]bool {
+	ids := make(map[string]bool)
+	for _, e := range log {
+		if e.Kind == KindAchievement {
+			ids[e.ItemID] = true
+		}
+	}
+	return ids
+}
+
+// unlockAchievements checks the rules after a change and logs newly reached
+// achievements. before holds the stats from just before the change.
+func (s *Store) unlockAchievements(before Stats, now time.Time) {
+	unlocked := unlockedIDs(s.xp)
+	// An unlock's XP can raise the level and unlock another, so repeat
+	// until nothing new is reached
+	for {
+		stats := ComputeStats(s.projects, s.xp, now)
+		finished := stats.TasksDone+stats.QuestsCompleted > before.TasksDone+before.QuestsCompleted
+		if before.Overdue > 0 && stats.Overdue == 0 && finished {
+			stats.OverdueCleared = 1
+		}
+		reached := false
+		for _, a := range Achievements {
+			if unlocked[a.ID] || a.Metric(stats) < a.Target {
+				continue
+			}
+			unlocked[a.ID] = true
+			reached = true
+			s.award(XPEvent{At: now, Kind: KindAchievement, ItemID: a.ID, Title: a.Name})
+			s.unlocked = append(s.unlocked, a)
+		}
+		if !reached {
+			return
+		}
+	}
+}
+
+// TakeUnlocked returns the achievements unlocked since the last call
+func (s *Store) TakeUnlocked() []Achievement {
+	unlocked := s.unlocked
+	s.unlocked = nil
+	return unlocked
+}
+
+// AchievementStatus is an achievement with how far along it is
+type AchievementStatus struct {
+	Achievement
+	Progress   int        `json:"progress"`
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+}
+
+// AchievementProgress lists every achievement with its progress and, for
+// unlocked ones, when it was reached
+func AchievementProgress(projects []Project, log []XPEvent, now time.Time) []AchievementStatus {
+	unlockedAt := make(map[string]time.Time)
+	for _, e := range log {
+		if e.Kind == KindAchievement {
+			unlockedAt[e.ItemID] = e.At
+		}
+	}
+	stats := ComputeStats(projects, log, now)
+	list := make([]AchievementStatus, len(Achievements))
+	for i, a := range Achievements {
+		list[i] = AchievementStatus{Achievement: a, Progress: a.Metric(stats)}
+		if at, ok := unlockedAt[a.ID]; ok {
+			list[i].UnlockedAt = &at
+			list[i].Progress = a.Target
+		} else if list[i].Progress > a.Target {
+			list[i].Progress = a.Target
+		}
+	}
+	return list
+}
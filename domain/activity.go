@@ -0,0 +1,350 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Operations recorded in the activity log
+const (
+	OpCreated = "created"
+	OpUpdated = "updated"
+	OpDeleted = "deleted"
+)
+
+// FieldChange is one field of an entity before and after a change. Before
+// is empty for created entities and After for deleted ones.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Activity records how one project, quest or task was changed by a mutation.
+// A mutation that touches several entities writes one entry for each.
+type Activity struct {
+	At        time.Time     `json:"at"`
+	Actor     string        `json:"actor"`
+	Action    string        `json:"action"` // the change's label, e.g. `add task "Buy milk"`
+	Op        string        `json:"op"`     // OpCreated, OpUpdated or OpDeleted
+	Kind      string        `json:"kind"`   // KindProject, KindQuest or KindTask
+	ID        string        `json:"id"`
+	Title     string        `json:"title"`
+	ProjectID string        `json:"project_id"`
+	Quests    []string      `json:"quests,omitempty"` // the quests containing the entity, innermost first
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+// InQuest reports whether the entry is about a quest or anything inside it
+func (a Activity) InQuest(questID string) bool {
+	if a.Kind == KindQuest && a.ID == questID {
+		return true
+	}
+	for _, id := range a.Quests {
+		if id == questID {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes the entry in a few words, e.g. `updated task "Buy milk"`
+func (a Activity) Summary() string {
+	return fmt.Sprintf("%s %s %q", a.Op, a.Kind, a.Title)
+}
+
+// String renders the change as `field: before → after`, with long values
+// cut short
+func (c FieldChange) String() string {
+	switch {
+	case len(c.Before) == 0:
+		return fmt.Sprintf("%s: %s", c.Field, shortValue(c.After))
+	case len(c.After) == 0:
+		return fmt.Sprintf("%s: %s (removed)", c.Field, shortValue(c.Before))
+	}
+	return fmt.Sprintf("%s: %s → %s", c.Field, shortValue(c.Before), shortValue(c.After))
+}
+
+// shortValue returns a JSON value of at most 40 characters
+func shortValue(v json.RawMessage) string {
+	const limit = 40
+	runes := []rune(string(v))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// ActivityLog is an append-only JSON Lines file of activity entries
+type ActivityLog struct {
+	Path  string // empty disables the log
+	Actor string // recorded as the author of every change
+}
+
+// Append writes entries to the end of the log, one JSON object per line
+func (l ActivityLog) Append(entries []Activity) error {
+	if l.Path == "" || len(entries) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, a := range entries {
+		if err := enc.Encode(a); err != nil {
+			return err
+		}
+	}
+	if dir := filepath.Dir(l.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns every entry in the log, oldest first. A missing file is an
+// empty log.
+func (l ActivityLog) Read() ([]Activity, error) {
+	if l.Path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(l.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Activity
+	scanner := bufio.NewScanner(f)
+	// Entries carry whole field values, such as a task's time entries
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var a Activity
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			return nil, fmt.Errorf("read activity log %s line %d: %w", l.Path, line, err)
+		}
+		entries = append(entries, a)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read activity log %s: %w", l.Path, err)
+	}
+	return entries, nil
+}
+
+// FilterActivity returns the entries about a project, or about a quest and
+// what is inside it; empty IDs match everything
+func FilterActivity(entries []Activity, projectID, questID string) []Activity {
+	var matched []Activity
+	for _, a := range entries {
+		if projectID != "" && a.ProjectID != projectID {
+			continue
+		}
+		if questID != "" && !a.InQuest(questID) {
+			continue
+		}
+		matched = append(matched, a)
+	}
+	return matched
+}
+
+// entityState is a project, quest or task as the activity diff sees it
+type entityState struct {
+	kind      string
+	id        string
+	title     string
+	projectID string
+	quests    []string
+	fields    map[string]json.RawMessage
+}
+
+// key identifies an entity across the projects of a change
+func (e entityState) key() string {
+	return e.kind + ":" + e.id
+}
+
+// fieldsOf flattens an entity to its JSON fields, leaving out those named
+// in skip. Child lists are cleared by the caller so they are not copied.
+func fieldsOf(v interface{}, skip ...string) map[string]json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("diff %T: %v", v, err))
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		panic(fmt.Sprintf("diff %T: %v", v, err))
+	}
+	for _, name := range skip {
+		delete(fields, name)
+	}
+	return fields
+}
+
+// collectEntities lists a project and everything in it in tree order.
+// Derived values and logs kept elsewhere are left out of the fields.
+func collectEntities(p *Project) []entityState {
+	if p == nil {
+		return nil
+	}
+	shallow := *p
+	shallow.Quests, shallow.Events = nil, nil
+	entities := []entityState{{kind: KindProject, id: p.ID, title: p.Name, projectID: p.ID,
+		fields: fieldsOf(shallow, "quests", "progress")}}
+	var walk func(quests []Quest, parents []string)
+	walk = func(quests []Quest, parents []string) {
+		for _, q := range quests {
+			inner := append([]string{q.ID}, parents...)
+			shallow := q
+			shallow.Tasks, shallow.SubQuests, shallow.History = nil, nil, nil
+			fields := fieldsOf(shallow, "tasks", "progress")
+			// States are stored as numbers; the log names them
+			fields["state"] = mustJSON(q.State.String())
+			entities = append(entities, entityState{kind: KindQuest, id: q.ID, title: q.Title, projectID: p.ID,
+				quests: parents, fields: fields})
+			for _, t := range q.Tasks {
+				entities = append(entities, entityState{kind: KindTask, id: t.ID, title: t.Description, projectID: p.ID,
+					quests: inner, fields: fieldsOf(t)})
+			}
+			walk(q.SubQuests, inner)
+		}
+	}
+	walk(p.Quests, nil)
+	return entities
+}
+
+// diffFields compares two field sets, adding where the entity lives when
+// that changed
+func diffFields(before, after entityState) []FieldChange {
+	var names []string
+	for name := range before.fields {
+		names = append(names, name)
+	}
+	for name := range after.fields {
+		if _, ok := before.fields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var changes []FieldChange
+	for _, name := range names {
+		b, a := before.fields[name], after.fields[name]
+		if !bytes.Equal(b, a) {
+			changes = append(changes, FieldChange{Field: name, Before: b, After: a})
+		}
+	}
+	if before.projectID != after.projectID {
+		changes = append(changes, FieldChange{Field: "project", Before: mustJSON(before.projectID), After: mustJSON(after.projectID)})
+	}
+	if parentOf(before) != parentOf(after) {
+		changes = append(changes, FieldChange{Field: "parent", Before: mustJSON(parentOf(before)), After: mustJSON(parentOf(after))})
+	}
+	return changes
+}
+
+// parentOf returns the quest directly containing an entity, or ""
+func parentOf(e entityState) string {
+	if len(e.quests) == 0 {
+		return ""
+	}
+	return e.quests[0]
+}
+
+// mustJSON encodes a plain value that cannot fail to encode
+func mustJSON(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// activityFor turns the project snapshots of a change into activity entries.
+// With undo set the change is being reverted, so After becomes Before.
+// Entities moved between the change's projects are reported as updated.
+func activityFor(label string, pcs []ProjectChange, undo bool, actor string, now time.Time) []Activity {
+	var before, after []entityState
+	for _, pc := range pcs {
+		from, to := pc.Before, pc.After
+		if undo {
+			from, to = to, from
+		}
+		before = append(before, collectEntities(from)...)
+		after = append(after, collectEntities(to)...)
+	}
+	old := make(map[string]entityState, len(before))
+	for _, e := range before {
+		old[e.key()] = e
+	}
+	entry := func(op string, e entityState, changes []FieldChange) Activity {
+		return Activity{At: now, Actor: actor, Action: label, Op: op, Kind: e.kind, ID: e.id, Title: e.title,
+			ProjectID: e.projectID, Quests: e.quests, Changes: changes}
+	}
+	var entries []Activity
+	seen := make(map[string]bool, len(after))
+	for _, e := range after {
+		seen[e.key()] = true
+		was, existed := old[e.key()]
+		if !existed {
+			var changes []FieldChange
+			for _, name := range sortedFields(e.fields) {
+				changes = append(changes, FieldChange{Field: name, After: e.fields[name]})
+			}
+			entries = append(entries, entry(OpCreated, e, changes))
+			continue
+		}
+		if changes := diffFields(was, e); len(changes) > 0 {
+			entries = append(entries, entry(OpUpdated, e, changes))
+		}
+	}
+	for _, e := range before {
+		if seen[e.key()] {
+			continue
+		}
+		var changes []FieldChange
+		for _, name := range sortedFields(e.fields) {
+			changes = append(changes, FieldChange{Field: name, Before: e.fields[name]})
+		}
+		entries = append(entries, entry(OpDeleted, e, changes))
+	}
+	return entries
+}
+
+// sortedFields returns the field names in order
+func sortedFields(fields map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetActor names who is making changes, for the activity log
+func (s *Store) SetActor(actor string) {
+	s.actor = actor
+}
+
+// TakeActivity returns the activity recorded since the last call, for
+// appending to the log once the change is saved
+func (s *Store) TakeActivity() []Activity {
+	activity := s.activity
+	s.activity = nil
+	return activity
+}
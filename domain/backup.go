@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBackupCount is how many previous versions JSONStorage keeps
+const DefaultBackupCount = 5
+
+// backupTimeFormat sorts lexically in time order
+const backupTimeFormat = "20060102T150405.000000000"
+
+// ErrBackupsUnsupported is returned when a backend has no backup support
+var ErrBackupsUnsupported = errors.New("storage backend does not support backups")
+
+// Backup describes one saved version of the data file
+type Backup struct {
+	Name string
+	Path string
+	Time time.Time
+	Size int64
+}
+
+// BackupStorage is implemented by storage backends that keep rolling backups
+type BackupStorage interface {
+	Backups() ([]Backup, error)
+	Restore(name string) error
+}
+
+// backupDir returns the directory holding backups of a data file
+func backupDir(path string) string {
+	return path + ".backups"
+}
+
+// backupCurrent copies the current data file into the backup directory and
+// prunes old backups down to keep. A missing data file is not an error.
+func backupCurrent(path string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	name := filepath.Base(path) + "." + time.Now().UTC().Format(backupTimeFormat)
+	if err := writeFileAtomic(filepath.Join(backupDir(path), name), data, 0644); err != nil {
+		return err
+	}
+	return pruneBackups(path, keep)
+}
+
+// listBackups returns the backups of a data file, newest first
+func listBackups(path string) ([]Backup, error) {
+	entries, err := os.ReadDir(backupDir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return nil, err
+	}
+	prefix := filepath.Base(path) + "."
+	backups := []Backup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, strings.TrimPrefix(name, prefix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, Backup{
+			Name: name,
+			Path: filepath.Join(backupDir(path), name),
+			Time: t,
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// pruneBackups removes all but the newest keep backups
+func pruneBackups(path string, keep int) error {
+	backups, err := listBackups(path)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreBackup replaces the data file with the named backup. The current
+// file is backed up first so a restore can itself be rolled back.
+func restoreBackup(path, name string, keep int) error {
+	backups, err := listBackups(path)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups {
+		if b.Name != name {
+			continue
+		}
+		data, err := os.ReadFile(b.Path)
+		if err != nil {
+			return err
+		}
+		// Keep one extra slot so the backup being restored survives pruning
+		if err := backupCurrent(path, keep+1); err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0644)
+	}
+	return fmt.Errorf("backup %q not found", name)
+}
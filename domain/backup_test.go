@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "quests.json")
+	for _, contents := range []string{"first", "second, longer"} {
+		if err := writeFileAtomic(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("writing %q failed: %v", contents, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != contents {
+			t.Errorf("file holds %q, want %q", got, contents)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("mode = %v, want 0600", perm)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want only the data file and no temp files", len(entries))
+	}
+}
+
+// backupContents returns the contents of each backup of path, newest first
+func backupContents(t *testing.T, path string) []string {
+	t.Helper()
+	backups, err := listBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, b := range backups {
+		data, err := os.ReadFile(b.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(data))
+	}
+	return contents
+}
+
+// writeVersions writes each version over path, backing up the one before
+func writeVersions(t *testing.T, path string, keep int, versions ...string) {
+	t.Helper()
+	for _, v := range versions {
+		if err := backupCurrent(path, keep); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFileAtomic(path, []byte(v), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackupCurrentPrunes(t *testing.T) {
+	tests := []struct {
+		name     string
+		keep     int
+		versions []string
+		want     []string
+	}{
+		{name: "no data file yet", keep: 3, versions: []string{"v1"}},
+		{name: "fewer than kept", keep: 3, versions: []string{"v1", "v2", "v3"}, want: []string{"v2", "v1"}},
+		{name: "oldest pruned", keep: 2, versions: []string{"v1", "v2", "v3", "v4", "v5"}, want: []string{"v4", "v3"}},
+		{name: "disabled", keep: 0, versions: []string{"v1", "v2"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "quests.json")
+		writeVersions(t, path, tt.keep, tt.versions...)
+		got := backupContents(t, path)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: backups = %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: backups = %q, want %q", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	const keep = 3
+	path := filepath.Join(t.TempDir(), "quests.json")
+	writeVersions(t, path, keep, "v1", "v2", "v3", "v4")
+	backups, err := listBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != keep {
+		t.Fatalf("%d backups, want %d", len(backups), keep)
+	}
+
+	// The oldest backup is the first to go when pruning, so restoring it
+	// needs the extra slot
+	oldest := backups[len(backups)-1]
+	if err := restoreBackup(path, oldest.Name, keep); err != nil {
+		t.Fatalf("restoreBackup failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "v1" {
+		t.Errorf("data file holds %q after the restore, want v1", data)
+	}
+	got := backupContents(t, path)
+	if len(got) != keep+1 || got[0] != "v4" || got[keep] != "v1" {
+		t.Errorf("backups = %q, want v4 saved first and v1 still kept", got)
+	}
+
+	if err := restoreBackup(path, "quests.json.20000101T000000.000000000", keep); err == nil {
+		t.Errorf("restoring a missing backup succeeded")
+	}
+}
+
+func TestJSONStorageBacksUpOncePerInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quests.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1, "projects": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewJSONStorage(path)
+	save := func() {
+		t.Helper()
+		encoded, err := EncodeData(Data{Projects: []Project{{ID: "p1", Name: "A", Quests: []Quest{}}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Save(encoded); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	save()
+	save()
+	save()
+	if got := backupContents(t, path); len(got) != 1 {
+		t.Fatalf("%d backups after three quick saves, want 1 from the first", len(got))
+	}
+
+	s.lastBackup = time.Now().Add(-backupInterval)
+	save()
+	if got := backupContents(t, path); len(got) != 2 {
+		t.Errorf("%d backups once the interval passed, want 2", len(got))
+	}
+
+	// A new session backs up on its first save
+	next := NewJSONStorage(path)
+	encoded, err := EncodeData(Data{Projects: []Project{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := next.Save(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := backupContents(t, path); len(got) != 3 {
+		t.Errorf("%d backups after a new session saved, want 3", len(got))
+	}
+}
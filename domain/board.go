@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BoardColumn is one column of a project's board, holding quests in State
+type BoardColumn struct {
+	Name  string     `json:"name"`
+	State QuestState `json:"state"`
+}
+
+// DefaultBoardColumns are used by projects without columns of their own
+func DefaultBoardColumns() []BoardColumn {
+	return []BoardColumn{
+		{Name: "Todo", State: StateActive},
+		{Name: "In Progress", State: StateInProgress},
+		{Name: "Done", State: StateCompleted},
+		{Name: "Cancelled", State: StateCancelled},
+	}
+}
+
+// Columns returns the project's board columns, or the default ones
+func (p Project) Columns() []BoardColumn {
+	if len(p.BoardColumns) > 0 {
+		return p.BoardColumns
+	}
+	return DefaultBoardColumns()
+}
+
+// stateNames maps the state names accepted in column specs
+var stateNames = map[string]QuestState{
+	"todo":        StateActive,
+	"active":      StateActive,
+	"in-progress": StateInProgress,
+	"doing":       StateInProgress,
+	"done":        StateCompleted,
+	"completed":   StateCompleted,
+	"cancelled":   StateCancelled,
+}
+
+// ParseBoardColumns reads a comma-separated list of Name=state columns such
+// as "Backlog=todo, Doing=in-progress, Review=in-progress, Done=done". The
+// states are todo, in-progress, done and cancelled.
+func ParseBoardColumns(s string) ([]BoardColumn, error) {
+	var columns []BoardColumn
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, stateName, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid column %q (use Name=state)", part)
+		}
+		state, ok := stateNames[strings.ToLower(strings.TrimSpace(stateName))]
+		if !ok {
+			return nil, fmt.Errorf("unknown state %q for column %q (use todo, in-progress, done or cancelled)", strings.TrimSpace(stateName), name)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("column %q is listed twice", name)
+		}
+		seen[strings.ToLower(name)] = true
+		columns = append(columns, BoardColumn{Name: name, State: state})
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns given")
+	}
+	return columns, nil
+}
+
+// FormatBoardColumns renders columns in the form ParseBoardColumns reads
+func FormatBoardColumns(columns []BoardColumn) string {
+	parts := make([]string, len(columns))
+	for i, c := range columns {
+		state := "todo"
+		switch c.State {
+		case StateInProgress:
+			state = "in-progress"
+		case StateCompleted:
+			state = "done"
+		case StateCancelled:
+			state = "cancelled"
+		}
+		parts[i] = c.Name + "=" + state
+	}
+	return strings.Join(parts, ", ")
+}
+
+// columnIndex returns the column a quest sits in: the one it was moved to,
+// or else the first column for its state. It is -1 when no column shows
+// the quest's state.
+func columnIndex(columns []BoardColumn, q Quest) int {
+	first := -1
+	for i, c := range columns {
+		if c.State != q.State {
+			continue
+		}
+		if strings.EqualFold(c.Name, q.Column) {
+			return i
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	return first
+}
+
+// Card is a quest placed on a board
+type Card struct {
+	Quest
+	Path    string `json:"path,omitempty"` // titles of the parent quests
+	Overdue bool   `json:"overdue,omitempty"`
+}
+
+// Board holds a project's quests sorted into its columns
+type Board struct {
+	Columns []BoardColumn `json:"columns"`
+	Cards   [][]Card      `json:"cards"` // one list per column
+}
+
+// collectCards places every quest of a tree that has a column on the board
+func collectCards(board *Board, quests []Quest, path string, now time.Time) {
+	for _, q := range quests {
+		if i := columnIndex(board.Columns, q); i >= 0 {
+			board.Cards[i] = append(board.Cards[i], Card{Quest: q, Path: path, Overdue: q.State.IsOpen() && IsOverdue(q.Deadline, now)})
+		}
+		sub := q.Title
+		if path != "" {
+			sub = path + " › " + q.Title
+		}
+		collectCards(board, q.SubQuests, sub, now)
+	}
+}
+
+// BuildBoard sorts a project's quests, at any depth, into its columns.
+// Archived quests and quests whose state has no column are left off.
+func BuildBoard(p Project, now time.Time) Board {
+	board := Board{Columns: p.Columns()}
+	board.Cards = make([][]Card, len(board.Columns))
+	for i := range board.Cards {
+		board.Cards[i] = []Card{}
+	}
+	collectCards(&board, p.Quests, "", now)
+	for _, cards := range board.Cards {
+		sortCards(cards)
+	}
+	return board
+}
+
+// sortCards orders cards by priority (higher first) and deadline (soonest first)
+func sortCards(cards []Card) {
+	quests := make([]Quest, len(cards))
+	index := make(map[string]Card, len(cards))
+	for i, c := range cards {
+		quests[i] = c.Quest
+		index[c.ID] = c
+	}
+	sortForPlanning(quests)
+	for i, q := range quests {
+		cards[i] = index[q.ID]
+	}
+}
+
+// SetBoardColumns replaces a project's board columns. No columns restores
+// the default ones.
+func (s *Store) SetBoardColumns(projectID string, columns []BoardColumn) error {
+	p, err := s.Project(projectID)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("set board columns of project %q", p.Name), []string{projectID}, func() error {
+		p.BoardColumns = columns
+		return nil
+	})
+}
+
+// MoveToColumn moves a quest to a board column of its project, changing the
+// quest's state to the column's. Column names match case-insensitively.
+func (s *Store) MoveToColumn(questID, column string) error {
+	loc, err := s.locateQuest(questID)
+	if err != nil {
+		return err
+	}
+	var target *BoardColumn
+	columns := loc.project.Columns()
+	for i := range columns {
+		if strings.EqualFold(columns[i].Name, column) {
+			target = &columns[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("project %q has no column %q", loc.project.Name, column)
+	}
+	q := loc.quest()
+	if q.State != target.State && !CanTransition(q.State, target.State) {
+		return &TransitionError{From: q.State, To: target.State}
+	}
+	return s.mutate(fmt.Sprintf("move quest %q to %s", q.Title, target.Name), []string{loc.project.ID}, func() error {
+		if q.State != target.State {
+			if err := s.changeState(loc, target.State, time.Now()); err != nil {
+				return err
+			}
+		}
+		// A recurring quest's next occurrence may have moved the siblings
+		loc.quest().Column = target.Name
+		return nil
+	})
+}
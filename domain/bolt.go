@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	projectsBucket = []byte("projects")
+	metaBucket     = []byte("meta")
+	orderKey       = []byte("order")
+	versionKey     = []byte("version")
+	xpLogKey       = []byte("xp_log")
+)
+
+// BoltStorage keeps each project under its ID in an embedded bbolt database.
+// Save only rewrites projects whose encoding changed, so toggling a task
+// touches one record instead of the whole data set.
+type BoltStorage struct {
+	mu      sync.Mutex
+	db      *bolt.DB
+	written map[string][]byte // last persisted encoding per project ID
+}
+
+// OpenBoltStorage opens or creates a bbolt database at path
+func OpenBoltStorage(path string) (*BoltStorage, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(projectsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStorage{db: db, written: make(map[string][]byte)}, nil
+}
+
+// Load reads all projects in their saved order and the XP log. Records from
+// an older schema version are migrated and rewritten on the next Save.
+func (s *BoltStorage) Load() (Data, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version := 1 // databases created before versioning hold version 1 records
+	var records []interface{}
+	var xpLog interface{}
+	written := make(map[string][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if raw := meta.Get(versionKey); raw != nil {
+			if err := json.Unmarshal(raw, &version); err != nil {
+				return err
+			}
+		}
+		if raw := meta.Get(xpLogKey); raw != nil {
+			if err := json.Unmarshal(raw, &xpLog); err != nil {
+				return err
+			}
+		}
+		var order []string
+		if raw := meta.Get(orderKey); raw != nil {
+			if err := json.Unmarshal(raw, &order); err != nil {
+				return err
+			}
+		}
+		bucket := tx.Bucket(projectsBucket)
+		for _, id := range order {
+			raw := bucket.Get([]byte(id))
+			if raw == nil {
+				continue
+			}
+			var record interface{}
+			if err := json.Unmarshal(raw, &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+			written[id] = append([]byte(nil), raw...)
+		}
+		return nil
+	})
+	if err != nil {
+		return Data{}, err
+	}
+
+	doc := map[string]interface{}{
+		"version":  float64(version),
+		"projects": records,
+	}
+	if xpLog != nil {
+		doc["xp_log"] = xpLog
+	}
+	d, err := migrateDocument(doc)
+	if err != nil {
+		return Data{}, err
+	}
+	if version != CurrentSchemaVersion {
+		// Force every record to be rewritten in the current format
+		written = make(map[string][]byte)
+	}
+	s.written = written
+	return d, nil
+}
+
+// Save writes changed projects, removes deleted ones and records the order
+// and the XP log
+func (s *BoltStorage) Save(d EncodedData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encoded := make(map[string][]byte, len(d.projects))
+	order := make([]string, 0, len(d.projects))
+	for _, p := range d.projects {
+		encoded[p.id] = p.raw
+		order = append(order, p.id)
+	}
+	rawOrder, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(projectsBucket)
+		for id, raw := range encoded {
+			if bytes.Equal(s.written[id], raw) {
+				continue
+			}
+			if err := bucket.Put([]byte(id), raw); err != nil {
+				return err
+			}
+		}
+		for id := range s.written {
+			if _, ok := encoded[id]; !ok {
+				if err := bucket.Delete([]byte(id)); err != nil {
+					return err
+				}
+			}
+		}
+		meta := tx.Bucket(metaBucket)
+		if err := meta.Put(versionKey, []byte(strconv.Itoa(CurrentSchemaVersion))); err != nil {
+			return err
+		}
+		if d.xpLog == nil {
+			if err := meta.Delete(xpLogKey); err != nil {
+				return err
+			}
+		} else if !bytes.Equal(meta.Get(xpLogKey), d.xpLog) {
+			if err := meta.Put(xpLogKey, d.xpLog); err != nil {
+				return err
+			}
+		}
+		if bytes.Equal(meta.Get(orderKey), rawOrder) {
+			return nil
+		}
+		return meta.Put(orderKey, rawOrder)
+	})
+	if err != nil {
+		return err
+	}
+	s.written = encoded
+	return nil
+}
+
+// Close releases the database file lock
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CalendarItem is a quest or task placed on the day it is due
+type CalendarItem struct {
+	Kind       string    `json:"kind"` // KindQuest or KindTask
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	QuestID    string    `json:"quest_id"`              // the quest itself, or the task's quest
+	QuestTitle string    `json:"quest_title,omitempty"` // set for tasks
+	ProjectID  string    `json:"project_id"`
+	Due        time.Time `json:"due"` // midnight UTC of the due day, like deadlines
+	Priority   int       `json:"priority,omitempty"`
+	Done       bool      `json:"done,omitempty"` // a finished quest, or a task done or in a finished quest
+	Overdue    bool      `json:"overdue,omitempty"`
+}
+
+// Day returns the calendar day of t in t's location, in the form deadlines
+// and CalendarItem.Due are stored in
+func Day(t time.Time) time.Time {
+	return civilDate(t)
+}
+
+// collectCalendar appends the quests and tasks of a tree that are due
+// within [from, to]
+func collectCalendar(projectID string, quests []Quest, from, to, now time.Time, items *[]CalendarItem) {
+	inRange := func(d *time.Time) bool {
+		if d == nil {
+			return false
+		}
+		day := civilDate(*d)
+		return (from.IsZero() || !day.Before(from)) && (to.IsZero() || !day.After(to))
+	}
+	for _, q := range quests {
+		if q.State == StateArchived {
+			continue
+		}
+		finished := !q.State.IsOpen()
+		if inRange(q.Deadline) {
+			*items = append(*items, CalendarItem{Kind: KindQuest, ID: q.ID, Title: q.Title, QuestID: q.ID, ProjectID: projectID,
+				Due: civilDate(*q.Deadline), Priority: q.Priority, Done: finished, Overdue: !finished && IsOverdue(q.Deadline, now)})
+		}
+		for _, t := range q.Tasks {
+			if inRange(t.Deadline) {
+				done := t.Done || finished
+				*items = append(*items, CalendarItem{Kind: KindTask, ID: t.ID, Title: t.Description, QuestID: q.ID, QuestTitle: q.Title, ProjectID: projectID,
+					Due: civilDate(*t.Deadline), Priority: t.Priority, Done: done, Overdue: !done && IsOverdue(t.Deadline, now)})
+			}
+		}
+		collectCalendar(projectID, q.SubQuests, from, to, now, items)
+	}
+}
+
+// CalendarItems returns the quests and tasks due from from to to, both
+// days included, ordered by day, then quests before tasks and by priority.
+// A zero from or to leaves that end open, and an empty projectID covers
+// every project. Archived quests are left out.
+func CalendarItems(projects []Project, projectID string, from, to, now time.Time) []CalendarItem {
+	var items []CalendarItem
+	for _, p := range projects {
+		if projectID == "" || p.ID == projectID {
+			collectCalendar(p.ID, p.Quests, from, to, now, &items)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].Due.Equal(items[j].Due) {
+			return items[i].Due.Before(items[j].Due)
+		}
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind == KindQuest
+		}
+		return items[i].Priority > items[j].Priority
+	})
+	return items
+}
+
+// Reschedule moves the deadline of a quest or task, whichever id names, to day
+func (s *Store) Reschedule(id string, day time.Time) error {
+	due := civilDate(day)
+	if loc, err := s.locateQuest(id); err == nil {
+		q := loc.quest()
+		return s.mutate(fmt.Sprintf("reschedule quest %q to %s", q.Title, due.Format("2006-01-02")), []string{loc.project.ID}, func() error {
+			q.Deadline = &due
+			return nil
+		})
+	}
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return err
+	}
+	t := &loc.quest.Tasks[loc.index]
+	return s.mutate(fmt.Sprintf("reschedule task %q to %s", t.Description, due.Format("2006-01-02")), []string{loc.project.ID}, func() error {
+		t.Deadline = &due
+		return nil
+	})
+}
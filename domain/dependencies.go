@@ -0,0 +1,361 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned when a blocker cannot be added
+var (
+	ErrSelfDependency         = errors.New("cannot be blocked by itself")
+	ErrCrossProjectDependency = errors.New("a blocker must be in the same project")
+)
+
+// CycleError reports a blocker that would leave work waiting on itself
+type CycleError struct {
+	Kind    string // KindQuest or KindTask
+	Item    string
+	Blocker string
+}
+
+// Error implements error interface
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%s %q cannot be blocked by %q: %q already waits on it", e.Kind, e.Item, e.Blocker, e.Blocker)
+}
+
+// questWaits records what each quest in a tree waits on: its own blockers,
+// those it inherits from its ancestors, and its sub-quests
+func questWaits(quests []Quest, inherited []string, waits map[string][]string) {
+	for _, q := range quests {
+		blockers := append(append([]string(nil), inherited...), q.BlockedBy...)
+		edges := append([]string(nil), blockers...)
+		for _, sub := range q.SubQuests {
+			edges = append(edges, sub.ID)
+		}
+		waits[q.ID] = edges
+		questWaits(q.SubQuests, blockers, waits)
+	}
+}
+
+// taskWaits records the blockers of each task in a tree
+func taskWaits(quests []Quest, waits map[string][]string) {
+	for _, q := range quests {
+		for _, t := range q.Tasks {
+			waits[t.ID] = t.BlockedBy
+		}
+		taskWaits(q.SubQuests, waits)
+	}
+}
+
+// reaches reports whether any target can be reached from start, including
+// start itself
+func reaches(waits map[string][]string, start string, targets map[string]bool) bool {
+	seen := make(map[string]bool)
+	stack := []string{start}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if targets[id] {
+			return true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		stack = append(stack, waits[id]...)
+	}
+	return false
+}
+
+// questIDs adds the ID of q and every quest below it to ids
+func questIDs(q *Quest, ids map[string]bool) {
+	ids[q.ID] = true
+	for i := range q.SubQuests {
+		questIDs(&q.SubQuests[i], ids)
+	}
+}
+
+// moveMakesCycle reports whether moving q under the quest parentID in p
+// would leave work waiting on itself, as when q blocks one of its new
+// parents or inherits a blocker that waits on q
+func moveMakesCycle(p *Project, q Quest, parentID string) bool {
+	dest := cloneProject(p)
+	if loc, ok := locateQuestIn(dest, nil, &dest.Quests, q.ID); ok {
+		*loc.siblings = append((*loc.siblings)[:loc.index], (*loc.siblings)[loc.index+1:]...)
+	}
+	parentLoc, ok := locateQuestIn(dest, nil, &dest.Quests, parentID)
+	if !ok {
+		return false
+	}
+	parent := parentLoc.quest()
+	parent.SubQuests = append(parent.SubQuests, q)
+
+	waits := make(map[string][]string)
+	questWaits(dest.Quests, nil, waits)
+	moved := make(map[string]bool)
+	questIDs(&q, moved)
+	for id := range moved {
+		for _, next := range waits[id] {
+			if reaches(waits, next, map[string]bool{id: true}) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddQuestBlocker marks a quest as blocked by another quest in the same
+// project. It fails if the blocker already waits on the quest, directly or
+// through sub-quests, which also rules out its own parents and sub-quests.
+func (s *Store) AddQuestBlocker(id, blockerID string) error {
+	if id == blockerID {
+		return ErrSelfDependency
+	}
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return err
+	}
+	blockerLoc, err := s.locateQuest(blockerID)
+	if err != nil {
+		return err
+	}
+	if blockerLoc.project != loc.project {
+		return ErrCrossProjectDependency
+	}
+	q, blocker := loc.quest(), blockerLoc.quest()
+	if containsID(q.BlockedBy, blockerID) {
+		return nil
+	}
+	waits := make(map[string][]string)
+	questWaits(loc.project.Quests, nil, waits)
+	// The quest's sub-quests would inherit the new blocker too
+	targets := make(map[string]bool)
+	questIDs(q, targets)
+	if reaches(waits, blockerID, targets) {
+		return &CycleError{Kind: KindQuest, Item: q.Title, Blocker: blocker.Title}
+	}
+	return s.mutate(fmt.Sprintf("block quest %q on %q", q.Title, blocker.Title), []string{loc.project.ID}, func() error {
+		q.BlockedBy = append(q.BlockedBy, blockerID)
+		return nil
+	})
+}
+
+// RemoveQuestBlocker drops a blocker from a quest. Removing one the quest
+// does not have is a no-op.
+func (s *Store) RemoveQuestBlocker(id, blockerID string) error {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return err
+	}
+	q := loc.quest()
+	if !containsID(q.BlockedBy, blockerID) {
+		return nil
+	}
+	return s.mutate(fmt.Sprintf("unblock quest %q", q.Title), []string{loc.project.ID}, func() error {
+		q.BlockedBy = removeID(q.BlockedBy, blockerID)
+		return nil
+	})
+}
+
+// AddTaskBlocker marks a task as blocked by another task in the same project
+func (s *Store) AddTaskBlocker(id, blockerID string) error {
+	if id == blockerID {
+		return ErrSelfDependency
+	}
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return err
+	}
+	blockerLoc, err := s.locateTask(blockerID)
+	if err != nil {
+		return err
+	}
+	if blockerLoc.project != loc.project {
+		return ErrCrossProjectDependency
+	}
+	t, blocker := &loc.quest.Tasks[loc.index], blockerLoc.quest.Tasks[blockerLoc.index]
+	if containsID(t.BlockedBy, blockerID) {
+		return nil
+	}
+	waits := make(map[string][]string)
+	taskWaits(loc.project.Quests, waits)
+	if reaches(waits, blockerID, map[string]bool{id: true}) {
+		return &CycleError{Kind: KindTask, Item: t.Description, Blocker: blocker.Description}
+	}
+	return s.mutate(fmt.Sprintf("block task %q on %q", t.Description, blocker.Description), []string{loc.project.ID}, func() error {
+		t.BlockedBy = append(t.BlockedBy, blockerID)
+		return nil
+	})
+}
+
+// RemoveTaskBlocker drops a blocker from a task. Removing one the task does
+// not have is a no-op.
+func (s *Store) RemoveTaskBlocker(id, blockerID string) error {
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return err
+	}
+	t := &loc.quest.Tasks[loc.index]
+	if !containsID(t.BlockedBy, blockerID) {
+		return nil
+	}
+	return s.mutate(fmt.Sprintf("unblock task %q", t.Description), []string{loc.project.ID}, func() error {
+		t.BlockedBy = removeID(t.BlockedBy, blockerID)
+		return nil
+	})
+}
+
+// QuestBlockers returns the quests a quest is blocked by, finished or not
+func (s *Store) QuestBlockers(id string) ([]Quest, error) {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return nil, err
+	}
+	var blockers []Quest
+	for _, blockerID := range loc.quest().BlockedBy {
+		if b, ok := locateQuestIn(loc.project, nil, &loc.project.Quests, blockerID); ok {
+			blockers = append(blockers, *b.quest())
+		}
+	}
+	return blockers, nil
+}
+
+// collectDependents appends the quests in a tree that are blocked by id
+func collectDependents(quests []Quest, id string, dependents *[]Quest) {
+	for _, q := range quests {
+		if containsID(q.BlockedBy, id) {
+			*dependents = append(*dependents, q)
+		}
+		collectDependents(q.SubQuests, id, dependents)
+	}
+}
+
+// QuestDependents returns the quests that a quest unblocks
+func (s *Store) QuestDependents(id string) ([]Quest, error) {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return nil, err
+	}
+	var dependents []Quest
+	collectDependents(loc.project.Quests, id, &dependents)
+	return dependents, nil
+}
+
+// TaskBlockers returns the tasks a task is blocked by, done or not
+func (s *Store) TaskBlockers(id string) ([]Task, error) {
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return nil, err
+	}
+	var blockers []Task
+	for _, blockerID := range loc.quest.Tasks[loc.index].BlockedBy {
+		if b, ok := locateTaskIn(loc.project, loc.project.Quests, blockerID); ok {
+			blockers = append(blockers, b.quest.Tasks[b.index])
+		}
+	}
+	return blockers, nil
+}
+
+// BlockedItems returns the IDs of the quests and tasks that cannot start
+// yet: those with an active quest or open task among their blockers, and
+// the sub-quests and tasks of a blocked quest
+func BlockedItems(projects []Project) map[string]bool {
+	blocked := make(map[string]bool)
+	for _, p := range projects {
+		questOpen := make(map[string]bool)
+		taskOpen := make(map[string]bool)
+		indexOpen(p.Quests, questOpen, taskOpen)
+		markBlocked(p.Quests, false, questOpen, taskOpen, blocked)
+	}
+	return blocked
+}
+
+// indexOpen records which quests are open and which tasks are not done
+func indexOpen(quests []Quest, questOpen, taskOpen map[string]bool) {
+	for _, q := range quests {
+		questOpen[q.ID] = q.State.IsOpen()
+		for _, t := range q.Tasks {
+			taskOpen[t.ID] = !t.Done
+		}
+		indexOpen(q.SubQuests, questOpen, taskOpen)
+	}
+}
+
+// markBlocked adds the blocked quests and tasks of a tree to blocked
+func markBlocked(quests []Quest, inherited bool, questOpen, taskOpen, blocked map[string]bool) {
+	for _, q := range quests {
+		isBlocked := inherited || anyOpen(q.BlockedBy, questOpen)
+		if isBlocked {
+			blocked[q.ID] = true
+		}
+		for _, t := range q.Tasks {
+			if isBlocked || anyOpen(t.BlockedBy, taskOpen) {
+				blocked[t.ID] = true
+			}
+		}
+		markBlocked(q.SubQuests, isBlocked, questOpen, taskOpen, blocked)
+	}
+}
+
+// anyOpen reports whether any of ids is still open. Unknown IDs are ignored.
+func anyOpen(ids []string, open map[string]bool) bool {
+	for _, id := range ids {
+		if open[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneBlockers drops blockers that no longer exist in a project, after a
+// delete or a move to another project
+func pruneBlockers(p *Project) {
+	questExists := make(map[string]bool)
+	taskExists := make(map[string]bool)
+	indexOpen(p.Quests, questExists, taskExists)
+	pruneTree(p.Quests, questExists, taskExists)
+}
+
+// pruneTree drops dangling blockers from a quest tree
+func pruneTree(quests []Quest, questExists, taskExists map[string]bool) {
+	for i := range quests {
+		q := &quests[i]
+		q.BlockedBy = keepKnown(q.BlockedBy, questExists)
+		for j := range q.Tasks {
+			q.Tasks[j].BlockedBy = keepKnown(q.Tasks[j].BlockedBy, taskExists)
+		}
+		pruneTree(q.SubQuests, questExists, taskExists)
+	}
+}
+
+// keepKnown returns the ids present in known, or nil when none are
+func keepKnown(ids []string, known map[string]bool) []string {
+	var kept []string
+	for _, id := range ids {
+		if _, ok := known[id]; ok {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// containsID reports whether ids contains id
+func containsID(ids []string, id string) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// removeID returns ids without id, or nil when nothing is left
+func removeID(ids []string, id string) []string {
+	var kept []string
+	for _, x := range ids {
+		if x != id {
+			kept = append(kept, x)
+		}
+	}
+	return kept
+}
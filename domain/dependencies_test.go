@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// blockerFixture is a project with a quest holding a sub-quest, two more
+// quests, and three tasks, beside a second project
+func blockerFixture() []Project {
+	return []Project{{ID: "p1", Name: "Build", Quests: []Quest{
+		{ID: "q1", Title: "Walls", State: StateActive, Tasks: []Task{},
+			SubQuests: []Quest{{ID: "q1a", Title: "Frame", State: StateActive, Tasks: []Task{}}}},
+		{ID: "q2", Title: "Roof", State: StateActive, Tasks: []Task{
+			{ID: "t1", Description: "Beams"}, {ID: "t2", Description: "Felt"}, {ID: "t3", Description: "Tiles"}}},
+		{ID: "q3", Title: "Paint", State: StateActive, Tasks: []Task{}},
+	}}, {ID: "p2", Name: "Other", Quests: []Quest{
+		{ID: "q9", Title: "Elsewhere", State: StateActive, Tasks: []Task{{ID: "t9", Description: "Far"}}},
+	}}}
+}
+
+func TestAddBlockerDetectsCycles(t *testing.T) {
+	const (
+		ok    = ""
+		self  = "self"
+		cycle = "cycle"
+		cross = "cross"
+	)
+	tests := []struct {
+		name     string
+		existing [][2]string // item and blocker pairs already in place
+		item     string
+		blocker  string
+		want     string
+	}{
+		{name: "independent quests", item: "q2", blocker: "q3", want: ok},
+		{name: "already blocked", existing: [][2]string{{"q2", "q3"}}, item: "q2", blocker: "q3", want: ok},
+		{name: "quest on itself", item: "q2", blocker: "q2", want: self},
+		{name: "direct quest cycle", existing: [][2]string{{"q2", "q3"}}, item: "q3", blocker: "q2", want: cycle},
+		{name: "longer quest cycle", existing: [][2]string{{"q2", "q3"}, {"q3", "q1"}}, item: "q1", blocker: "q2", want: cycle},
+		{name: "parent on its sub-quest", item: "q1", blocker: "q1a", want: cycle},
+		{name: "sub-quest on its parent", item: "q1a", blocker: "q1", want: cycle},
+		{name: "sub-quest on what waits for its parent", existing: [][2]string{{"q2", "q1"}}, item: "q1a", blocker: "q2", want: cycle},
+		{name: "blocker inherited by a sub-quest", existing: [][2]string{{"q1", "q2"}}, item: "q2", blocker: "q1a", want: cycle},
+		{name: "quest in another project", item: "q2", blocker: "q9", want: cross},
+		{name: "independent tasks", item: "t2", blocker: "t1", want: ok},
+		{name: "task on itself", item: "t1", blocker: "t1", want: self},
+		{name: "direct task cycle", existing: [][2]string{{"t2", "t1"}}, item: "t1", blocker: "t2", want: cycle},
+		{name: "longer task cycle", existing: [][2]string{{"t3", "t2"}, {"t2", "t1"}}, item: "t1", blocker: "t3", want: cycle},
+		{name: "task in another project", item: "t1", blocker: "t9", want: cross},
+	}
+	for _, tt := range tests {
+		s := NewStore(blockerFixture())
+		for _, pair := range tt.existing {
+			if q, err := s.Quest(pair[0]); err == nil {
+				q.BlockedBy = append(q.BlockedBy, pair[1])
+			} else if task, err := s.Task(pair[0]); err == nil {
+				task.BlockedBy = append(task.BlockedBy, pair[1])
+			} else {
+				t.Fatalf("%s: no item %s", tt.name, pair[0])
+			}
+		}
+
+		var err error
+		if tt.item[0] == 'q' {
+			err = s.AddQuestBlocker(tt.item, tt.blocker)
+		} else {
+			err = s.AddTaskBlocker(tt.item, tt.blocker)
+		}
+		var cycleErr *CycleError
+		got := ok
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrSelfDependency):
+			got = self
+		case errors.As(err, &cycleErr):
+			got = cycle
+		case errors.Is(err, ErrCrossProjectDependency):
+			got = cross
+		default:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: blocking %s on %s gave %q (%v), want %q", tt.name, tt.item, tt.blocker, got, err, tt.want)
+		}
+
+		// Whatever was accepted must pass the check imports run
+		for i := range s.Projects() {
+			if err := checkBlockers(&s.Projects()[i]); err != nil {
+				t.Errorf("%s: the result fails checkBlockers: %v", tt.name, err)
+			}
+		}
+	}
+}
+
+func TestMoveQuestDetectsCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  [][2]string // quest and blocker pairs already in place
+		id        string
+		project   string
+		parent    string
+		wantCycle bool
+	}{
+		{name: "independent quest", id: "q3", project: "p1", parent: "q1"},
+		{name: "to the top level", existing: [][2]string{{"q1", "q2"}}, id: "q1a", project: "p1"},
+		{name: "blocker under what it blocks", existing: [][2]string{{"q1", "q2"}}, id: "q2", project: "p1", parent: "q1", wantCycle: true},
+		{name: "blocked quest under its blocker", existing: [][2]string{{"q2", "q1"}}, id: "q2", project: "p1", parent: "q1", wantCycle: true},
+		{name: "blocked by a sub-quest of the moved quest", existing: [][2]string{{"q3", "q1a"}}, id: "q1", project: "p1", parent: "q3", wantCycle: true},
+		{name: "blocker under a sibling of what it blocks", existing: [][2]string{{"q1", "q2"}}, id: "q2", project: "p1", parent: "q3"},
+		{name: "to another project", existing: [][2]string{{"q2", "q3"}}, id: "q2", project: "p2", parent: "q9"},
+	}
+	for _, tt := range tests {
+		s := NewStore(blockerFixture())
+		for _, pair := range tt.existing {
+			q, err := s.Quest(pair[0])
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			q.BlockedBy = append(q.BlockedBy, pair[1])
+		}
+
+		err := s.MoveQuest(tt.id, tt.project, tt.parent)
+		var cycleErr *CycleError
+		if got := errors.As(err, &cycleErr); got != tt.wantCycle {
+			t.Errorf("%s: moving %s under %q gave %v, want a cycle error %v", tt.name, tt.id, tt.parent, err, tt.wantCycle)
+			continue
+		}
+		if tt.wantCycle {
+			if s.History().CanUndo() {
+				t.Errorf("%s: a refused move was recorded", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: MoveQuest failed: %v", tt.name, err)
+		}
+		for i := range s.Projects() {
+			if err := checkBlockers(&s.Projects()[i]); err != nil {
+				t.Errorf("%s: the result fails checkBlockers: %v", tt.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package domain
+
+import "time"
+
+// Event kinds recorded in a project's event log
+const (
+	EventTaskAdded    = "task_added"
+	EventTaskDone     = "task_done"
+	EventTaskReopened = "task_reopened"
+	EventTaskRemoved  = "task_removed"
+	EventQuestAdded   = "quest_added"
+	EventQuestState   = "quest_state"
+	EventQuestRemoved = "quest_removed"
+)
+
+// Event is a timestamped change to a quest or task, kept for statistics.
+// Tasks and quests moved between projects are removed from one log and
+// added to the other.
+type Event struct {
+	At     time.Time   `json:"at"`
+	Kind   string      `json:"kind"`
+	ItemID string      `json:"item_id"`
+	State  *QuestState `json:"state,omitempty"` // the new state of EventQuestState
+	Done   bool        `json:"done,omitempty"`  // a task added or removed while done
+}
+
+// itemIndex records the state of every quest and task in a project
+type itemIndex struct {
+	quests    map[string]QuestState
+	tasks     map[string]bool // done
+	questList []string        // IDs in tree order, so events come out stable
+	taskList  []string
+}
+
+// indexItems walks a quest tree into idx
+func indexItems(quests []Quest, idx *itemIndex) {
+	for _, q := range quests {
+		idx.quests[q.ID] = q.State
+		idx.questList = append(idx.questList, q.ID)
+		for _, t := range q.Tasks {
+			idx.tasks[t.ID] = t.Done
+			idx.taskList = append(idx.taskList, t.ID)
+		}
+		indexItems(q.SubQuests, idx)
+	}
+}
+
+// newItemIndex indexes a project, which may be nil
+func newItemIndex(p *Project) itemIndex {
+	idx := itemIndex{quests: make(map[string]QuestState), tasks: make(map[string]bool)}
+	if p != nil {
+		indexItems(p.Quests, &idx)
+	}
+	return idx
+}
+
+// recordEvents appends to after's log the events that turned before into
+// after. Either may be nil for a project being created or deleted; a
+// deleted project takes its log with it.
+func recordEvents(before, after *Project, now time.Time) {
+	if after == nil {
+		return
+	}
+	old, cur := newItemIndex(before), newItemIndex(after)
+	for _, id := range old.questList {
+		if _, ok := cur.quests[id]; !ok {
+			after.Events = append(after.Events, Event{At: now, Kind: EventQuestRemoved, ItemID: id})
+		}
+	}
+	for _, id := range old.taskList {
+		if _, ok := cur.tasks[id]; !ok {
+			after.Events = append(after.Events, Event{At: now, Kind: EventTaskRemoved, ItemID: id, Done: old.tasks[id]})
+		}
+	}
+	for _, id := range cur.questList {
+		state := cur.quests[id]
+		was, existed := old.quests[id]
+		if !existed {
+			after.Events = append(after.Events, Event{At: now, Kind: EventQuestAdded, ItemID: id})
+		}
+		if (!existed && state != StateActive) || (existed && was != state) {
+			after.Events = append(after.Events, Event{At: now, Kind: EventQuestState, ItemID: id, State: &state})
+		}
+	}
+	for _, id := range cur.taskList {
+		done := cur.tasks[id]
+		was, existed := old.tasks[id]
+		switch {
+		case !existed:
+			after.Events = append(after.Events, Event{At: now, Kind: EventTaskAdded, ItemID: id, Done: done})
+		case done && !was:
+			after.Events = append(after.Events, Event{At: now, Kind: EventTaskDone, ItemID: id})
+		case !done && was:
+			after.Events = append(after.Events, Event{At: now, Kind: EventTaskReopened, ItemID: id})
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// DefaultUndoLimit is how many changes the undo stack keeps
+const DefaultUndoLimit = 100
+
+// Errors returned when a stack is empty
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// ProjectChange records one project before and after a mutation. Before is
+// nil when the project was created and After is nil when it was deleted.
+// The snapshots leave out the event log, which undo and redo append to
+// like any other change, except on the side of a created or deleted
+// project, where there is no live log to keep.
+type ProjectChange struct {
+	ID          string   `json:"id"`
+	BeforeIndex int      `json:"before_index"`
+	Before      *Project `json:"before,omitempty"`
+	AfterIndex  int      `json:"after_index"`
+	After       *Project `json:"after,omitempty"`
+}
+
+// Change is one reversible mutation, stored as snapshots of the projects it
+// touched and the entries it added to and removed from the XP log
+type Change struct {
+	Label     string          `json:"label"`
+	At        time.Time       `json:"at"`
+	Projects  []ProjectChange `json:"projects"`
+	XPAdded   []XPEvent       `json:"xp_added,omitempty"`
+	XPRemoved []XPEvent       `json:"xp_removed,omitempty"`
+}
+
+// History holds the undo and redo stacks, most recent change last
+type History struct {
+	Undo  []Change `json:"undo"`
+	Redo  []Change `json:"redo"`
+	Limit int      `json:"-"`
+}
+
+// NewHistory creates an empty history keeping up to DefaultUndoLimit changes
+func NewHistory() *History {
+	return &History{Limit: DefaultUndoLimit}
+}
+
+// record pushes a change and clears the redo stack
+func (h *History) record(c Change) {
+	h.Undo = append(h.Undo, c)
+	if h.Limit > 0 && len(h.Undo) > h.Limit {
+		h.Undo = h.Undo[len(h.Undo)-h.Limit:]
+	}
+	h.Redo = nil
+}
+
+// CanUndo reports whether there is a change to undo
+func (h *History) CanUndo() bool {
+	return len(h.Undo) > 0
+}
+
+// CanRedo reports whether there is an undone change to redo
+func (h *History) CanRedo() bool {
+	return len(h.Redo) > 0
+}
+
+// cloneProject deep-copies a project through its JSON form, so snapshots
+// never share slices with the live tree
+func cloneProject(p *Project) *Project {
+	data, err := json.Marshal(p)
+	if err != nil {
+		panic(fmt.Sprintf("snapshot project %s: %v", p.ID, err))
+	}
+	var c Project
+	if err := json.Unmarshal(data, &c); err != nil {
+		panic(fmt.Sprintf("snapshot project %s: %v", p.ID, err))
+	}
+	return &c
+}
+
+// snapshotProject copies a project for the undo history without its event log
+func snapshotProject(p *Project) *Project {
+	shallow := *p
+	shallow.Events = nil
+	return cloneProject(&shallow)
+}
+
+// projectIndex returns the position of a project, or -1
+func (s *Store) projectIndex(id string) int {
+	for i := range s.projects {
+		if s.projects[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// snapshot copies the named projects with their positions
+func (s *Store) snapshot(ids []string) map[string]ProjectChange {
+	snaps := make(map[string]ProjectChange, len(ids))
+	for _, id := range ids {
+		pc := ProjectChange{ID: id, BeforeIndex: s.projectIndex(id)}
+		if pc.BeforeIndex >= 0 {
+			pc.Before = snapshotProject(&s.projects[pc.BeforeIndex])
+		}
+		snaps[id] = pc
+	}
+	return snaps
+}
+
+// locked reports whether any achievement is still to be unlocked
+func (s *Store) locked() bool {
+	return len(unlockedIDs(s.xp)) < len(Achievements)
+}
+
+// mutate runs fn as one undoable change touching the given projects. Nothing
+// is recorded if fn fails; fn must validate before it modifies anything.
+func (s *Store) mutate(label string, projectIDs []string, fn func() error) error {
+	snaps := s.snapshot(projectIDs)
+	events := make(map[string][]Event, len(snaps))
+	for id, pc := range snaps {
+		if pc.Before != nil {
+			events[id] = s.projects[pc.BeforeIndex].Events
+		}
+	}
+	// Once everything is unlocked there is nothing to check the change against
+	locked := s.locked()
+	var before Stats
+	if locked {
+		before = ComputeStats(s.projects, s.xp, time.Now())
+	}
+	s.xpAdded, s.xpRemoved = nil, nil
+	if err := fn(); err != nil {
+		return err
+	}
+	for id, pc := range snaps {
+		if i := s.projectIndex(id); i >= 0 {
+			recordEvents(pc.Before, &s.projects[i], time.Now())
+		}
+	}
+	if locked {
+		s.unlockAchievements(before, time.Now())
+	}
+	c := Change{Label: label, At: time.Now(), XPAdded: s.xpAdded, XPRemoved: s.xpRemoved}
+	s.xpAdded, s.xpRemoved = nil, nil
+	for _, id := range projectIDs {
+		pc, ok := snaps[id]
+		if !ok {
+			continue
+		}
+		delete(snaps, id) // a project listed twice is recorded once
+		pc.AfterIndex = s.projectIndex(id)
+		switch {
+		case pc.AfterIndex >= 0 && pc.Before == nil:
+			pc.After = cloneProject(&s.projects[pc.AfterIndex])
+		case pc.AfterIndex >= 0:
+			pc.After = snapshotProject(&s.projects[pc.AfterIndex])
+		case pc.Before != nil:
+			// The log goes with the deleted project and comes back on undo
+			pc.Before.Events = append([]Event(nil), events[id]...)
+		}
+		c.Projects = append(c.Projects, pc)
+	}
+	s.history.record(c)
+	s.activity = append(s.activity, activityFor(c.Label, c.Projects, false, s.actor, c.At)...)
+	return nil
+}
+
+// restore replaces the changed projects with one side of their snapshots
+// and takes back or re-logs the change's XP
+func (s *Store) restore(c Change, undo bool) {
+	added, removed := c.XPAdded, c.XPRemoved
+	if undo {
+		added, removed = removed, added
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		s.xp = dropXP(s.xp, removed[i])
+	}
+	for _, e := range added {
+		s.xp = insertXP(s.xp, e)
+	}
+
+	type placement struct {
+		index   int
+		project *Project
+	}
+	var placements []placement
+	now := time.Now()
+	for _, pc := range c.Projects {
+		var live *Project
+		if i := s.projectIndex(pc.ID); i >= 0 {
+			p := s.projects[i]
+			live = &p
+			s.projects = append(s.projects[:i], s.projects[i+1:]...)
+		}
+		snap, index := pc.After, pc.AfterIndex
+		if undo {
+			snap, index = pc.Before, pc.BeforeIndex
+		}
+		if snap == nil {
+			continue
+		}
+		p := cloneProject(snap)
+		if live != nil {
+			// Keep the live event log and add what this restore changes
+			p.Events = live.Events
+			recordEvents(live, p, now)
+		}
+		placements = append(placements, placement{index, p})
+	}
+	// Insert in ascending order so each index is valid when it is used
+	sort.Slice(placements, func(i, j int) bool { return placements[i].index < placements[j].index })
+	for _, pl := range placements {
+		i := pl.index
+		if i > len(s.projects) {
+			i = len(s.projects)
+		}
+		s.projects = append(s.projects, Project{})
+		copy(s.projects[i+1:], s.projects[i:])
+		s.projects[i] = *pl.project
+		s.projects[i].CalculateProgress()
+	}
+}
+
+// Undo reverts the most recent change and returns it
+func (s *Store) Undo() (Change, error) {
+	h := s.history
+	if !h.CanUndo() {
+		return Change{}, ErrNothingToUndo
+	}
+	c := h.Undo[len(h.Undo)-1]
+	h.Undo = h.Undo[:len(h.Undo)-1]
+	s.restore(c, true)
+	s.activity = append(s.activity, activityFor("undo "+c.Label, c.Projects, true, s.actor, time.Now())...)
+	h.Redo = append(h.Redo, c)
+	return c, nil
+}
+
+// Redo re-applies the most recently undone change and returns it
+func (s *Store) Redo() (Change, error) {
+	h := s.history
+	if !h.CanRedo() {
+		return Change{}, ErrNothingToRedo
+	}
+	c := h.Redo[len(h.Redo)-1]
+	h.Redo = h.Redo[:len(h.Redo)-1]
+	s.restore(c, false)
+	s.activity = append(s.activity, activityFor("redo "+c.Label, c.Projects, false, s.actor, time.Now())...)
+	h.Undo = append(h.Undo, c)
+	return c, nil
+}
+
+// History returns the store's undo history
+func (s *Store) History() *History {
+	return s.history
+}
+
+// SetHistory replaces the undo history, e.g. with one loaded from disk
+func (s *Store) SetHistory(h *History) {
+	s.history = h
+}
+
+// matches reports whether the projects are in the state the history left
+// them in, so that undoing cannot clobber changes made elsewhere
+func (h *History) matches(projects []Project) bool {
+	var pcs []ProjectChange
+	var after bool
+	switch {
+	case h.CanUndo():
+		pcs, after = h.Undo[len(h.Undo)-1].Projects, true
+	case h.CanRedo():
+		pcs, after = h.Redo[len(h.Redo)-1].Projects, false
+	default:
+		return true
+	}
+	current := make(map[string]*Project, len(projects))
+	for i := range projects {
+		current[projects[i].ID] = &projects[i]
+	}
+	for _, pc := range pcs {
+		want := pc.Before
+		if after {
+			want = pc.After
+		}
+		got := current[pc.ID]
+		if (want == nil) != (got == nil) {
+			return false
+		}
+		if want == nil {
+			continue
+		}
+		a, errA := json.Marshal(snapshotProject(want))
+		b, errB := json.Marshal(snapshotProject(got))
+		if errA != nil || errB != nil || !bytes.Equal(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadHistory reads an undo history saved by SaveHistory. A missing file
+// gives an empty history, and so does one that no longer matches projects,
+// since the data was changed by something that did not record it.
+func LoadHistory(path string, projects []Project) (*History, error) {
+	h := NewHistory()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("read undo history %s: %w", path, err)
+	}
+	if !h.matches(projects) {
+		return NewHistory(), nil
+	}
+	return h, nil
+}
+
+// EncodeHistory encodes the undo history for WriteHistory
+func EncodeHistory(h *History) ([]byte, error) {
+	return json.Marshal(h)
+}
+
+// WriteHistory writes an undo history encoded by EncodeHistory
+func WriteHistory(path string, data []byte) error {
+	return writeFileAtomic(path, data, 0644)
+}
+
+// SaveHistory writes the undo history so it survives a restart
+func SaveHistory(path string, h *History) error {
+	data, err := EncodeHistory(h)
+	if err != nil {
+		return err
+	}
+	return WriteHistory(path, data)
+}
@@ -0,0 +1,309 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// historyFixture is three projects, the first with a quest of two tasks
+// and an event log, so undo has positions, XP and events to put back
+func historyFixture() []Project {
+	created := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	return []Project{
+		{ID: "p1", Name: "Garden", Events: []Event{{At: created, Kind: EventQuestAdded, ItemID: "q1"}}, Quests: []Quest{{
+			ID: "q1", Title: "Weed", State: StateActive,
+			Tasks: []Task{{ID: "t1", Description: "Front bed"}, {ID: "t2", Description: "Back bed", Done: true}},
+		}}},
+		{ID: "p2", Name: "House", Quests: []Quest{{ID: "q2", Title: "Paint", State: StateActive, Tasks: []Task{}}}},
+		{ID: "p3", Name: "Car", Quests: []Quest{}},
+	}
+}
+
+// historyState describes the projects, without their event logs, and the
+// XP log, so states before and after undo and redo can be compared
+func historyState(t *testing.T, s *Store) string {
+	t.Helper()
+	var b strings.Builder
+	for i := range s.Projects() {
+		raw, err := json.Marshal(snapshotProject(&s.Projects()[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(raw)
+		b.WriteByte('\n')
+	}
+	for _, e := range s.XPLog() {
+		fmt.Fprintf(&b, "xp %s %s %s@%s\n", e.At.UTC().Format(time.RFC3339Nano), e.Kind, e.ItemID, e.ProjectID)
+	}
+	return b.String()
+}
+
+// eventCounts maps each project to the length of its event log
+func eventCounts(s *Store) map[string]int {
+	counts := make(map[string]int)
+	for _, p := range s.Projects() {
+		counts[p.ID] = len(p.Events)
+	}
+	return counts
+}
+
+func TestUndoRedoRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		xp     []XPEvent // logged before the change
+		change func(s *Store) error
+		wantXP int // change in the number of XP log entries
+	}{
+		{name: "delete the first project", change: func(s *Store) error { return s.DeleteProject("p1") }},
+		{name: "delete a middle project", change: func(s *Store) error { return s.DeleteProject("p2") }},
+		{name: "create a project", change: func(s *Store) error {
+			_, err := s.CreateProject(ProjectInput{Name: "Shed"})
+			return err
+		}},
+		// The first task done also unlocks an achievement
+		{name: "check off a task", change: func(s *Store) error { return s.ToggleTask("t1") }, wantXP: 2},
+		{name: "uncheck a task", xp: []XPEvent{{At: time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC), Kind: KindTask, ItemID: "t2", ProjectID: "p1"}},
+			change: func(s *Store) error { return s.ToggleTask("t2") }, wantXP: -1},
+		{name: "move a quest to another project", change: func(s *Store) error { return s.MoveQuest("q1", "p3", "") }},
+		{name: "delete a quest", change: func(s *Store) error { return s.DeleteQuest("q1") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(historyFixture())
+			s.SetXPLog(tt.xp)
+			before, beforeEvents := historyState(t, s), eventCounts(s)
+			xpBefore := len(s.XPLog())
+			if err := tt.change(s); err != nil {
+				t.Fatal(err)
+			}
+			after := historyState(t, s)
+			if got := len(s.XPLog()) - xpBefore; got != tt.wantXP {
+				t.Errorf("the change added %d XP log entries, want %d", got, tt.wantXP)
+			}
+
+			if _, err := s.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := historyState(t, s); got != before {
+				t.Errorf("undo left\n%s\nwant\n%s", got, before)
+			}
+			// Undo adds to the event logs it finds and brings back the
+			// log of a deleted project
+			for id, n := range eventCounts(s) {
+				if n < beforeEvents[id] {
+					t.Errorf("project %s has %d events after undo, fewer than the %d before", id, n, beforeEvents[id])
+				}
+			}
+
+			if _, err := s.Redo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := historyState(t, s); got != after {
+				t.Errorf("redo left\n%s\nwant\n%s", got, after)
+			}
+			if _, err := s.Redo(); err != ErrNothingToRedo {
+				t.Errorf("a second redo gave %v, want ErrNothingToRedo", err)
+			}
+		})
+	}
+}
+
+func TestUndoStackOrder(t *testing.T) {
+	s := NewStore(historyFixture())
+	states := []string{historyState(t, s)}
+	changes := []func() error{
+		func() error { return s.ToggleTask("t1") },
+		func() error { return s.MoveQuest("q2", "p1", "q1") },
+		func() error { return s.DeleteProject("p3") },
+	}
+	for _, change := range changes {
+		if err := change(); err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, historyState(t, s))
+	}
+	for i := len(states) - 2; i >= 0; i-- {
+		if _, err := s.Undo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := historyState(t, s); got != states[i] {
+			t.Errorf("after undoing back to state %d:\n%s\nwant\n%s", i, got, states[i])
+		}
+	}
+	if _, err := s.Undo(); err != ErrNothingToUndo {
+		t.Errorf("undo past the first change gave %v, want ErrNothingToUndo", err)
+	}
+	for i := 1; i < len(states); i++ {
+		if _, err := s.Redo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := historyState(t, s); got != states[i] {
+			t.Errorf("after redoing to state %d:\n%s\nwant\n%s", i, got, states[i])
+		}
+	}
+
+	// A new change drops what could still be redone
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateProject("p1", "Allotment"); err != nil {
+		t.Fatal(err)
+	}
+	if s.History().CanRedo() {
+		t.Errorf("a new change kept the redo stack")
+	}
+}
+
+// restart saves the data and the undo history and loads them into a new
+// store, as a run with --keep-undo does
+func restart(t *testing.T, s *Store, undoFile string) *Store {
+	t.Helper()
+	encoded, err := EncodeData(s.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := encodeDocument(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveHistory(undoFile, s.History()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := decodeDocument(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := NewStore(data.Projects)
+	next.SetXPLog(data.XPLog)
+	h, err := LoadHistory(undoFile, next.Projects())
+	if err != nil {
+		t.Fatal(err)
+	}
+	next.SetHistory(h)
+	return next
+}
+
+func TestKeptUndoAcrossRestarts(t *testing.T) {
+	undoFile := filepath.Join(t.TempDir(), "quests.json.undo")
+	s := NewStore(historyFixture())
+	states := []string{historyState(t, s)}
+	changes := []func() error{
+		func() error { return s.ToggleTask("t1") },
+		func() error { return s.MoveQuest("q1", "p2", "q2") },
+		func() error { return s.CompleteQuest("q2") },
+		func() error { return s.DeleteProject("p2") },
+	}
+	for _, change := range changes {
+		if err := change(); err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, historyState(t, s))
+	}
+
+	// Undo everything, restarting between each step
+	for i := len(states) - 2; i >= 0; i-- {
+		s = restart(t, s, undoFile)
+		if !s.History().CanUndo() {
+			t.Fatalf("the history was dropped on restart before undoing to state %d", i)
+		}
+		if _, err := s.Undo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := historyState(t, s); got != states[i] {
+			t.Errorf("after undoing to state %d:\n%s\nwant\n%s", i, got, states[i])
+		}
+	}
+	// and redo it all the same way
+	for i := 1; i < len(states); i++ {
+		s = restart(t, s, undoFile)
+		if _, err := s.Redo(); err != nil {
+			t.Fatalf("redo to state %d after a restart: %v", i, err)
+		}
+		if got := historyState(t, s); got != states[i] {
+			t.Errorf("after redoing to state %d:\n%s\nwant\n%s", i, got, states[i])
+		}
+	}
+}
+
+func TestLoadHistoryMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		undo     bool // undo the change before saving, leaving only a redo
+		edit     func(projects []Project) []Project
+		wantKept bool
+	}{
+		{name: "unchanged", wantKept: true},
+		{name: "unchanged after an undo", undo: true, wantKept: true},
+		{name: "only the event log differs", wantKept: true, edit: func(projects []Project) []Project {
+			projects[0].Events = append(projects[0].Events, Event{At: time.Now(), Kind: EventQuestAdded, ItemID: "x"})
+			return projects
+		}},
+		{name: "a project edited elsewhere", edit: func(projects []Project) []Project {
+			projects[0].Quests[0].Title = "Weed again"
+			return projects
+		}},
+		{name: "a changed project deleted elsewhere", edit: func(projects []Project) []Project {
+			return projects[1:]
+		}},
+		{name: "an untouched project edited elsewhere", wantKept: true, edit: func(projects []Project) []Project {
+			projects[2].Name = "Bike"
+			return projects
+		}},
+		{name: "an undone change redone elsewhere", undo: true, edit: func(projects []Project) []Project {
+			projects[0].Quests[0].Tasks[0].Done = true
+			return projects
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			undoFile := filepath.Join(t.TempDir(), "quests.json.undo")
+			s := NewStore(historyFixture())
+			if err := s.UpdateTask("t1", TaskInput{Description: "Front beds"}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.undo {
+				if _, err := s.Undo(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := SaveHistory(undoFile, s.History()); err != nil {
+				t.Fatal(err)
+			}
+			projects := make([]Project, len(s.Projects()))
+			for i := range s.Projects() {
+				projects[i] = *cloneProject(&s.Projects()[i])
+			}
+			if tt.edit != nil {
+				projects = tt.edit(projects)
+			}
+
+			h, err := LoadHistory(undoFile, projects)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kept := h.CanUndo() || h.CanRedo(); kept != tt.wantKept {
+				t.Errorf("history kept = %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestLoadHistoryFiles(t *testing.T) {
+	dir := t.TempDir()
+	h, err := LoadHistory(filepath.Join(dir, "missing.undo"), historyFixture())
+	if err != nil || h.CanUndo() || h.CanRedo() {
+		t.Errorf("a missing file gave %+v, %v; want an empty history", h, err)
+	}
+	bad := filepath.Join(dir, "bad.undo")
+	if err := os.WriteFile(bad, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHistory(bad, historyFixture()); err == nil {
+		t.Errorf("a corrupt file loaded without an error")
+	}
+}
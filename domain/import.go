@@ -0,0 +1,341 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ImportSummary counts what an import added and what it merged into
+// existing projects, quests and tasks
+type ImportSummary struct {
+	Projects      []string `json:"projects"` // IDs of the projects imported into
+	AddedProjects int      `json:"added_projects"`
+	AddedQuests   int      `json:"added_quests"`
+	MergedQuests  int      `json:"merged_quests"`
+	AddedTasks    int      `json:"added_tasks"`
+	MergedTasks   int      `json:"merged_tasks"`
+}
+
+// importIndex locates the quests and tasks of an existing project
+type importIndex struct {
+	quests    map[string]Quest
+	tasks     map[string]Task
+	mentioned map[string]bool // IDs of the items the import lists
+	awards    []XPEvent       // completions to log once the import applies
+	revokes   []string        // items whose logged completion is taken back
+}
+
+// indexProjectItems adds every quest and task of a tree to idx
+func indexProjectItems(quests []Quest, idx *importIndex) {
+	for _, q := range quests {
+		idx.quests[q.ID] = q
+		for _, t := range q.Tasks {
+			idx.tasks[t.ID] = t
+		}
+		indexProjectItems(q.SubQuests, idx)
+	}
+}
+
+// collectMentioned records the IDs in an imported tree, failing on an ID
+// listed twice
+func collectMentioned(quests []Quest, mentioned map[string]bool) error {
+	for _, q := range quests {
+		if q.ID != "" {
+			if mentioned[q.ID] {
+				return fmt.Errorf("ID %s is listed twice", q.ID)
+			}
+			mentioned[q.ID] = true
+		}
+		for _, t := range q.Tasks {
+			if t.ID != "" {
+				if mentioned[t.ID] {
+					return fmt.Errorf("ID %s is listed twice", t.ID)
+				}
+				mentioned[t.ID] = true
+			}
+		}
+		if err := collectMentioned(q.SubQuests, mentioned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutMentioned returns a kept quest without the items the import
+// lists, since those are placed where the import puts them
+func withoutMentioned(q Quest, mentioned map[string]bool) Quest {
+	tasks := []Task{}
+	for _, t := range q.Tasks {
+		if !mentioned[t.ID] {
+			tasks = append(tasks, t)
+		}
+	}
+	q.Tasks = tasks
+	var subs []Quest
+	for _, sub := range q.SubQuests {
+		if !mentioned[sub.ID] {
+			subs = append(subs, withoutMentioned(sub, mentioned))
+		}
+	}
+	q.SubQuests = subs
+	return q
+}
+
+// mergeTask returns the imported task merged over the existing one with the
+// same ID, or a new task. Checking off or unchecking an existing task earns
+// or takes back its XP as it would in the app.
+func mergeTask(p *Project, in Task, idx *importIndex, sum *ImportSummary, now time.Time) Task {
+	t, ok := idx.tasks[in.ID]
+	if !ok {
+		sum.AddedTasks++
+		if in.ID == "" {
+			in.ID = generateID()
+		}
+		return in
+	}
+	sum.MergedTasks++
+	TaskInput{Description: in.Description, Tags: in.Tags, Priority: in.Priority, Deadline: in.Deadline,
+		Estimate: in.Estimate, Notes: in.Notes, Recur: in.Recur}.apply(&t)
+	t.BlockedBy = in.BlockedBy
+	if in.Done && !t.Done {
+		idx.awards = append(idx.awards, taskAward(p.ID, &t, now))
+		stopRunning(&t, now, "")
+	} else if !in.Done && t.Done {
+		idx.revokes = append(idx.revokes, t.ID)
+	}
+	t.Done = in.Done
+	return t
+}
+
+// stampState sets the time a quest reached its state, clearing the others
+// when it is open again
+func stampState(q *Quest, now time.Time) {
+	switch q.State {
+	case StateActive, StateInProgress:
+		q.CompletedAt, q.CancelledAt, q.ArchivedAt = nil, nil, nil
+	case StateCompleted:
+		q.CompletedAt = &now
+	case StateCancelled:
+		q.CancelledAt = &now
+	case StateArchived:
+		q.ArchivedAt = &now
+	}
+}
+
+// mergeQuests returns the imported quests merged over the existing ones
+// with the same IDs, followed by the existing quests at this level that the
+// import leaves out. A state change is stamped and earns or takes back XP
+// as it would in the app; new quests earn nothing for the state they arrive
+// in.
+func mergeQuests(p *Project, imported, existing []Quest, idx *importIndex, sum *ImportSummary, now time.Time) []Quest {
+	var merged []Quest
+	for _, in := range imported {
+		q, ok := idx.quests[in.ID]
+		if ok {
+			sum.MergedQuests++
+		} else {
+			sum.AddedQuests++
+			q = Quest{ID: in.ID, State: in.State, CreatedAt: &now}
+			if q.ID == "" {
+				q.ID = generateID()
+			}
+		}
+		QuestInput{Title: in.Title, Description: in.Description, Priority: in.Priority, Deadline: in.Deadline,
+			Tags: in.Tags, Recur: in.Recur, Estimate: in.Estimate}.apply(&q)
+		q.Column, q.BlockedBy = in.Column, in.BlockedBy
+
+		tasks := []Task{}
+		for _, t := range in.Tasks {
+			tasks = append(tasks, mergeTask(p, t, idx, sum, now))
+		}
+		for _, t := range q.Tasks {
+			if !idx.mentioned[t.ID] {
+				tasks = append(tasks, t)
+			}
+		}
+		q.Tasks = tasks
+		q.SubQuests = mergeQuests(p, in.SubQuests, q.SubQuests, idx, sum, now)
+
+		if !ok {
+			stampState(&q, now)
+		} else if q.State != in.State {
+			completed := q.CompletedAt != nil
+			q.History = append(q.History, StateChange{From: q.State, To: in.State, At: now})
+			q.State = in.State
+			stampState(&q, now)
+			if q.State == StateCompleted && !completed {
+				idx.awards = append(idx.awards, questAward(p.ID, &q, now))
+			} else if completed && q.State.IsOpen() {
+				idx.revokes = append(idx.revokes, q.ID)
+			}
+		}
+		merged = append(merged, q)
+	}
+	for _, q := range existing {
+		if !idx.mentioned[q.ID] {
+			merged = append(merged, withoutMentioned(q, idx.mentioned))
+		}
+	}
+	return merged
+}
+
+// checkBlockers makes sure every blocker in a merged project exists in it
+// and that no work ends up waiting on itself
+func checkBlockers(p *Project) error {
+	questExists := make(map[string]bool)
+	taskExists := make(map[string]bool)
+	indexOpen(p.Quests, questExists, taskExists)
+	var check func(quests []Quest) error
+	check = func(quests []Quest) error {
+		for _, q := range quests {
+			for _, id := range q.BlockedBy {
+				if _, ok := questExists[id]; !ok {
+					return fmt.Errorf("quest %q is blocked by %s, which is not a quest in project %q", q.Title, id, p.Name)
+				}
+			}
+			for _, t := range q.Tasks {
+				for _, id := range t.BlockedBy {
+					if _, ok := taskExists[id]; !ok {
+						return fmt.Errorf("task %q is blocked by %s, which is not a task in project %q", t.Description, id, p.Name)
+					}
+				}
+			}
+			if err := check(q.SubQuests); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := check(p.Quests); err != nil {
+		return err
+	}
+	questGraph := make(map[string][]string)
+	questWaits(p.Quests, nil, questGraph)
+	for id, edges := range questGraph {
+		for _, blocker := range edges {
+			if reaches(questGraph, blocker, map[string]bool{id: true}) {
+				return fmt.Errorf("quest %s waits on itself through its blockers", id)
+			}
+		}
+	}
+	taskGraph := make(map[string][]string)
+	taskWaits(p.Quests, taskGraph)
+	for id, edges := range taskGraph {
+		for _, blocker := range edges {
+			if reaches(taskGraph, blocker, map[string]bool{id: true}) {
+				return fmt.Errorf("task %s waits on itself through its blockers", id)
+			}
+		}
+	}
+	return nil
+}
+
+// ImportProjects merges imported projects into the store as one change.
+// Projects, quests and tasks are matched by ID, and projects without one by
+// name; anything unmatched is added. Matched items take the imported
+// fields and are placed where the import puts them, keeping what imports
+// do not carry, such as time entries. Existing items the import leaves out
+// are kept. An ID already used in another project is refused.
+func (s *Store) ImportProjects(projects []Project) (ImportSummary, error) {
+	var sum ImportSummary
+	now := time.Now()
+	owner := make(map[string]string) // quest and task IDs to their project
+	for _, p := range s.projects {
+		idx := importIndex{quests: make(map[string]Quest), tasks: make(map[string]Task)}
+		indexProjectItems(p.Quests, &idx)
+		for id := range idx.quests {
+			owner[id] = p.ID
+		}
+		for id := range idx.tasks {
+			owner[id] = p.ID
+		}
+	}
+
+	var results []Project
+	var awards []XPEvent
+	var revokes []string
+	seen := make(map[string]bool)
+	for _, in := range projects {
+		i := -1
+		if in.ID != "" {
+			i = s.projectIndex(in.ID)
+		} else {
+			for j := range s.projects {
+				if strings.EqualFold(s.projects[j].Name, in.Name) {
+					i = j
+					break
+				}
+			}
+		}
+		var p Project
+		if i >= 0 {
+			p = *cloneProject(&s.projects[i])
+		} else {
+			sum.AddedProjects++
+			p = Project{ID: in.ID, Quests: []Quest{}}
+			if p.ID == "" {
+				p.ID = generateID()
+			}
+		}
+		if seen[p.ID] {
+			return ImportSummary{}, fmt.Errorf("project %q is listed twice", in.Name)
+		}
+		seen[p.ID] = true
+
+		idx := importIndex{quests: make(map[string]Quest), tasks: make(map[string]Task), mentioned: make(map[string]bool)}
+		indexProjectItems(p.Quests, &idx)
+		if err := collectMentioned(in.Quests, idx.mentioned); err != nil {
+			return ImportSummary{}, fmt.Errorf("project %q: %w", in.Name, err)
+		}
+		for id := range idx.mentioned {
+			if other, ok := owner[id]; ok && other != p.ID {
+				return ImportSummary{}, fmt.Errorf("project %q: %s belongs to another project", in.Name, id)
+			}
+		}
+		p.Name = in.Name
+		p.AutoComplete = in.AutoComplete
+		p.ProgressMode = in.ProgressMode
+		p.BoardColumns = in.BoardColumns
+		p.Quests = mergeQuests(&p, in.Quests, p.Quests, &idx, &sum, now)
+		if p.Quests == nil {
+			p.Quests = []Quest{}
+		}
+		if err := checkBlockers(&p); err != nil {
+			return ImportSummary{}, err
+		}
+		p.CalculateProgress()
+		results = append(results, p)
+		awards = append(awards, idx.awards...)
+		revokes = append(revokes, idx.revokes...)
+		sum.Projects = append(sum.Projects, p.ID)
+	}
+	if len(results) == 0 {
+		return ImportSummary{}, fmt.Errorf("nothing to import")
+	}
+
+	label := fmt.Sprintf("import %d projects", len(results))
+	if len(results) == 1 {
+		label = fmt.Sprintf("import project %q", results[0].Name)
+	}
+	err := s.mutate(label, sum.Projects, func() error {
+		for _, p := range results {
+			if i := s.projectIndex(p.ID); i >= 0 {
+				s.projects[i] = p
+			} else {
+				s.projects = append(s.projects, p)
+			}
+		}
+		for _, id := range revokes {
+			s.revokeXP(id)
+		}
+		for _, e := range awards {
+			s.award(e)
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	return sum, nil
+}
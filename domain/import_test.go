@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// importFixture is a project with a quest of two tasks, one of them done
+// with logged time
+func importFixture() []Project {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	return []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{
+		ID: "q1", Title: "Weed", State: StateActive,
+		Tasks: []Task{
+			{ID: "t1", Description: "Front bed", Estimate: 30, TimeEntries: []TimeEntry{{Start: start, End: &end}}},
+			{ID: "t2", Description: "Back bed", Done: true},
+		},
+	}, {
+		ID: "q2", Title: "Mow", State: StateActive, Tasks: []Task{},
+	}}}, {ID: "p2", Name: "House", Quests: []Quest{{ID: "q9", Title: "Paint", State: StateActive, Tasks: []Task{{ID: "t9", Description: "Walls"}}}}}}
+}
+
+func TestImportProjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []Project
+		want    ImportSummary
+		check   func(t *testing.T, s *Store)
+		wantErr string
+	}{
+		{
+			name: "new project",
+			in:   []Project{{Name: "Kitchen", Quests: []Quest{{Title: "Clean", Tasks: []Task{{Description: "Oven"}}}}}},
+			want: ImportSummary{AddedProjects: 1, AddedQuests: 1, AddedTasks: 1},
+			check: func(t *testing.T, s *Store) {
+				p := s.Projects()[2]
+				if p.Name != "Kitchen" || p.ID == "" || p.Quests[0].ID == "" || p.Quests[0].Tasks[0].ID == "" {
+					t.Errorf("project = %+v, want Kitchen with new IDs", p)
+				}
+			},
+		},
+		{
+			name: "matched by name, keeping what the import does not carry",
+			in: []Project{{Name: "garden", Quests: []Quest{{ID: "q1", Title: "Weed beds", State: StateActive,
+				Tasks: []Task{{ID: "t1", Description: "Front bed"}, {Description: "Side bed"}}}}}},
+			want: ImportSummary{MergedQuests: 1, MergedTasks: 1, AddedTasks: 1},
+			check: func(t *testing.T, s *Store) {
+				p := s.Projects()[0]
+				if p.Name != "garden" || len(p.Quests) != 2 || p.Quests[1].ID != "q2" {
+					t.Fatalf("quests = %+v, want q1 merged and q2 kept", p.Quests)
+				}
+				q := p.Quests[0]
+				if q.Title != "Weed beds" || len(q.Tasks) != 3 {
+					t.Fatalf("quest = %+v, want the new title and t1, Side bed and the kept t2", q)
+				}
+				t1 := q.Tasks[0]
+				if t1.ID != "t1" || len(t1.TimeEntries) != 1 {
+					t.Errorf("t1 = %+v, want its time entries kept", t1)
+				}
+				if q.Tasks[1].Description != "Side bed" || q.Tasks[2].ID != "t2" {
+					t.Errorf("tasks = %+v, want Side bed then the kept t2", q.Tasks)
+				}
+			},
+		},
+		{
+			name: "checking off and unchecking earns and takes back XP",
+			in: []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{ID: "q1", Title: "Weed", State: StateCompleted,
+				Tasks: []Task{{ID: "t1", Description: "Front bed", Done: true}, {ID: "t2", Description: "Back bed"}}}}}},
+			want: ImportSummary{MergedQuests: 1, MergedTasks: 2},
+			check: func(t *testing.T, s *Store) {
+				items := make(map[string]bool)
+				for _, e := range s.XPLog() {
+					if e.Kind != KindAchievement {
+						items[e.ItemID+"@"+e.ProjectID] = true
+					}
+				}
+				if !items["t1@p1"] || !items["q1@p1"] || items["t2@p1"] {
+					t.Errorf("XP log items = %v, want t1 and q1 and no t2", items)
+				}
+				q := s.Projects()[0].Quests[0]
+				if q.CompletedAt == nil || len(q.History) != 1 {
+					t.Errorf("quest = %+v, want the completion stamped", q)
+				}
+			},
+		},
+		{
+			name: "a task moves to the quest the import puts it in",
+			in:   []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{ID: "q2", Title: "Mow", Tasks: []Task{{ID: "t1", Description: "Front bed"}}}}}},
+			want: ImportSummary{MergedQuests: 1, MergedTasks: 1},
+			check: func(t *testing.T, s *Store) {
+				p := s.Projects()[0]
+				if len(p.Quests) != 2 || p.Quests[0].ID != "q2" || len(p.Quests[0].Tasks) != 1 {
+					t.Fatalf("quests = %+v, want q2 holding t1 first", p.Quests)
+				}
+				if len(p.Quests[1].Tasks) != 1 || p.Quests[1].Tasks[0].ID != "t2" {
+					t.Errorf("q1 tasks = %+v, want only t2", p.Quests[1].Tasks)
+				}
+			},
+		},
+		{
+			name:    "an ID from another project",
+			in:      []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{ID: "q9", Title: "Paint"}}}},
+			wantErr: "belongs to another project",
+		},
+		{
+			name:    "an ID listed twice",
+			in:      []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{ID: "q1", Title: "A"}, {ID: "q1", Title: "B"}}}},
+			wantErr: "listed twice",
+		},
+		{
+			name:    "a project listed twice",
+			in:      []Project{{ID: "p1", Name: "Garden"}, {Name: "garden"}},
+			wantErr: "listed twice",
+		},
+		{
+			name:    "an unknown blocker",
+			in:      []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{ID: "q1", Title: "Weed", BlockedBy: []string{"nope"}}}}},
+			wantErr: "not a quest",
+		},
+		{
+			name: "a dependency cycle",
+			in: []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{ID: "q1", Title: "Weed",
+				Tasks: []Task{{ID: "t1", Description: "a", BlockedBy: []string{"t2"}}, {ID: "t2", Description: "b", BlockedBy: []string{"t1"}}}}}}},
+			wantErr: "waits on itself",
+		},
+		{
+			name:    "nothing",
+			in:      nil,
+			wantErr: "nothing to import",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(importFixture())
+			sum, err := s.ImportProjects(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ImportProjects error = %v, want one containing %q", err, tt.wantErr)
+				}
+				if s.History().CanUndo() {
+					t.Errorf("a refused import was recorded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportProjects failed: %v", err)
+			}
+			sum.Projects = nil
+			if !reflect.DeepEqual(sum, tt.want) {
+				t.Errorf("summary = %+v, want %+v", sum, tt.want)
+			}
+			tt.check(t, s)
+
+			// The import is one change, and undoing it restores the fixture
+			if _, err := s.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := FormatMarkdown(s.Projects()), FormatMarkdown(importFixture()); got != want {
+				t.Errorf("undo left\n%s\nwant\n%s", got, want)
+			}
+			if len(s.XPLog()) != 0 {
+				t.Errorf("undo left %+v in the XP log", s.XPLog())
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// StateChange records one lifecycle transition of a quest
+type StateChange struct {
+	From QuestState `json:"from"`
+	To   QuestState `json:"to"`
+	At   time.Time  `json:"at"`
+}
+
+// TransitionError reports a lifecycle change that is not allowed
+type TransitionError struct {
+	From QuestState
+	To   QuestState
+}
+
+// Error implements error interface
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move a quest from %s to %s", e.From, e.To)
+}
+
+// transitions lists the states each state may move to
+var transitions = map[QuestState][]QuestState{
+	StateActive:     {StateInProgress, StateCompleted, StateCancelled},
+	StateInProgress: {StateActive, StateCompleted, StateCancelled},
+	StateCompleted:  {StateActive, StateInProgress, StateArchived},
+	StateCancelled:  {StateActive, StateInProgress, StateArchived},
+	StateArchived:   {StateActive},
+}
+
+// IsOpen reports whether a quest in this state is still to do or under way
+func (s QuestState) IsOpen() bool {
+	return s == StateActive || s == StateInProgress
+}
+
+// CanTransition reports whether a quest may move from one state to another
+func CanTransition(from, to QuestState) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transition validates and applies a state change, stamping its time
+func (q *Quest) transition(to QuestState, at time.Time) error {
+	if !CanTransition(q.State, to) {
+		return &TransitionError{From: q.State, To: to}
+	}
+	q.History = append(q.History, StateChange{From: q.State, To: to, At: at})
+	switch to {
+	case StateActive, StateInProgress:
+		q.CompletedAt = nil
+		q.CancelledAt = nil
+		q.ArchivedAt = nil
+	case StateCompleted:
+		q.CompletedAt = &at
+	case StateCancelled:
+		q.CancelledAt = &at
+	case StateArchived:
+		q.ArchivedAt = &at
+	}
+	q.State = to
+	return nil
+}
+
+// IsFinished reports whether the quest was completed or cancelled and not yet archived
+func (q *Quest) IsFinished() bool {
+	return q.State == StateCompleted || q.State == StateCancelled
+}
+
+// FinishedAt returns when the quest was completed or cancelled, if it was
+func (q *Quest) FinishedAt() *time.Time {
+	switch q.State {
+	case StateCompleted:
+		return q.CompletedAt
+	case StateCancelled:
+		return q.CancelledAt
+	}
+	return nil
+}
+
+// setQuestState moves a quest to a new state
+func (s *Store) setQuestState(id string, to QuestState) error {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return err
+	}
+	q := loc.quest()
+	if !CanTransition(q.State, to) {
+		return &TransitionError{From: q.State, To: to}
+	}
+	return s.mutate(fmt.Sprintf("mark quest %q %s", q.Title, to), []string{loc.project.ID}, func() error {
+		return s.changeState(loc, to, time.Now())
+	})
+}
+
+// changeState moves a located quest to a new state, awarding or taking back
+// its XP and spawning the next occurrence of a recurring quest. XP is taken
+// back whenever a quest with a completion on record opens again, even when
+// it was archived in between. The quest leaves its board column, which only
+// applies to the state it was set in.
+func (s *Store) changeState(loc questLocation, to QuestState, now time.Time) error {
+	q := loc.quest()
+	completed := q.CompletedAt != nil
+	if err := q.transition(to, now); err != nil {
+		return err
+	}
+	q.Column = ""
+	if to == StateCompleted {
+		s.award(questAward(loc.project.ID, q, now))
+	} else if completed && to.IsOpen() {
+		s.revokeXP(q.ID)
+	}
+	spawnNextQuest(loc, now)
+	loc.project.CalculateProgress()
+	return nil
+}
+
+// CompleteQuest marks an active quest completed
+func (s *Store) CompleteQuest(id string) error {
+	return s.setQuestState(id, StateCompleted)
+}
+
+// CancelQuest marks an active quest cancelled
+func (s *Store) CancelQuest(id string) error {
+	return s.setQuestState(id, StateCancelled)
+}
+
+// StartQuest marks a quest as in progress
+func (s *Store) StartQuest(id string) error {
+	return s.setQuestState(id, StateInProgress)
+}
+
+// ReopenQuest makes a completed, cancelled or archived quest active again
+func (s *Store) ReopenQuest(id string) error {
+	return s.setQuestState(id, StateActive)
+}
+
+// ArchiveQuest hides a completed or cancelled quest from the finished list
+func (s *Store) ArchiveQuest(id string) error {
+	return s.setQuestState(id, StateArchived)
+}
+
+// SetAutoComplete turns the auto-complete rule on or off for a project
+func (s *Store) SetAutoComplete(projectID string, enabled bool) error {
+	p, err := s.Project(projectID)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("set auto-complete on project %q", p.Name), []string{projectID}, func() error {
+		p.AutoComplete = enabled
+		return nil
+	})
+}
+
+// SetProgressMode chooses how a project's progress is calculated
+func (s *Store) SetProgressMode(projectID, mode string) error {
+	if !ValidProgressMode(mode) {
+		return fmt.Errorf("unknown progress mode %q (use %s or %s)", mode, ProgressByCount, ProgressByEffort)
+	}
+	p, err := s.Project(projectID)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("set progress mode of project %q to %s", p.Name, mode), []string{projectID}, func() error {
+		p.ProgressMode = mode
+		if mode == ProgressByCount {
+			p.ProgressMode = ""
+		}
+		p.CalculateProgress()
+		return nil
+	})
+}
+
+// autoComplete completes the quest holding a changed task, and then each
+// ancestor in turn, once all of its tasks and sub-quests are done. It only
+// applies to projects with AutoComplete enabled.
+func (s *Store) autoComplete(questID string) {
+	for questID != "" {
+		loc, err := s.locateQuest(questID)
+		if err != nil || !loc.project.AutoComplete {
+			return
+		}
+		q := loc.quest()
+		if !q.State.IsOpen() || len(q.Tasks)+len(q.SubQuests) == 0 || q.Progress < 100.0 {
+			return
+		}
+		if err := s.changeState(loc, StateCompleted, time.Now()); err != nil {
+			return
+		}
+		questID = ""
+		if loc.parent != nil {
+			questID = loc.parent.ID
+		}
+	}
+}
+
+// collectFinished appends completed and cancelled quests at any depth
+func collectFinished(quests []Quest, finished *[]Quest) {
+	for _, quest := range quests {
+		if quest.IsFinished() {
+			*finished = append(*finished, quest)
+		}
+		collectFinished(quest.SubQuests, finished)
+	}
+}
+
+// FinishedQuests returns completed and cancelled quests, most recently
+// finished first. An empty projectID searches every project.
+func FinishedQuests(projects []Project, projectID string) []Quest {
+	var finished []Quest
+	for _, project := range projects {
+		if projectID == "" || project.ID == projectID {
+			collectFinished(project.Quests, &finished)
+		}
+	}
+	sort.SliceStable(finished, func(i, j int) bool {
+		a, b := finished[i].FinishedAt(), finished[j].FinishedAt()
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(*b)
+	})
+	return finished
+}
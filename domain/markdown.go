@@ -0,0 +1,419 @@
+package domain
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stateKeywords names the quest states in exported files
+var stateKeywords = map[QuestState]string{
+	StateActive:     "active",
+	StateInProgress: "in-progress",
+	StateCompleted:  "completed",
+	StateCancelled:  "cancelled",
+	StateArchived:   "archived",
+}
+
+// parseStateKeyword reads a state written by an export, or any state name
+// accepted in board column specs
+func parseStateKeyword(s string) (QuestState, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for state, keyword := range stateKeywords {
+		if keyword == s {
+			return state, nil
+		}
+	}
+	if state, ok := stateNames[s]; ok {
+		return state, nil
+	}
+	return 0, fmt.Errorf("unknown state %q (use active, in-progress, completed, cancelled or archived)", s)
+}
+
+// Metadata keys written under Markdown headings and tasks
+var (
+	projectKeys = map[string]bool{"id": true, "auto_complete": true, "progress_mode": true, "board_columns": true}
+	questKeys   = map[string]bool{"id": true, "state": true, "priority": true, "deadline": true, "estimate": true,
+		"tags": true, "repeat": true, "blocked_by": true, "column": true}
+	taskKeys = map[string]bool{"id": true, "priority": true, "deadline": true, "estimate": true, "tags": true,
+		"repeat": true, "blocked_by": true}
+)
+
+// FormatMarkdown renders projects as Markdown: a "#" heading per project and
+// a deeper heading per quest, each followed by "key: value" metadata lines,
+// the quest's description, and its tasks as a checklist with their own
+// indented metadata and "> " note lines. ParseMarkdown reads it back.
+func FormatMarkdown(projects []Project) string {
+	var b strings.Builder
+	for i, p := range projects {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("# " + p.Name + "\n")
+		writeMeta(&b, "", "id", p.ID)
+		if p.AutoComplete {
+			writeMeta(&b, "", "auto_complete", "true")
+		}
+		writeMeta(&b, "", "progress_mode", p.ProgressMode)
+		if len(p.BoardColumns) > 0 {
+			writeMeta(&b, "", "board_columns", FormatBoardColumns(p.BoardColumns))
+		}
+		writeQuestsMarkdown(&b, p.Quests, 2)
+	}
+	return b.String()
+}
+
+// writeMeta writes a metadata line, skipping empty values
+func writeMeta(b *strings.Builder, indent, key, value string) {
+	if value != "" {
+		b.WriteString(indent + key + ": " + value + "\n")
+	}
+}
+
+// writeItemMeta writes the metadata quests and tasks share
+func writeItemMeta(b *strings.Builder, indent string, priority int, deadline *time.Time, estimate int, tags []string, recur *Recurrence, blockedBy []string) {
+	if priority != 0 {
+		writeMeta(b, indent, "priority", strconv.Itoa(priority))
+	}
+	if deadline != nil {
+		writeMeta(b, indent, "deadline", deadline.Format("2006-01-02"))
+	}
+	if estimate > 0 {
+		writeMeta(b, indent, "estimate", FormatEstimate(estimate))
+	}
+	writeMeta(b, indent, "tags", FormatTags(tags))
+	if recur != nil {
+		writeMeta(b, indent, "repeat", recur.String())
+	}
+	writeMeta(b, indent, "blocked_by", strings.Join(blockedBy, ", "))
+}
+
+// writeQuestsMarkdown writes a quest tree with headings of the given level
+func writeQuestsMarkdown(b *strings.Builder, quests []Quest, level int) {
+	for _, q := range quests {
+		b.WriteString("\n" + strings.Repeat("#", level) + " " + q.Title + "\n")
+		writeMeta(b, "", "id", q.ID)
+		writeMeta(b, "", "state", stateKeywords[q.State])
+		writeItemMeta(b, "", q.Priority, q.Deadline, q.Estimate, q.Tags, q.Recur, q.BlockedBy)
+		writeMeta(b, "", "column", q.Column)
+		if q.Description != "" {
+			b.WriteString("\n")
+			for _, line := range strings.Split(q.Description, "\n") {
+				b.WriteString(escapeDescriptionLine(line) + "\n")
+			}
+		}
+		if len(q.Tasks) > 0 {
+			b.WriteString("\n")
+		}
+		for _, t := range q.Tasks {
+			box := "[ ]"
+			if t.Done {
+				box = "[x]"
+			}
+			b.WriteString("- " + box + " " + t.Description + "\n")
+			writeMeta(b, "  ", "id", t.ID)
+			writeItemMeta(b, "  ", t.Priority, t.Deadline, t.Estimate, t.Tags, t.Recur, t.BlockedBy)
+			if t.Notes != "" {
+				for _, line := range strings.Split(t.Notes, "\n") {
+					b.WriteString(strings.TrimRight("  > "+line, " ") + "\n")
+				}
+			}
+		}
+		writeQuestsMarkdown(b, q.SubQuests, level+1)
+	}
+}
+
+// escapeDescriptionLine guards a description line that would otherwise be
+// read back as a heading, a task or metadata
+func escapeDescriptionLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "\\") || isTaskLine(trimmed) {
+		return "\\" + line
+	}
+	if key, _, ok := cutMeta(trimmed); ok && questKeys[key] {
+		return "\\" + line
+	}
+	return line
+}
+
+// isTaskLine reports whether a line starts a checklist item
+func isTaskLine(line string) bool {
+	_, _, ok := parseTaskLine(line)
+	return ok
+}
+
+// parseTaskLine reads "- [ ] text" or "- [x] text", also with "*" bullets
+func parseTaskLine(line string) (text string, done, ok bool) {
+	if !strings.HasPrefix(line, "- [") && !strings.HasPrefix(line, "* [") {
+		return "", false, false
+	}
+	if len(line) < 5 || line[4] != ']' {
+		return "", false, false
+	}
+	switch line[3] {
+	case ' ':
+	case 'x', 'X':
+		done = true
+	default:
+		return "", false, false
+	}
+	return strings.TrimSpace(line[5:]), done, true
+}
+
+// cutMeta splits a "key: value" line
+func cutMeta(line string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(line, ":")
+	if !ok || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return strings.ToLower(key), strings.TrimSpace(value), true
+}
+
+// headingLevel returns the number of leading "#" of a heading line, or 0
+func headingLevel(line string) (level int, text string) {
+	level = len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || len(line) == level || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(line[level:])
+}
+
+// markdownParser holds the state of ParseMarkdown
+type markdownParser struct {
+	projects []Project
+	stack    []*Quest // the open quest at each depth below the project
+	quest    *Quest   // the quest whose body is being read
+	task     *Task    // the task whose indented lines are being read
+	inMeta   bool     // still reading metadata under the last heading
+	desc     []string // description lines of quest
+	notes    []string // note lines of task
+}
+
+// ParseMarkdown reads projects in the form FormatMarkdown writes. Metadata
+// that is missing leaves a field empty, and IDs may be left out of items
+// drafted by hand.
+func ParseMarkdown(r io.Reader) ([]Project, error) {
+	var p markdownParser
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if err := p.line(strings.TrimRight(scanner.Text(), " \t\r")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.flush()
+	return p.projects, nil
+}
+
+// project returns the project being read
+func (p *markdownParser) project() *Project {
+	return &p.projects[len(p.projects)-1]
+}
+
+// flush stores the description and notes collected so far
+func (p *markdownParser) flush() {
+	if p.task != nil {
+		p.task.Notes = strings.Join(p.notes, "\n")
+		p.task, p.notes = nil, nil
+	}
+	if p.quest != nil {
+		p.quest.Description = strings.TrimSpace(strings.Join(p.desc, "\n"))
+	}
+}
+
+// line reads one line
+func (p *markdownParser) line(line string) error {
+	if level, text := headingLevel(line); level > 0 {
+		return p.heading(level, text)
+	}
+	if p.task != nil {
+		if strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t") {
+			return p.taskLine(strings.TrimSpace(line))
+		}
+		p.flush()
+	}
+	if text, done, ok := parseTaskLine(line); ok {
+		if p.quest == nil {
+			return fmt.Errorf("task %q is not under a quest heading", text)
+		}
+		p.inMeta = false
+		p.quest.Tasks = append(p.quest.Tasks, Task{Description: text, Done: done})
+		p.task = &p.quest.Tasks[len(p.quest.Tasks)-1]
+		return nil
+	}
+	if len(p.projects) == 0 {
+		if line == "" {
+			return nil
+		}
+		return fmt.Errorf("text before the first project heading")
+	}
+	if p.inMeta {
+		if line == "" {
+			p.inMeta = false
+			return nil
+		}
+		if key, value, ok := cutMeta(line); ok {
+			if p.quest == nil {
+				return p.projectMeta(key, value)
+			}
+			if questKeys[key] {
+				return p.questMeta(key, value)
+			}
+		}
+		p.inMeta = false
+	}
+	if p.quest == nil {
+		if line == "" {
+			return nil
+		}
+		return fmt.Errorf("text under a project heading; put it under a quest")
+	}
+	if strings.HasPrefix(line, "\\") {
+		line = line[1:]
+	}
+	p.desc = append(p.desc, line)
+	return nil
+}
+
+// heading starts a project at level 1 or a quest below it
+func (p *markdownParser) heading(level int, text string) error {
+	p.flush()
+	if text == "" {
+		return fmt.Errorf("empty heading")
+	}
+	p.inMeta = true
+	p.desc = nil
+	if level == 1 {
+		p.projects = append(p.projects, Project{Name: text, Quests: []Quest{}})
+		p.stack, p.quest = nil, nil
+		return nil
+	}
+	if len(p.projects) == 0 {
+		return fmt.Errorf("quest %q comes before any project heading", text)
+	}
+	depth := level - 2
+	if depth > len(p.stack) {
+		return fmt.Errorf("heading %q skips a level", text)
+	}
+	siblings := &p.project().Quests
+	if depth > 0 {
+		siblings = &p.stack[depth-1].SubQuests
+	}
+	*siblings = append(*siblings, Quest{Title: text, Tasks: []Task{}, State: StateActive})
+	// Appending may move the siblings, so deeper pointers are rebuilt
+	p.stack = append(p.stack[:depth], &(*siblings)[len(*siblings)-1])
+	p.quest = p.stack[depth]
+	return nil
+}
+
+// projectMeta applies a metadata line under a project heading
+func (p *markdownParser) projectMeta(key, value string) error {
+	if !projectKeys[key] {
+		return fmt.Errorf("unknown project field %q", key)
+	}
+	project := p.project()
+	switch key {
+	case "id":
+		project.ID = value
+	case "auto_complete":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("auto_complete must be true or false, got %q", value)
+		}
+		project.AutoComplete = enabled
+	case "progress_mode":
+		if !ValidProgressMode(value) {
+			return fmt.Errorf("unknown progress mode %q", value)
+		}
+		project.ProgressMode = value
+	case "board_columns":
+		columns, err := ParseBoardColumns(value)
+		if err != nil {
+			return err
+		}
+		project.BoardColumns = columns
+	}
+	return nil
+}
+
+// questMeta applies a metadata line under a quest heading
+func (p *markdownParser) questMeta(key, value string) error {
+	q := p.quest
+	switch key {
+	case "id":
+		q.ID = value
+	case "state":
+		state, err := parseStateKeyword(value)
+		if err != nil {
+			return err
+		}
+		q.State = state
+	case "column":
+		q.Column = value
+	default:
+		return parseItemMeta(key, value, &q.Priority, &q.Deadline, &q.Estimate, &q.Tags, &q.Recur, &q.BlockedBy)
+	}
+	return nil
+}
+
+// taskLine applies an indented line under a task: a note or metadata
+func (p *markdownParser) taskLine(line string) error {
+	if line == ">" || strings.HasPrefix(line, "> ") {
+		p.notes = append(p.notes, strings.TrimPrefix(strings.TrimPrefix(line, ">"), " "))
+		return nil
+	}
+	key, value, ok := cutMeta(line)
+	if !ok || !taskKeys[key] {
+		return fmt.Errorf("unexpected line under task %q (use key: value or > notes)", p.task.Description)
+	}
+	t := p.task
+	if key == "id" {
+		t.ID = value
+		return nil
+	}
+	return parseItemMeta(key, value, &t.Priority, &t.Deadline, &t.Estimate, &t.Tags, &t.Recur, &t.BlockedBy)
+}
+
+// parseItemMeta reads the metadata quests and tasks share
+func parseItemMeta(key, value string, priority *int, deadline **time.Time, estimate *int, tags *[]string, recur **Recurrence, blockedBy *[]string) error {
+	switch key {
+	case "priority":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid priority %q", value)
+		}
+		*priority = n
+	case "deadline":
+		d, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return fmt.Errorf("invalid deadline %q (use YYYY-MM-DD)", value)
+		}
+		*deadline = &d
+	case "estimate":
+		minutes, err := ParseEstimate(value)
+		if err != nil {
+			return err
+		}
+		*estimate = minutes
+	case "tags":
+		*tags = ParseTags(value)
+	case "repeat":
+		r, err := ParseRecurrence(value)
+		if err != nil {
+			return err
+		}
+		*recur = r
+	case "blocked_by":
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				*blockedBy = append(*blockedBy, id)
+			}
+		}
+	}
+	return nil
+}
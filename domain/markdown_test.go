@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	day := func(s string) *time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	columns, err := ParseBoardColumns("Todo=active,Doing=in-progress,Done=completed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		projects []Project
+	}{
+		{
+			name:     "empty project",
+			projects: []Project{{ID: "p1", Name: "Empty", Quests: []Quest{}}},
+		},
+		{
+			name: "project settings",
+			projects: []Project{{ID: "p1", Name: "Settings", AutoComplete: true, ProgressMode: ProgressByEffort,
+				BoardColumns: columns, Quests: []Quest{}}},
+		},
+		{
+			name: "quest and task metadata",
+			projects: []Project{{ID: "p1", Name: "Garden", Quests: []Quest{{
+				ID: "q1", Title: "Plant bulbs", State: StateInProgress, Priority: 7, Deadline: day("2026-10-31"),
+				Estimate: 150, Tags: []string{"outdoor", "autumn"}, Recur: &Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Saturday}},
+				Column: "Doing", Description: "Tulips along the fence.\nDaffodils by the gate.",
+				Tasks: []Task{
+					{ID: "t1", Description: "Buy bulbs", Done: true, Priority: 3, Deadline: day("2026-10-20"), Estimate: 45,
+						Tags: []string{"shop"}, Recur: &Recurrence{Freq: FreqAfter, Interval: 30}},
+					{ID: "t2", Description: "Dig beds", BlockedBy: []string{"t1"}, Notes: "Mind the roots.\n\nBorrow a spade."},
+				},
+			}, {
+				ID: "q2", Title: "Water", State: StateActive, BlockedBy: []string{"q1"}, Tasks: []Task{},
+			}}}},
+		},
+		{
+			name: "nested quests and every state",
+			projects: []Project{{ID: "p1", Name: "House", Quests: []Quest{{
+				ID: "q1", Title: "Renovate", State: StateActive, Tasks: []Task{},
+				SubQuests: []Quest{
+					{ID: "q2", Title: "Kitchen", State: StateCompleted, Tasks: []Task{{ID: "t1", Description: "Tiles", Done: true}},
+						SubQuests: []Quest{{ID: "q3", Title: "Sink", State: StateCancelled, Tasks: []Task{}}}},
+					{ID: "q4", Title: "Attic", State: StateArchived, Tasks: []Task{}},
+				},
+			}}}},
+		},
+		{
+			name: "descriptions that look like markup",
+			projects: []Project{{ID: "p1", Name: "Notes", Quests: []Quest{{
+				ID: "q1", Title: "Tricky", State: StateActive, Tasks: []Task{},
+				Description: "# not a heading\n- [ ] not a task\nstate: not metadata\n\\ a backslash",
+			}}}},
+		},
+		{
+			name: "several projects",
+			projects: []Project{
+				{ID: "p1", Name: "One", Quests: []Quest{{ID: "q1", Title: "A", State: StateActive, Tasks: []Task{{ID: "t1", Description: "x"}}}}},
+				{ID: "p2", Name: "Two", Quests: []Quest{{ID: "q2", Title: "B", State: StateActive, Tasks: []Task{{ID: "t2", Description: "y"}}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := FormatMarkdown(tt.projects)
+			parsed, err := ParseMarkdown(strings.NewReader(text))
+			if err != nil {
+				t.Fatalf("ParseMarkdown failed: %v\n%s", err, text)
+			}
+			if got := FormatMarkdown(parsed); got != text {
+				t.Errorf("round trip changed the Markdown\ngot:\n%s\nwant:\n%s", got, text)
+			}
+			if !reflect.DeepEqual(markdownFields(parsed), markdownFields(tt.projects)) {
+				t.Errorf("round trip changed the projects\ngot:  %+v\nwant: %+v", parsed, tt.projects)
+			}
+		})
+	}
+}
+
+// markdownFields strips what Markdown does not carry, and normalizes empty
+// lists, so projects can be compared after a round trip
+func markdownFields(projects []Project) []Project {
+	var strip func(quests []Quest) []Quest
+	strip = func(quests []Quest) []Quest {
+		var out []Quest
+		for _, q := range quests {
+			q.Progress, q.CreatedAt, q.CompletedAt, q.CancelledAt, q.ArchivedAt, q.History = 0, nil, nil, nil, nil, nil
+			var tasks []Task
+			for _, t := range q.Tasks {
+				t.TimeEntries = nil
+				tasks = append(tasks, t)
+			}
+			q.Tasks = tasks
+			q.SubQuests = strip(q.SubQuests)
+			out = append(out, q)
+		}
+		return out
+	}
+	var out []Project
+	for _, p := range projects {
+		p.Progress, p.Events = 0, nil
+		p.Quests = strip(p.Quests)
+		out = append(out, p)
+	}
+	return out
+}
+
+func TestParseMarkdownErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"text before a project", "stray\n# P\n"},
+		{"text under a project", "# P\n\nstray\n"},
+		{"task outside a quest", "# P\n- [ ] loose\n"},
+		{"skipped heading level", "# P\n### Deep\n"},
+		{"quest before a project", "## Q\n"},
+		{"unknown project field", "# P\ncolour: red\n"},
+		{"unknown progress mode", "# P\nprogress_mode: vibes\n"},
+		{"unknown state", "# P\n## Q\nstate: dormant\n"},
+		{"bad deadline", "# P\n## Q\ndeadline: 31/10/2026\n"},
+		{"bad repeat rule", "# P\n## Q\nrepeat: ,\n"},
+		{"unexpected task line", "# P\n## Q\n- [ ] t\n  colour: red\n"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseMarkdown(strings.NewReader(tt.in)); err == nil {
+			t.Errorf("%s: ParseMarkdown succeeded, want an error", tt.name)
+		}
+	}
+}
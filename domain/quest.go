@@ -3,208 +3,316 @@ package domain
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// Progress modes for a project
+const (
+	ProgressByCount  = "count"  // every task and sub-quest counts the same
+	ProgressByEffort = "effort" // tasks and sub-quests are weighted by their estimates
+)
+
+// ValidProgressMode reports whether mode is a known progress mode
+func ValidProgressMode(mode string) bool {
+	return mode == ProgressByCount || mode == ProgressByEffort
+}
+
 // CalculateProgress computes the progress of a quest based on completed tasks
+// and the progress of its sub-quests. Each task and each sub-quest counts as
+// one unit, and sub-quests are recalculated recursively first.
 func (q *Quest) CalculateProgress() {
-	if len(q.Tasks) == 0 {
+	q.calculateProgress(false)
+}
+
+// calculateProgress computes a quest's progress, weighting tasks and
+// sub-quests by effort when byEffort is set
+func (q *Quest) calculateProgress(byEffort bool) {
+	units := len(q.Tasks) + len(q.SubQuests)
+	if units == 0 {
 		q.Progress = 0.0
 		return
 	}
-	completed := 0
+	progress := make([]float64, 0, units)
+	weights := make([]int, 0, units)
 	for _, task := range q.Tasks {
+		done := 0.0
 		if task.Done {
-			completed++
+			done = 100.0
+		}
+		progress = append(progress, done)
+		weights = append(weights, task.Estimate)
+	}
+	for i := range q.SubQuests {
+		q.SubQuests[i].calculateProgress(byEffort)
+		progress = append(progress, q.SubQuests[i].Progress)
+		weights = append(weights, q.SubQuests[i].Effort())
+	}
+	if !byEffort {
+		weights = nil
+	}
+	q.Progress = weightedAverage(progress, weights)
+}
+
+// Effort returns the quest's estimate in minutes, or without one the sum of
+// the estimates of its tasks and sub-quests. Zero means nothing is estimated.
+func (q Quest) Effort() int {
+	if q.Estimate > 0 {
+		return q.Estimate
+	}
+	total := 0
+	for _, task := range q.Tasks {
+		total += task.Estimate
+	}
+	for _, sub := range q.SubQuests {
+		total += sub.Effort()
+	}
+	return total
+}
+
+// weightedAverage averages values by weight. Items without a weight count
+// as the mean of the known weights, and with no weights at all every item
+// counts the same.
+func weightedAverage(values []float64, weights []int) float64 {
+	known, sum := 0, 0
+	for _, w := range weights {
+		if w > 0 {
+			known++
+			sum += w
 		}
 	}
-	q.Progress = float64(completed) / float64(len(q.Tasks)) * 100.0
+	total, totalWeight := 0.0, 0.0
+	for i, v := range values {
+		w := 1.0
+		if known > 0 {
+			w = float64(sum) / float64(known)
+			if weights[i] > 0 {
+				w = float64(weights[i])
+			}
+		}
+		total += v * w
+		totalWeight += w
+	}
+	return total / totalWeight
 }
 
-// CalculateProgress computes the progress of a project based on quest progresses
+// CalculateProgress computes the progress of a project based on quest progresses,
+// recalculating each quest tree first. In effort mode quests are weighted by
+// their estimates.
 func (p *Project) CalculateProgress() {
 	if len(p.Quests) == 0 {
 		p.Progress = 0.0
 		return
 	}
-	total := 0.0
-	for _, quest := range p.Quests {
-		total += quest.Progress
+	byEffort := p.ProgressMode == ProgressByEffort
+	progress := make([]float64, len(p.Quests))
+	var weights []int
+	for i := range p.Quests {
+		p.Quests[i].calculateProgress(byEffort)
+		progress[i] = p.Quests[i].Progress
+		if byEffort {
+			weights = append(weights, p.Quests[i].Effort())
+		}
 	}
-	p.Progress = total / float64(len(p.Quests))
+	p.Progress = weightedAverage(progress, weights)
 }
 
-// IsLeaf returns true if the quest has no sub-quests (assuming no nested quests for now)
+// IsLeaf returns true if the quest has no sub-quests
 func (q *Quest) IsLeaf() bool {
-	// Since we don't have sub-quests in the current model, all quests are leaf
-	return true
+	return len(q.SubQuests) == 0
 }
 
-// DailyPlanner returns leaf quests ordered by priority (higher first) and deadlines (soonest first)
-func DailyPlanner(projects []Project) []Quest {
-	var leafQuests []Quest
-	for _, project := range projects {
-		for _, quest := range project.Quests {
-			if quest.IsLeaf() && quest.State == StateActive {
-				leafQuests = append(leafQuests, quest)
-			}
+// collectActiveLeaves appends the open leaf quests of a quest tree to leaves.
+// Sub-quests of finished quests are skipped along with their parent.
+func collectActiveLeaves(quests []Quest, leaves *[]Quest) {
+	for _, quest := range quests {
+		if !quest.State.IsOpen() {
+			continue
+		}
+		if quest.IsLeaf() {
+			*leaves = append(*leaves, quest)
+		} else {
+			collectActiveLeaves(quest.SubQuests, leaves)
 		}
 	}
+}
 
-	sort.Slice(leafQuests, func(i, j int) bool {
+// sortForPlanning orders quests by priority (higher first) and deadlines (soonest first)
+func sortForPlanning(quests []Quest) {
+	sort.Slice(quests, func(i, j int) bool {
 		// Higher priority first
-		if leafQuests[i].Priority != leafQuests[j].Priority {
-			return leafQuests[i].Priority > leafQuests[j].Priority
+		if quests[i].Priority != quests[j].Priority {
+			return quests[i].Priority > quests[j].Priority
 		}
 		// Soonest deadline first
-		if leafQuests[i].Deadline != nil && leafQuests[j].Deadline != nil {
-			return leafQuests[i].Deadline.Before(*leafQuests[j].Deadline)
+		if quests[i].Deadline != nil && quests[j].Deadline != nil {
+			return quests[i].Deadline.Before(*quests[j].Deadline)
 		}
-		if leafQuests[i].Deadline != nil {
+		if quests[i].Deadline != nil {
 			return true
 		}
-		if leafQuests[j].Deadline != nil {
+		if quests[j].Deadline != nil {
 			return false
 		}
 		return false
 	})
+}
 
+// DailyPlanner returns leaf quests ordered by priority (higher first) and
+// deadlines (soonest first). Blocked quests are left out until their
+// blockers are finished.
+func DailyPlanner(projects []Project) []Quest {
+	var leafQuests []Quest
+	for _, project := range projects {
+		collectActiveLeaves(project.Quests, &leafQuests)
+	}
+	leafQuests, _ = splitBlocked(leafQuests, BlockedItems(projects))
+	sortForPlanning(leafQuests)
 	return leafQuests
 }
 
-// DailyPlannerForProject returns active quests for a specific project
-func DailyPlannerForProject(projects []Project, projectIdx int) []Quest {
-	if projectIdx < 0 || projectIdx >= len(projects) {
-		return []Quest{}
-	}
-	var leafQuests []Quest
-	for _, quest := range projects[projectIdx].Quests {
-		if quest.IsLeaf() && quest.State == StateActive {
-			leafQuests = append(leafQuests, quest)
+// DailyPlannerForProject returns active, unblocked leaf quests for a specific project
+func DailyPlannerForProject(projects []Project, projectID string) []Quest {
+	for _, project := range projects {
+		if project.ID == projectID {
+			var leafQuests []Quest
+			collectActiveLeaves(project.Quests, &leafQuests)
+			leafQuests, _ = splitBlocked(leafQuests, BlockedItems(projects))
+			sortForPlanning(leafQuests)
+			return leafQuests
 		}
 	}
+	return []Quest{}
+}
 
-	sort.Slice(leafQuests, func(i, j int) bool {
-		// Higher priority first
-		if leafQuests[i].Priority != leafQuests[j].Priority {
-			return leafQuests[i].Priority > leafQuests[j].Priority
-		}
-		// Soonest deadline first
-		if leafQuests[i].Deadline != nil && leafQuests[j].Deadline != nil {
-			return leafQuests[i].Deadline.Before(*leafQuests[j].Deadline)
-		}
-		if leafQuests[i].Deadline != nil {
-			return true
-		}
-		if leafQuests[j].Deadline != nil {
-			return false
+// BlockedQuests returns the active leaf quests the planner leaves out
+// because they are blocked. An empty projectID searches every project.
+func BlockedQuests(projects []Project, projectID string) []Quest {
+	var leafQuests []Quest
+	for _, project := range projects {
+		if projectID == "" || project.ID == projectID {
+			collectActiveLeaves(project.Quests, &leafQuests)
 		}
-		return false
-	})
-
-	return leafQuests
+	}
+	_, blocked := splitBlocked(leafQuests, BlockedItems(projects))
+	sortForPlanning(blocked)
+	return blocked
 }
 
-// generateID generates a unique ID using timestamp
-func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+// splitBlocked separates quests that are free to work on from blocked ones
+func splitBlocked(quests []Quest, blockedIDs map[string]bool) (free, blocked []Quest) {
+	free = []Quest{}
+	for _, q := range quests {
+		if blockedIDs[q.ID] {
+			blocked = append(blocked, q)
+		} else {
+			free = append(free, q)
+		}
+	}
+	return free, blocked
 }
 
-// CreateProject adds a new project to the list
-func CreateProject(projects *[]Project, name string) *Project {
-	id := generateID()
-	p := Project{ID: id, Name: name}
-	*projects = append(*projects, p)
-	return &(*projects)[len(*projects)-1]
+// PlannedTask is a task surfaced by the planner together with its quest
+type PlannedTask struct {
+	Task       Task   `json:"task"`
+	QuestID    string `json:"quest_id"`
+	QuestTitle string `json:"quest_title"`
+	ProjectID  string `json:"project_id"`
+	Blocked    bool   `json:"blocked,omitempty"`
 }
 
-// UpdateProject updates an existing project
-func UpdateProject(projects *[]Project, index int, name string) {
-	if index >= 0 && index < len(*projects) {
-		(*projects)[index].Name = name
+// IsOverdue reports whether a deadline is on a day before now
+func IsOverdue(deadline *time.Time, now time.Time) bool {
+	if deadline == nil {
+		return false
 	}
+	return deadline.Format("2006-01-02") < now.Format("2006-01-02")
 }
 
-// DeleteProject removes a project and its quests
-func DeleteProject(projects *[]Project, index int) {
-	if index >= 0 && index < len(*projects) {
-		*projects = append((*projects)[:index], (*projects)[index+1:]...)
+// collectOverdueTasks appends the open, overdue tasks of open quests
+func collectOverdueTasks(projectID string, quests []Quest, now time.Time, tasks *[]PlannedTask) {
+	for _, quest := range quests {
+		if !quest.State.IsOpen() {
+			continue
+		}
+		for _, task := range quest.Tasks {
+			if !task.Done && IsOverdue(task.Deadline, now) {
+				*tasks = append(*tasks, PlannedTask{Task: task, QuestID: quest.ID, QuestTitle: quest.Title, ProjectID: projectID})
+			}
+		}
+		collectOverdueTasks(projectID, quest.SubQuests, now, tasks)
 	}
 }
 
-// CreateQuest adds a new quest to a project
-func CreateQuest(projects *[]Project, projectIndex int, title, description string, priority int, deadline *time.Time) *Quest {
-	if projectIndex < 0 || projectIndex >= len(*projects) {
-		return nil
+// OverdueTasks returns open tasks whose deadline has passed, ordered by
+// priority (higher first) and deadline (oldest first). An empty projectID
+// searches every project.
+func OverdueTasks(projects []Project, projectID string, now time.Time) []PlannedTask {
+	var tasks []PlannedTask
+	for _, project := range projects {
+		if projectID == "" || project.ID == projectID {
+			collectOverdueTasks(project.ID, project.Quests, now, &tasks)
+		}
 	}
-	id := generateID()
-	q := Quest{
-		ID:          id,
-		Title:       title,
-		Description: description,
-		Priority:    priority,
-		Deadline:    deadline,
-		State:       StateActive,
+	blocked := BlockedItems(projects)
+	for i := range tasks {
+		tasks[i].Blocked = blocked[tasks[i].Task.ID]
 	}
-	(*projects)[projectIndex].Quests = append((*projects)[projectIndex].Quests, q)
-	return &(*projects)[projectIndex].Quests[len((*projects)[projectIndex].Quests)-1]
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Task.Priority != tasks[j].Task.Priority {
+			return tasks[i].Task.Priority > tasks[j].Task.Priority
+		}
+		return tasks[i].Task.Deadline.Before(*tasks[j].Task.Deadline)
+	})
+	return tasks
 }
 
-// UpdateQuest updates an existing quest
-func UpdateQuest(projects *[]Project, projectIndex, questIndex int, title, description string, priority int, deadline *time.Time) {
-	if projectIndex >= 0 && projectIndex < len(*projects) && questIndex >= 0 && questIndex < len((*projects)[projectIndex].Quests) {
-		q := &(*projects)[projectIndex].Quests[questIndex]
-		q.Title = title
-		q.Description = description
-		q.Priority = priority
-		q.Deadline = deadline
-		q.CalculateProgress()
+// FormatEstimate renders minutes as e.g. "1h30m", "2h" or "45m"
+func FormatEstimate(minutes int) string {
+	h, m := minutes/60, minutes%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dh%dm", h, m)
 	}
 }
 
-// DeleteQuest removes a quest from a project
-func DeleteQuest(projects *[]Project, projectIndex, questIndex int) {
-	if projectIndex >= 0 && projectIndex < len(*projects) && questIndex >= 0 && questIndex < len((*projects)[projectIndex].Quests) {
-		(*projects)[projectIndex].Quests = append((*projects)[projectIndex].Quests[:questIndex], (*projects)[projectIndex].Quests[questIndex+1:]...)
+// ParseEstimate reads an estimate such as "90", "90m", "1h30m" or "2h" as
+// minutes. A bare number counts as minutes.
+func ParseEstimate(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
 	}
-}
-
-// CreateTask adds a new task to a quest
-func CreateTask(projects *[]Project, projectIndex, questIndex int, description string) *Task {
-	if projectIndex >= 0 && projectIndex < len(*projects) && questIndex >= 0 && questIndex < len((*projects)[projectIndex].Quests) {
-		id := generateID()
-		t := Task{ID: id, Description: description, Done: false}
-		(*projects)[projectIndex].Quests[questIndex].Tasks = append((*projects)[projectIndex].Quests[questIndex].Tasks, t)
-		(*projects)[projectIndex].Quests[questIndex].CalculateProgress()
-		return &(*projects)[projectIndex].Quests[questIndex].Tasks[len((*projects)[projectIndex].Quests[questIndex].Tasks)-1]
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, nil
 	}
-	return nil
-}
-
-// UpdateTask updates an existing task
-func UpdateTask(projects *[]Project, projectIndex, questIndex, taskIndex int, description string) {
-	if projectIndex >= 0 && projectIndex < len(*projects) && questIndex >= 0 && questIndex < len((*projects)[projectIndex].Quests) && taskIndex >= 0 && taskIndex < len((*projects)[projectIndex].Quests[questIndex].Tasks) {
-		(*projects)[projectIndex].Quests[questIndex].Tasks[taskIndex].Description = description
-		(*projects)[projectIndex].Quests[questIndex].CalculateProgress()
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid estimate %q (use e.g. 45m or 1h30m)", s)
 	}
+	return int(d.Round(time.Minute) / time.Minute), nil
 }
 
-// DeleteTask removes a task from a quest
-func DeleteTask(projects *[]Project, projectIndex, questIndex, taskIndex int) {
-	if projectIndex >= 0 && projectIndex < len(*projects) && questIndex >= 0 && questIndex < len((*projects)[projectIndex].Quests) && taskIndex >= 0 && taskIndex < len((*projects)[projectIndex].Quests[questIndex].Tasks) {
-		(*projects)[projectIndex].Quests[questIndex].Tasks = append((*projects)[projectIndex].Quests[questIndex].Tasks[:taskIndex], (*projects)[projectIndex].Quests[questIndex].Tasks[taskIndex+1:]...)
-		(*projects)[projectIndex].Quests[questIndex].CalculateProgress()
-	}
-}
+// lastID is the most recent timestamp handed out by generateID
+var lastID int64
 
-// FindQuestIndices finds the project and quest indices for a given quest ID
-func FindQuestIndices(projects []Project, questID string) (int, int) {
-	for pIdx, project := range projects {
-		for qIdx, quest := range project.Quests {
-			if quest.ID == questID {
-				return pIdx, qIdx
-			}
+// generateID generates a unique ID using timestamp. IDs created within the
+// same nanosecond, e.g. when copying a quest's tasks, are bumped forward.
+func generateID() string {
+	for {
+		last := atomic.LoadInt64(&lastID)
+		id := time.Now().UnixNano()
+		if id <= last {
+			id = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastID, last, id) {
+			return fmt.Sprintf("%d", id)
 		}
 	}
-	return -1, -1
 }
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateProgressWeighted(t *testing.T) {
+	task := func(done bool, estimate int) Task {
+		return Task{Description: "t", Done: done, Estimate: estimate}
+	}
+	tests := []struct {
+		name   string
+		mode   string
+		quests []Quest
+		want   float64
+	}{
+		{name: "no quests", mode: ProgressByEffort, want: 0},
+		{name: "empty quest", mode: ProgressByEffort, quests: []Quest{{}}, want: 0},
+		{name: "count ignores estimates", mode: ProgressByCount,
+			quests: []Quest{{Tasks: []Task{task(true, 60), task(false, 30)}}}, want: 50},
+		{name: "effort weights tasks", mode: ProgressByEffort,
+			quests: []Quest{{Tasks: []Task{task(true, 60), task(false, 30)}}}, want: 200.0 / 3},
+		{name: "unestimated tasks count as the average", mode: ProgressByEffort,
+			quests: []Quest{{Tasks: []Task{task(true, 60), task(false, 0), task(false, 30)}}}, want: 6000.0 / 135},
+		{name: "no estimates fall back to counting", mode: ProgressByEffort,
+			quests: []Quest{{Tasks: []Task{task(true, 0), task(false, 0), task(false, 0)}}}, want: 100.0 / 3},
+		{name: "count treats a sub-quest as one unit", mode: ProgressByCount,
+			quests: []Quest{{Tasks: []Task{task(true, 30)},
+				SubQuests: []Quest{{Tasks: []Task{task(true, 60), task(false, 60)}}}}}, want: 75},
+		{name: "effort weights a sub-quest by its tasks", mode: ProgressByEffort,
+			quests: []Quest{{Tasks: []Task{task(true, 30)},
+				SubQuests: []Quest{{Tasks: []Task{task(true, 60), task(false, 60)}}}}}, want: 60},
+		{name: "a sub-quest's own estimate wins", mode: ProgressByEffort,
+			quests: []Quest{{Tasks: []Task{task(true, 30)},
+				SubQuests: []Quest{{Estimate: 30, Tasks: []Task{task(true, 60), task(false, 60)}}}}}, want: 75},
+		{name: "count averages quests", mode: ProgressByCount,
+			quests: []Quest{{Tasks: []Task{task(true, 90)}}, {Tasks: []Task{task(false, 30)}}}, want: 50},
+		{name: "effort weights quests", mode: ProgressByEffort,
+			quests: []Quest{{Tasks: []Task{task(true, 90)}}, {Tasks: []Task{task(false, 30)}}}, want: 75},
+		{name: "an unestimated quest counts as the average", mode: ProgressByEffort,
+			quests: []Quest{{Tasks: []Task{task(true, 90)}}, {Tasks: []Task{task(false, 30)}}, {Tasks: []Task{task(false, 0)}}}, want: 50},
+	}
+	for _, tt := range tests {
+		p := Project{Name: "p", ProgressMode: tt.mode, Quests: tt.quests}
+		p.CalculateProgress()
+		if math.Abs(p.Progress-tt.want) > 1e-9 {
+			t.Errorf("%s: progress = %.4f, want %.4f", tt.name, p.Progress, tt.want)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies
+const (
+	FreqDaily   = "daily"
+	FreqWeekly  = "weekly"
+	FreqMonthly = "monthly"
+	FreqAfter   = "after" // a number of days after the last completion
+)
+
+// Recurrence is a repeat rule for a quest or task. Completing an instance
+// creates the next one, due on the following date the rule allows.
+type Recurrence struct {
+	Freq     string         `json:"freq"`
+	Interval int            `json:"interval,omitempty"`  // days for daily and after
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`  // weekly; defaults to the due date's weekday
+	MonthDay int            `json:"month_day,omitempty"` // monthly; clamped to the month's length
+}
+
+// weekdayNames are the short names used in rules, indexed by time.Weekday
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseWeekday reads a weekday name ("mon", "Monday") or RRULE code ("MO")
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) >= 2 {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.HasPrefix(strings.ToLower(day.String()), s) {
+				return day, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
+
+// ParseRecurrence reads a repeat rule. It accepts "daily", "every N days",
+// "weekly on mon,thu", "monthly on 15", "after N days", or an RRULE subset
+// such as "FREQ=WEEKLY;BYDAY=MO,TH". An empty string means no rule.
+func ParseRecurrence(s string) (*Recurrence, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if strings.Contains(strings.ToUpper(s), "FREQ=") {
+		return parseRRule(s)
+	}
+
+	invalid := fmt.Errorf("invalid repeat rule %q (try daily, every 3 days, weekly on mon,thu, monthly on 15 or after 7 days)", s)
+	words := strings.Fields(strings.ToLower(strings.ReplaceAll(s, ",", " ")))
+	if len(words) == 0 {
+		return nil, invalid
+	}
+	r := &Recurrence{}
+	switch words[0] {
+	case "daily":
+		r.Freq, r.Interval = FreqDaily, 1
+		words = words[1:]
+	case "every":
+		if len(words) == 2 && (words[1] == "day" || words[1] == "days") {
+			r.Freq, r.Interval = FreqDaily, 1
+		} else if len(words) == 3 && (words[2] == "day" || words[2] == "days") {
+			n, err := strconv.Atoi(words[1])
+			if err != nil || n < 1 {
+				return nil, invalid
+			}
+			r.Freq, r.Interval = FreqDaily, n
+		} else {
+			return nil, invalid
+		}
+		words = nil
+	case "weekly":
+		r.Freq = FreqWeekly
+		for _, w := range words[1:] {
+			if w == "on" {
+				continue
+			}
+			day, err := parseWeekday(w)
+			if err != nil {
+				return nil, invalid
+			}
+			r.Weekdays = append(r.Weekdays, day)
+		}
+		words = nil
+	case "monthly":
+		words = words[1:]
+		if len(words) > 0 && words[0] == "on" {
+			words = words[1:]
+		}
+		if len(words) != 1 {
+			return nil, invalid
+		}
+		n, err := strconv.Atoi(strings.TrimRight(words[0], "stndrh"))
+		if err != nil || n < 1 || n > 31 {
+			return nil, invalid
+		}
+		r.Freq, r.MonthDay = FreqMonthly, n
+		words = nil
+	case "after":
+		if len(words) < 2 || len(words) > 3 {
+			return nil, invalid
+		}
+		n, err := strconv.Atoi(words[1])
+		if err != nil || n < 1 {
+			return nil, invalid
+		}
+		r.Freq, r.Interval = FreqAfter, n
+		words = nil
+	default:
+		return nil, invalid
+	}
+	if len(words) > 0 {
+		return nil, invalid
+	}
+	return r, nil
+}
+
+// parseRRule reads the FREQ, INTERVAL, BYDAY and BYMONTHDAY parts of an RRULE
+func parseRRule(s string) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1}
+	s = strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		switch name {
+		case "FREQ":
+			switch value {
+			case "DAILY":
+				r.Freq = FreqDaily
+			case "WEEKLY":
+				r.Freq = FreqWeekly
+			case "MONTHLY":
+				r.Freq = FreqMonthly
+			default:
+				return nil, fmt.Errorf("unsupported RRULE FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid RRULE INTERVAL %q", value)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, err := parseWeekday(code)
+				if err != nil {
+					return nil, err
+				}
+				r.Weekdays = append(r.Weekdays, day)
+			}
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return nil, fmt.Errorf("invalid RRULE BYMONTHDAY %q", value)
+			}
+			r.MonthDay = n
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %q", name)
+		}
+	}
+	switch {
+	case r.Freq == "":
+		return nil, fmt.Errorf("RRULE needs a FREQ")
+	case r.Freq == FreqMonthly && r.MonthDay == 0:
+		return nil, fmt.Errorf("monthly RRULE needs BYMONTHDAY")
+	case r.Freq != FreqDaily && r.Interval != 1:
+		return nil, fmt.Errorf("INTERVAL is only supported for FREQ=DAILY")
+	}
+	if r.Freq != FreqDaily {
+		r.Interval = 0
+	}
+	return r, nil
+}
+
+// String renders the rule in the form ParseRecurrence reads
+func (r Recurrence) String() string {
+	switch r.Freq {
+	case FreqDaily:
+		if r.Interval > 1 {
+			return fmt.Sprintf("every %d days", r.Interval)
+		}
+		return "daily"
+	case FreqWeekly:
+		if len(r.Weekdays) == 0 {
+			return "weekly"
+		}
+		names := make([]string, len(r.Weekdays))
+		for i, day := range r.Weekdays {
+			names[i] = weekdayNames[day]
+		}
+		return "weekly on " + strings.Join(names, ",")
+	case FreqMonthly:
+		return fmt.Sprintf("monthly on %d", r.MonthDay)
+	case FreqAfter:
+		return fmt.Sprintf("after %d days", r.Interval)
+	}
+	return r.Freq
+}
+
+// civilDate returns midnight UTC of t's calendar day in t's location, the
+// form deadlines are stored in
+func civilDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// step returns the next date the rule allows strictly after base
+func (r Recurrence) step(base time.Time) time.Time {
+	switch r.Freq {
+	case FreqWeekly:
+		days := r.Weekdays
+		if len(days) == 0 {
+			days = []time.Weekday{base.Weekday()}
+		}
+		for i := 1; i <= 7; i++ {
+			next := base.AddDate(0, 0, i)
+			for _, day := range days {
+				if next.Weekday() == day {
+					return next
+				}
+			}
+		}
+	case FreqMonthly:
+		for months := 0; ; months++ {
+			first := time.Date(base.Year(), base.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+			day := r.MonthDay
+			if last := first.AddDate(0, 1, -1).Day(); day > last {
+				day = last
+			}
+			if next := first.AddDate(0, 0, day-1); next.After(base) {
+				return next
+			}
+		}
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	return base.AddDate(0, 0, interval)
+}
+
+// Next returns the due date of the occurrence after one that was due on
+// due (nil if it had no deadline) and completed at completed. Scheduled
+// rules skip dates already past, so finishing late does not pile up
+// overdue instances.
+func (r Recurrence) Next(due *time.Time, completed time.Time) time.Time {
+	done := civilDate(completed)
+	if r.Freq == FreqAfter {
+		return r.step(done)
+	}
+	base := done
+	if due != nil {
+		base = civilDate(due.UTC())
+	}
+	next := r.step(base)
+	for !next.After(done) {
+		next = r.step(next)
+	}
+	return next
+}
+
+// shiftDeadline moves a deadline by the same number of days as its parent
+// quest's deadline moved
+func shiftDeadline(d *time.Time, days int) *time.Time {
+	if d == nil {
+		return nil
+	}
+	shifted := d.AddDate(0, 0, days)
+	return &shifted
+}
+
+// nextTask returns a fresh, undone copy of a task due on next
+func nextTask(t Task, next time.Time) Task {
+	t.ID = generateID()
+	t.Done = false
+	t.Deadline = &next
+	t.TimeEntries = nil
+	return t
+}
+
+// resetQuest returns a fresh, active copy of a quest tree with new IDs,
+// recording each old ID against its new one in ids. Task and sub-quest
+// deadlines move by shiftDays.
+func resetQuest(q Quest, shiftDays int, now time.Time, ids map[string]string) Quest {
+	ids[q.ID] = generateID()
+	q.ID = ids[q.ID]
+	q.State = StateActive
+	q.Progress = 0
+	q.CreatedAt = &now
+	q.CompletedAt, q.CancelledAt, q.ArchivedAt, q.History = nil, nil, nil, nil
+	q.Deadline = shiftDeadline(q.Deadline, shiftDays)
+	tasks := make([]Task, len(q.Tasks))
+	for i, t := range q.Tasks {
+		ids[t.ID] = generateID()
+		t.ID = ids[t.ID]
+		t.Done = false
+		t.TimeEntries = nil
+		t.Deadline = shiftDeadline(t.Deadline, shiftDays)
+		tasks[i] = t
+	}
+	q.Tasks = tasks
+	subQuests := make([]Quest, len(q.SubQuests))
+	for i, sub := range q.SubQuests {
+		subQuests[i] = resetQuest(sub, shiftDays, now, ids)
+	}
+	if q.SubQuests == nil {
+		subQuests = nil
+	}
+	q.SubQuests = subQuests
+	return q
+}
+
+// nextQuest returns the next occurrence of a completed recurring quest:
+// a reset copy due on the next date, carrying the rule forward
+func nextQuest(q Quest, now time.Time) Quest {
+	next := q.Recur.Next(q.Deadline, now)
+	shiftDays := 0
+	if q.Deadline != nil {
+		shiftDays = int(next.Sub(civilDate(q.Deadline.UTC())).Hours()+12) / 24
+	}
+	ids := make(map[string]string)
+	spawned := resetQuest(q, shiftDays, now, ids)
+	spawned.Deadline = &next
+	remapBlockers(&spawned, ids)
+	return spawned
+}
+
+// remapBlockers points blockers within a copied quest tree at the copies,
+// so the order of its tasks and sub-quests carries over
+func remapBlockers(q *Quest, ids map[string]string) {
+	q.BlockedBy = remapIDs(q.BlockedBy, ids)
+	for i := range q.Tasks {
+		q.Tasks[i].BlockedBy = remapIDs(q.Tasks[i].BlockedBy, ids)
+	}
+	for i := range q.SubQuests {
+		remapBlockers(&q.SubQuests[i], ids)
+	}
+}
+
+// remapIDs returns a copy of ids with each mapped ID replaced
+func remapIDs(list []string, ids map[string]string) []string {
+	if list == nil {
+		return nil
+	}
+	mapped := make([]string, len(list))
+	for i, id := range list {
+		if newID, ok := ids[id]; ok {
+			id = newID
+		}
+		mapped[i] = id
+	}
+	return mapped
+}
+
+// spawnNextQuest appends the next occurrence of a just-completed recurring
+// quest beside it. The completed instance drops its rule so that reopening
+// and completing it again does not spawn a second copy.
+func spawnNextQuest(loc questLocation, now time.Time) {
+	q := loc.quest()
+	if q.Recur == nil || q.State != StateCompleted {
+		return
+	}
+	spawned := nextQuest(*q, now)
+	q.Recur = nil
+	*loc.siblings = append(*loc.siblings, spawned)
+}
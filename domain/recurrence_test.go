@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *Recurrence
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "daily", want: &Recurrence{Freq: FreqDaily, Interval: 1}},
+		{in: "every day", want: &Recurrence{Freq: FreqDaily, Interval: 1}},
+		{in: "every 3 days", want: &Recurrence{Freq: FreqDaily, Interval: 3}},
+		{in: "weekly", want: &Recurrence{Freq: FreqWeekly}},
+		{in: "weekly on mon,thu", want: &Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}}},
+		{in: "Weekly on Monday, Friday", want: &Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Friday}}},
+		{in: "monthly on 15th", want: &Recurrence{Freq: FreqMonthly, MonthDay: 15}},
+		{in: "after 7 days", want: &Recurrence{Freq: FreqAfter, Interval: 7}},
+		{in: "FREQ=DAILY;INTERVAL=2", want: &Recurrence{Freq: FreqDaily, Interval: 2}},
+		{in: "RRULE:FREQ=WEEKLY;BYDAY=MO,TH", want: &Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}}},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=31", want: &Recurrence{Freq: FreqMonthly, MonthDay: 31}},
+		// Commas alone leave no words, which must fail rather than panic
+		{in: ",", wantErr: true},
+		{in: " , ,", wantErr: true},
+		{in: "every 0 days", wantErr: true},
+		{in: "every 3 weeks", wantErr: true},
+		{in: "weekly on someday", wantErr: true},
+		{in: "monthly on 32", wantErr: true},
+		{in: "monthly", wantErr: true},
+		{in: "after days", wantErr: true},
+		{in: "daily please", wantErr: true},
+		{in: "fortnightly", wantErr: true},
+		{in: "FREQ=YEARLY", wantErr: true},
+		{in: "FREQ=MONTHLY", wantErr: true},
+		{in: "FREQ=WEEKLY;INTERVAL=2", wantErr: true},
+		{in: "INTERVAL=2", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRecurrence(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRecurrence(%q) = %+v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRecurrence(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceStringRoundTrip(t *testing.T) {
+	for _, in := range []string{"daily", "every 3 days", "weekly", "weekly on mon,thu", "monthly on 15", "after 7 days"} {
+		r, err := ParseRecurrence(in)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) failed: %v", in, err)
+		}
+		back, err := ParseRecurrence(r.String())
+		if err != nil || !reflect.DeepEqual(back, r) {
+			t.Errorf("%q renders as %q, which reads back as %+v (%v)", in, r.String(), back, err)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	due := func(s string) *time.Time {
+		d := date(s)
+		return &d
+	}
+	// Completion times are noon local time, so the day is the same everywhere
+	at := func(s string) time.Time {
+		d := date(s)
+		return time.Date(d.Year(), d.Month(), d.Day(), 12, 0, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name      string
+		rule      Recurrence
+		due       *time.Time
+		completed time.Time
+		want      string
+	}{
+		{"daily on time", Recurrence{Freq: FreqDaily, Interval: 1}, due("2026-03-10"), at("2026-03-10"), "2026-03-11"},
+		{"daily early keeps the schedule", Recurrence{Freq: FreqDaily, Interval: 1}, due("2026-03-10"), at("2026-03-08"), "2026-03-11"},
+		{"daily late skips past dates", Recurrence{Freq: FreqDaily, Interval: 1}, due("2026-03-10"), at("2026-03-14"), "2026-03-15"},
+		{"every 3 days", Recurrence{Freq: FreqDaily, Interval: 3}, due("2026-03-10"), at("2026-03-10"), "2026-03-13"},
+		{"every 3 days late stays on the cycle", Recurrence{Freq: FreqDaily, Interval: 3}, due("2026-03-10"), at("2026-03-14"), "2026-03-16"},
+		{"no deadline counts from completion", Recurrence{Freq: FreqDaily, Interval: 2}, nil, at("2026-03-10"), "2026-03-12"},
+		{"weekly on the due weekday", Recurrence{Freq: FreqWeekly}, due("2026-03-10"), at("2026-03-10"), "2026-03-17"},
+		{"weekly on mon,thu", Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}}, due("2026-03-09"), at("2026-03-09"), "2026-03-12"},
+		{"weekly wraps to next week", Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}}, due("2026-03-12"), at("2026-03-12"), "2026-03-16"},
+		{"monthly", Recurrence{Freq: FreqMonthly, MonthDay: 15}, due("2026-03-15"), at("2026-03-15"), "2026-04-15"},
+		{"monthly clamps to short months", Recurrence{Freq: FreqMonthly, MonthDay: 31}, due("2026-01-31"), at("2026-01-31"), "2026-02-28"},
+		{"monthly clamps in leap years", Recurrence{Freq: FreqMonthly, MonthDay: 31}, due("2028-01-31"), at("2028-01-31"), "2028-02-29"},
+		{"monthly returns to the day after clamping", Recurrence{Freq: FreqMonthly, MonthDay: 31}, due("2026-02-28"), at("2026-02-28"), "2026-03-31"},
+		{"after counts from completion", Recurrence{Freq: FreqAfter, Interval: 7}, due("2026-03-01"), at("2026-03-10"), "2026-03-17"},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.Next(tt.due, tt.completed); !got.Equal(date(tt.want)) {
+			t.Errorf("%s: Next = %s, want %s", tt.name, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
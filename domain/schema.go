@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CurrentSchemaVersion is the data format version written by this build
+const CurrentSchemaVersion = 4
+
+// Migration upgrades a decoded data document by one version in place.
+// The document has the envelope shape {"version": N, "projects": [...]},
+// with "xp_log" alongside from version 4.
+type Migration func(doc map[string]interface{}) error
+
+// migrations maps each version to the function upgrading it to the next one.
+// A release that alters the stored shape of Project, Quest or Task bumps
+// CurrentSchemaVersion once and registers a migration here.
+var migrations = map[int]Migration{
+	1: migrateV1ToV2,
+	2: migrateV2ToV3,
+	3: migrateV3ToV4,
+}
+
+// UnsupportedVersionError is returned for data written by a newer build
+type UnsupportedVersionError struct {
+	Version int
+}
+
+// Error implements error interface
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("data file version %d is newer than supported version %d; upgrade quest_line to open it",
+		e.Version, CurrentSchemaVersion)
+}
+
+// document is the versioned envelope stored on disk
+type document struct {
+	Version  int       `json:"version"`
+	Projects []Project `json:"projects"`
+	XPLog    []XPEvent `json:"xp_log,omitempty"`
+}
+
+// encodedDocument is a document whose projects and XP log are already encoded
+type encodedDocument struct {
+	Version  int               `json:"version"`
+	Projects []json.RawMessage `json:"projects"`
+	XPLog    json.RawMessage   `json:"xp_log,omitempty"`
+}
+
+// encodeDocument wraps the data in the current versioned envelope
+func encodeDocument(d EncodedData) ([]byte, error) {
+	doc := encodedDocument{Version: CurrentSchemaVersion, Projects: []json.RawMessage{}, XPLog: d.xpLog}
+	for _, p := range d.projects {
+		doc.Projects = append(doc.Projects, p.raw)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// decodeDocument parses a data file of any supported version, migrating it to
+// the current one. Version 1 files are a bare JSON array of projects.
+func decodeDocument(data []byte) (Data, error) {
+	var doc map[string]interface{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var projects []interface{}
+		if err := json.Unmarshal(trimmed, &projects); err != nil {
+			return Data{}, err
+		}
+		doc = map[string]interface{}{"version": float64(1), "projects": projects}
+	} else if err := json.Unmarshal(data, &doc); err != nil {
+		return Data{}, err
+	}
+	return migrateDocument(doc)
+}
+
+// migrateDocument runs the migration chain from the document's version up to
+// CurrentSchemaVersion and decodes the result
+func migrateDocument(doc map[string]interface{}) (Data, error) {
+	v, ok := doc["version"].(float64)
+	if !ok || v < 1 || v != float64(int(v)) {
+		return Data{}, fmt.Errorf("data file has no valid version marker")
+	}
+	version := int(v)
+	if version > CurrentSchemaVersion {
+		return Data{}, &UnsupportedVersionError{Version: version}
+	}
+	for ; version < CurrentSchemaVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return Data{}, fmt.Errorf("no migration registered from data version %d", version)
+		}
+		if err := migrate(doc); err != nil {
+			return Data{}, fmt.Errorf("migrating data from version %d: %w", version, err)
+		}
+		doc["version"] = float64(version + 1)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return Data{}, err
+	}
+	var current document
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return Data{}, err
+	}
+	if current.Projects == nil {
+		current.Projects = []Project{}
+	}
+	return Data{Projects: current.Projects, XPLog: current.XPLog}, nil
+}
+
+// v1FieldNames maps the Go-cased keys of version 1 files to version 2 keys
+var v1FieldNames = map[string]string{
+	"ID":          "id",
+	"Name":        "name",
+	"Quests":      "quests",
+	"Progress":    "progress",
+	"Title":       "title",
+	"Description": "description",
+	"Tasks":       "tasks",
+	"SubQuests":   "sub_quests",
+	"Priority":    "priority",
+	"Deadline":    "deadline",
+	"State":       "state",
+	"Done":        "done",
+}
+
+// migrateV1ToV2 renames Go-cased field names to snake_case
+func migrateV1ToV2(doc map[string]interface{}) error {
+	renameKeys(doc["projects"], v1FieldNames)
+	return nil
+}
+
+// migrateV2ToV3 covers the optional fields added in version 3: lifecycle
+// states and timestamps, project settings, tags, task details, repeat rules,
+// blockers, time entries, estimates, board columns, event logs and
+// per-project XP logs. Older documents decode as-is; the version bump stops
+// older builds from loading newer files and silently dropping those fields.
+func migrateV2ToV3(doc map[string]interface{}) error {
+	return nil
+}
+
+// migrateV3ToV4 moves the XP logs out of the projects into one log for
+// the whole document, so deleting a project keeps the XP earned in it.
+// Completions remember their project; achievements belong to none.
+func migrateV3ToV4(doc map[string]interface{}) error {
+	log := []interface{}{}
+	projects, _ := doc["projects"].([]interface{})
+	for _, node := range projects {
+		p, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entries, _ := p["xp_log"].([]interface{})
+		for _, node := range entries {
+			if e, ok := node.(map[string]interface{}); ok && e["kind"] != KindAchievement {
+				e["project_id"] = p["id"]
+			}
+			log = append(log, node)
+		}
+		delete(p, "xp_log")
+	}
+	sort.SliceStable(log, func(i, j int) bool { return loggedAt(log[i]).Before(loggedAt(log[j])) })
+	doc["xp_log"] = log
+	return nil
+}
+
+// loggedAt reads the time of a decoded XP log entry, or the zero time
+func loggedAt(node interface{}) time.Time {
+	e, _ := node.(map[string]interface{})
+	s, _ := e["at"].(string)
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// renameKeys walks decoded JSON and renames object keys found in names
+func renameKeys(node interface{}, names map[string]string) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for oldKey, newKey := range names {
+			if value, ok := n[oldKey]; ok {
+				delete(n, oldKey)
+				n[newKey] = value
+			}
+		}
+		for _, value := range n {
+			renameKeys(value, names)
+		}
+	case []interface{}:
+		for _, value := range n {
+			renameKeys(value, names)
+		}
+	}
+}
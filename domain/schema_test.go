@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDecodeDocument(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		check func(t *testing.T, d Data)
+	}{
+		{
+			name: "v1 bare array with Go-cased keys",
+			in: `[{"ID": "p1", "Name": "Garden", "Progress": 0, "Quests": [
+				{"ID": "q1", "Title": "Weed", "Description": "beds", "Priority": 3, "State": 1,
+				 "Deadline": "2026-05-01T00:00:00Z",
+				 "Tasks": [{"ID": "t1", "Description": "pull", "Done": true}],
+				 "SubQuests": [{"ID": "q2", "Title": "Edges", "Tasks": []}]}]}]`,
+			check: func(t *testing.T, d Data) {
+				p := d.Projects[0]
+				if p.ID != "p1" || p.Name != "Garden" {
+					t.Fatalf("project = %q %q, want p1 Garden", p.ID, p.Name)
+				}
+				q := p.Quests[0]
+				if q.ID != "q1" || q.Title != "Weed" || q.Description != "beds" || q.Priority != 3 || q.State != StateCompleted {
+					t.Errorf("quest = %+v, want the renamed fields kept", q)
+				}
+				if q.Deadline == nil || q.Deadline.Format("2006-01-02") != "2026-05-01" {
+					t.Errorf("deadline = %v, want 2026-05-01", q.Deadline)
+				}
+				if len(q.Tasks) != 1 || q.Tasks[0].Description != "pull" || !q.Tasks[0].Done {
+					t.Errorf("tasks = %+v, want pull done", q.Tasks)
+				}
+				if len(q.SubQuests) != 1 || q.SubQuests[0].Title != "Edges" {
+					t.Errorf("sub-quests = %+v, want Edges", q.SubQuests)
+				}
+			},
+		},
+		{
+			name: "v1 envelope",
+			in:   `{"version": 1, "projects": [{"ID": "p1", "Name": "Home", "Quests": []}]}`,
+			check: func(t *testing.T, d Data) {
+				if len(d.Projects) != 1 || d.Projects[0].Name != "Home" {
+					t.Errorf("projects = %+v, want Home", d.Projects)
+				}
+			},
+		},
+		{
+			name: "v3 XP logs move out of the projects",
+			in: `{"version": 3, "projects": [
+				{"id": "p1", "name": "A", "quests": [], "xp_log": [
+					{"at": "2026-01-03T10:00:00Z", "kind": "task", "item_id": "t2", "title": "later"},
+					{"at": "2026-01-01T10:00:00Z", "kind": "achievement", "item_id": "first-task", "title": "First Steps"}]},
+				{"id": "p2", "name": "B", "quests": [], "xp_log": [
+					{"at": "2026-01-01T09:00:00Z", "kind": "task", "item_id": "t1", "title": "first"}]}]}`,
+			check: func(t *testing.T, d Data) {
+				var got []string
+				for _, e := range d.XPLog {
+					got = append(got, e.ItemID+"@"+e.ProjectID)
+				}
+				want := []string{"t1@p2", "first-task@", "t2@p1"}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("XP log = %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name: "current version",
+			in: fmt.Sprintf(`{"version": %d, "projects": [{"id": "p1", "name": "A", "quests": []}],
+				"xp_log": [{"at": "2026-01-01T09:00:00Z", "kind": "task", "item_id": "t1", "title": "x", "project_id": "p1"}]}`,
+				CurrentSchemaVersion),
+			check: func(t *testing.T, d Data) {
+				if len(d.Projects) != 1 || len(d.XPLog) != 1 || d.XPLog[0].ProjectID != "p1" {
+					t.Errorf("data = %+v, want it unchanged", d)
+				}
+			},
+		},
+		{
+			name: "no projects",
+			in:   `{"version": 2}`,
+			check: func(t *testing.T, d Data) {
+				if d.Projects == nil || len(d.Projects) != 0 {
+					t.Errorf("projects = %#v, want an empty list", d.Projects)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := decodeDocument([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("decodeDocument failed: %v", err)
+			}
+			tt.check(t, d)
+		})
+	}
+}
+
+func TestDecodeDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		wantNewer bool
+	}{
+		{name: "newer version", in: fmt.Sprintf(`{"version": %d, "projects": []}`, CurrentSchemaVersion+1), wantNewer: true},
+		{name: "no version", in: `{"projects": []}`},
+		{name: "zero version", in: `{"version": 0, "projects": []}`},
+		{name: "fractional version", in: `{"version": 2.5, "projects": []}`},
+		{name: "not JSON", in: `projects:`},
+	}
+	for _, tt := range tests {
+		_, err := decodeDocument([]byte(tt.in))
+		if err == nil {
+			t.Errorf("%s: decodeDocument succeeded, want an error", tt.name)
+			continue
+		}
+		var newer *UnsupportedVersionError
+		if got := errors.As(err, &newer); got != tt.wantNewer {
+			t.Errorf("%s: error %v, UnsupportedVersionError = %v, want %v", tt.name, err, got, tt.wantNewer)
+		}
+	}
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	for v := 1; v < CurrentSchemaVersion; v++ {
+		if migrations[v] == nil {
+			t.Errorf("no migration from version %d", v)
+		}
+	}
+}
+
+func TestEncodeDocumentRoundTrip(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := Data{
+		Projects: []Project{{ID: "p1", Name: "A", Quests: []Quest{{ID: "q1", Title: "Q", Tasks: []Task{{ID: "t1", Description: "T"}}}}}},
+		XPLog:    []XPEvent{{At: at, Kind: KindTask, ItemID: "t1", Title: "T", ProjectID: "p1"}},
+	}
+	encoded, err := EncodeData(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := encodeDocument(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := decodeDocument(raw)
+	if err != nil {
+		t.Fatalf("decoding %s failed: %v", raw, err)
+	}
+	if len(out.Projects) != 1 || out.Projects[0].Quests[0].Tasks[0].ID != "t1" {
+		t.Errorf("projects = %+v, want them unchanged", out.Projects)
+	}
+	if len(out.XPLog) != 1 || !out.XPLog[0].At.Equal(at) || out.XPLog[0].ProjectID != "p1" {
+		t.Errorf("XP log = %+v, want it unchanged", out.XPLog)
+	}
+}
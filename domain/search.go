@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"github.com/sahilm/fuzzy"
+)
+
+// Fields a search result can match on
+const (
+	FieldName        = "name"
+	FieldTitle       = "title"
+	FieldDescription = "description"
+)
+
+// SearchResult is one fuzzy match. QuestID is empty for a project match and
+// TaskID is set only for a task match.
+type SearchResult struct {
+	Kind           string `json:"kind"` // KindProject, KindQuest or KindTask
+	Field          string `json:"field"`
+	Text           string `json:"text"`
+	Path           string `json:"path"` // project › quest › sub-quest
+	ProjectID      string `json:"project_id"`
+	QuestID        string `json:"quest_id,omitempty"`
+	TaskID         string `json:"task_id,omitempty"`
+	MatchedIndexes []int  `json:"matched_indexes"` // byte offsets into Text
+	Score          int    `json:"score"`
+}
+
+// searchSource adapts the candidates to fuzzy.Source
+type searchSource []SearchResult
+
+func (s searchSource) String(i int) string { return s[i].Text }
+func (s searchSource) Len() int            { return len(s) }
+
+// collectCandidates lists every searchable string in a quest tree
+func collectCandidates(candidates *searchSource, projectID, path string, quests []Quest) {
+	for _, q := range quests {
+		questPath := path + " › " + q.Title
+		base := SearchResult{Kind: KindQuest, Path: questPath, ProjectID: projectID, QuestID: q.ID}
+		title := base
+		title.Field, title.Text = FieldTitle, q.Title
+		*candidates = append(*candidates, title)
+		if q.Description != "" {
+			desc := base
+			desc.Field, desc.Text = FieldDescription, q.Description
+			*candidates = append(*candidates, desc)
+		}
+		for _, t := range q.Tasks {
+			*candidates = append(*candidates, SearchResult{
+				Kind: KindTask, Field: FieldDescription, Text: t.Description, Path: questPath,
+				ProjectID: projectID, QuestID: q.ID, TaskID: t.ID,
+			})
+		}
+		collectCandidates(candidates, projectID, questPath, q.SubQuests)
+	}
+}
+
+// Search fuzzy-matches project names, quest titles and descriptions and task
+// text across every project, best match first. Words such as +backend or
+// -blocked in the query filter quests and tasks by tag; with only tag terms
+// every quest and task that matches them is returned.
+func Search(projects []Project, query string) []SearchResult {
+	filter, query := splitTagTerms(query)
+	if query == "" && filter.IsEmpty() {
+		return nil
+	}
+	var candidates searchSource
+	for _, p := range projects {
+		candidates = append(candidates, SearchResult{
+			Kind: KindProject, Field: FieldName, Text: p.Name, Path: p.Name, ProjectID: p.ID,
+		})
+		collectCandidates(&candidates, p.ID, p.Name, p.Quests)
+	}
+
+	if !filter.IsEmpty() {
+		index := EffectiveTags(projects)
+		kept := candidates[:0]
+		for _, c := range candidates {
+			id := c.TaskID
+			if id == "" {
+				id = c.QuestID
+			}
+			if c.Kind == KindProject || !filter.Matches(index[id]) {
+				continue
+			}
+			if query == "" && c.Field == FieldDescription && c.Kind == KindQuest {
+				continue // list each quest once, by title
+			}
+			kept = append(kept, c)
+		}
+		candidates = kept
+		if query == "" {
+			return candidates
+		}
+	}
+
+	matches := fuzzy.FindFrom(query, candidates)
+	results := make([]SearchResult, 0, len(matches))
+	for _, match := range matches {
+		r := candidates[match.Index]
+		r.MatchedIndexes = match.MatchedIndexes
+		r.Score = match.Score
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DayCount is a number for one day, or for the week starting on Day
+type DayCount struct {
+	Day   time.Time `json:"day"` // midnight UTC, like deadlines
+	Count int       `json:"count"`
+}
+
+// ProjectReport holds the numbers of one project
+type ProjectReport struct {
+	ProjectID     string     `json:"project_id"`
+	Project       string     `json:"project"`
+	OpenTasks     int        `json:"open_tasks"`
+	OverdueTasks  int        `json:"overdue_tasks"`
+	OverdueQuests int        `json:"overdue_quests"`
+	Burndown      []DayCount `json:"burndown"` // open tasks at the end of each day
+}
+
+// Report sums up the event logs of one or every project
+type Report struct {
+	From             time.Time       `json:"from"`
+	To               time.Time       `json:"to"`
+	CompletedPerDay  []DayCount      `json:"completed_per_day"`
+	CompletedPerWeek []DayCount      `json:"completed_per_week"` // weeks start on Monday
+	QuestsMeasured   int             `json:"quests_measured"`    // completed quests with known creation times
+	AvgQuestTime     time.Duration   `json:"-"`
+	AvgQuestSeconds  int64           `json:"avg_quest_seconds"`
+	OverdueTasks     int             `json:"overdue_tasks"`
+	OverdueQuests    int             `json:"overdue_quests"`
+	Projects         []ProjectReport `json:"projects"`
+}
+
+// overdueCounts counts the open overdue quests and tasks in a quest tree
+func overdueCounts(quests []Quest, now time.Time) (questCount, taskCount int) {
+	for _, q := range quests {
+		if !q.State.IsOpen() {
+			continue
+		}
+		if IsOverdue(q.Deadline, now) {
+			questCount++
+		}
+		for _, t := range q.Tasks {
+			if !t.Done && IsOverdue(t.Deadline, now) {
+				taskCount++
+			}
+		}
+		subQuests, subTasks := overdueCounts(q.SubQuests, now)
+		questCount += subQuests
+		taskCount += subTasks
+	}
+	return questCount, taskCount
+}
+
+// countOpenTasks counts the tasks not yet done in a quest tree
+func countOpenTasks(quests []Quest) int {
+	n := 0
+	for _, q := range quests {
+		for _, t := range q.Tasks {
+			if !t.Done {
+				n++
+			}
+		}
+		n += countOpenTasks(q.SubQuests)
+	}
+	return n
+}
+
+// questDurations adds up the time from creation to completion of the
+// completed quests in a tree, archived ones included
+func questDurations(quests []Quest, total *time.Duration, count *int) {
+	for _, q := range quests {
+		if q.CreatedAt != nil && q.CompletedAt != nil && (q.State == StateCompleted || q.State == StateArchived) {
+			*total += q.CompletedAt.Sub(*q.CreatedAt)
+			*count++
+		}
+		questDurations(q.SubQuests, total, count)
+	}
+}
+
+// completions returns the days on which tasks were completed, one entry per
+// completion; completions later undone are left as zero days
+func completions(events []Event) []time.Time {
+	var days []time.Time
+	last := make(map[string]int) // task ID to its latest completion in days
+	for _, e := range events {
+		switch e.Kind {
+		case EventTaskDone:
+			last[e.ItemID] = len(days)
+			days = append(days, civilDate(e.At.Local()))
+		case EventTaskReopened:
+			if i, ok := last[e.ItemID]; ok {
+				days[i] = time.Time{}
+				delete(last, e.ItemID)
+			}
+		}
+	}
+	return days
+}
+
+// openDelta returns how an event changed the number of open tasks
+func openDelta(e Event) int {
+	switch e.Kind {
+	case EventTaskAdded, EventTaskReopened:
+		if !e.Done {
+			return 1
+		}
+	case EventTaskRemoved, EventTaskDone:
+		if !e.Done {
+			return -1
+		}
+	}
+	return 0
+}
+
+// burndown returns the open tasks of a project at the end of each day from
+// from to to, working back from the current count through its event log.
+// Days before the log began show the count as of its first event.
+func burndown(p Project, from, to, now time.Time) []DayCount {
+	open := countOpenTasks(p.Quests)
+	events := p.Events
+	var points []DayCount
+	// Walk the days newest first, undoing the events after each one
+	i := len(events) - 1
+	for day := civilDate(now.Local()); !day.Before(from); day = day.AddDate(0, 0, -1) {
+		for ; i >= 0 && civilDate(events[i].At.Local()).After(day); i-- {
+			open -= openDelta(events[i])
+		}
+		if !day.After(to) {
+			points = append(points, DayCount{Day: day, Count: open})
+		}
+	}
+	for l, r := 0, len(points)-1; l < r; l, r = l+1, r-1 {
+		points[l], points[r] = points[r], points[l]
+	}
+	return points
+}
+
+// BuildReport gathers the statistics of a project, or of every project when
+// projectID is empty. Daily counts and burndowns cover from to to (days, in
+// the form Day returns); weekly counts cover the weeks those days fall in.
+// The average quest time and overdue counts are for all time and now.
+func BuildReport(projects []Project, projectID string, from, to, now time.Time) Report {
+	r := Report{From: from, To: to}
+	perDay := make(map[time.Time]int)
+	var total time.Duration
+	for _, p := range projects {
+		if projectID != "" && p.ID != projectID {
+			continue
+		}
+		for _, day := range completions(p.Events) {
+			if !day.IsZero() {
+				perDay[day]++
+			}
+		}
+		questDurations(p.Quests, &total, &r.QuestsMeasured)
+		pr := ProjectReport{ProjectID: p.ID, Project: p.Name, OpenTasks: countOpenTasks(p.Quests), Burndown: burndown(p, from, to, now)}
+		pr.OverdueQuests, pr.OverdueTasks = overdueCounts(p.Quests, now)
+		r.OverdueQuests += pr.OverdueQuests
+		r.OverdueTasks += pr.OverdueTasks
+		r.Projects = append(r.Projects, pr)
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		r.CompletedPerDay = append(r.CompletedPerDay, DayCount{Day: day, Count: perDay[day]})
+	}
+	for week := WeekStart(from); !week.After(to); week = week.AddDate(0, 0, 7) {
+		count := 0
+		for day := week; day.Before(week.AddDate(0, 0, 7)); day = day.AddDate(0, 0, 1) {
+			count += perDay[day]
+		}
+		r.CompletedPerWeek = append(r.CompletedPerWeek, DayCount{Day: week, Count: count})
+	}
+	if r.QuestsMeasured > 0 {
+		r.AvgQuestTime = total / time.Duration(r.QuestsMeasured)
+		r.AvgQuestSeconds = int64(r.AvgQuestTime / time.Second)
+	}
+	if r.Projects == nil {
+		r.Projects = []ProjectReport{}
+	}
+	return r
+}
+
+// WeekStart returns the Monday on or before day
+func WeekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// FormatSpan renders a long duration as e.g. "3d 4h", or like
+// FormatDuration when it is under a day
+func FormatSpan(d time.Duration) string {
+	if d < 24*time.Hour {
+		return FormatDuration(d)
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d%(24*time.Hour)) / int(time.Hour)
+	if hours == 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+	return fmt.Sprintf("%dd %dh", days, hours)
+}
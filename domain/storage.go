@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Default data files used when no path is configured
+const (
+	DefaultDataFile = "quests.json"
+	DefaultBoltFile = "quests.db"
+)
+
+// Storage backend names accepted by OpenStorage
+const (
+	BackendJSON = "json"
+	BackendBolt = "bolt"
+)
+
+// Data is everything a Storage keeps: the projects and the XP log
+type Data struct {
+	Projects []Project
+	XPLog    []XPEvent
+}
+
+// EncodedData is Data encoded for saving. Encoding happens up front, so the
+// write can run on another goroutine while the projects keep changing.
+type EncodedData struct {
+	projects []encodedProject // in display order
+	xpLog    []byte           // nil when the log is empty
+}
+
+// encodedProject is one project's JSON encoding
+type encodedProject struct {
+	id  string
+	raw []byte
+}
+
+// EncodeData encodes the projects and the XP log for Storage.Save
+func EncodeData(d Data) (EncodedData, error) {
+	var e EncodedData
+	for i := range d.Projects {
+		raw, err := json.Marshal(&d.Projects[i])
+		if err != nil {
+			return EncodedData{}, err
+		}
+		e.projects = append(e.projects, encodedProject{id: d.Projects[i].ID, raw: raw})
+	}
+	if len(d.XPLog) > 0 {
+		raw, err := json.Marshal(d.XPLog)
+		if err != nil {
+			return EncodedData{}, err
+		}
+		e.xpLog = raw
+	}
+	return e, nil
+}
+
+// Storage loads and saves projects. Implementations may write incrementally,
+// persisting only the projects that changed since the last Load or Save.
+type Storage interface {
+	Load() (Data, error)
+	Save(d EncodedData) error
+	Close() error
+}
+
+// StorageConfig selects a storage backend and its location
+type StorageConfig struct {
+	Backend string // BackendJSON (default) or BackendBolt
+	Path    string
+	Backups int // previous versions to keep; 0 uses DefaultBackupCount, negative disables
+}
+
+// OpenStorage creates the storage backend described by cfg
+func OpenStorage(cfg StorageConfig) (Storage, error) {
+	switch cfg.Backend {
+	case "", BackendJSON:
+		s := NewJSONStorage(cfg.Path)
+		if cfg.Path == "" {
+			s = NewJSONStorage(DefaultDataFile)
+		}
+		if cfg.Backups != 0 {
+			s.keepBackups = cfg.Backups
+		}
+		return s, nil
+	case BackendBolt:
+		if cfg.Path == "" {
+			return OpenBoltStorage(DefaultBoltFile)
+		}
+		return OpenBoltStorage(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (use %q or %q)", cfg.Backend, BackendJSON, BackendBolt)
+	}
+}
+
+// backupInterval is the least time between backups taken by saves. The TUI
+// saves after every change, so a backup per save would only keep the last
+// few keystrokes.
+const backupInterval = 10 * time.Minute
+
+// JSONStorage keeps all projects in a single JSON file. Saves are atomic
+// and previous versions are kept as timestamped backups: one from the first
+// save of a session, then at most one per backupInterval.
+type JSONStorage struct {
+	mu          sync.Mutex
+	path        string
+	keepBackups int
+	lastBackup  time.Time // zero until this session's first save
+}
+
+// NewJSONStorage creates a JSON file storage at path
+func NewJSONStorage(path string) *JSONStorage {
+	return &JSONStorage{path: path, keepBackups: DefaultBackupCount}
+}
+
+// Save atomically writes the data, backing up the current file first when
+// this is the session's first save or the last backup is old enough
+func (s *JSONStorage) Save(d EncodedData) error {
+	data, err := encodeDocument(d)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); s.lastBackup.IsZero() || now.Sub(s.lastBackup) >= backupInterval {
+		if err := backupCurrent(s.path, s.keepBackups); err != nil {
+			return err
+		}
+		s.lastBackup = now
+	}
+	return writeFileAtomic(s.path, data, 0644)
+}
+
+// Load loads the data from the JSON file, migrating older versions
+func (s *JSONStorage) Load() (Data, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Data{Projects: []Project{}}, nil
+		}
+		return Data{}, err
+	}
+	return decodeDocument(data)
+}
+
+// Close implements Storage; the JSON file holds no open handles
+func (s *JSONStorage) Close() error {
+	return nil
+}
+
+// Backups lists the saved versions of the data file, newest first
+func (s *JSONStorage) Backups() ([]Backup, error) {
+	return listBackups(s.path)
+}
+
+// Restore rolls the data file back to the named backup
+func (s *JSONStorage) Restore(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return restoreBackup(s.path, name, s.keepBackups)
+}
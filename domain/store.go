@@ -0,0 +1,507 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Entity kinds used in NotFoundError
+const (
+	KindProject = "project"
+	KindQuest   = "quest"
+	KindTask    = "task"
+)
+
+// ErrNotFound is matched by every NotFoundError via errors.Is
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidMove is returned when a quest would be moved into its own sub-tree
+var ErrInvalidMove = errors.New("cannot move a quest into itself or its sub-quests")
+
+// NotFoundError reports a project, quest or task ID that does not exist
+type NotFoundError struct {
+	Kind string // KindProject, KindQuest or KindTask
+	ID   string
+}
+
+// Error implements error interface
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found", e.Kind, e.ID)
+}
+
+// Is makes errors.Is(err, ErrNotFound) true for any NotFoundError
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ProjectInput holds the settings of a new project
+type ProjectInput struct {
+	Name         string
+	AutoComplete bool
+	ProgressMode string // ProgressByCount or ProgressByEffort; empty counts
+}
+
+// QuestInput holds the editable fields of a quest
+type QuestInput struct {
+	Title       string
+	Description string
+	Priority    int
+	Deadline    *time.Time
+	Tags        []string
+	Recur       *Recurrence
+	Estimate    int // minutes
+}
+
+// TaskInput holds the editable fields of a task
+type TaskInput struct {
+	Description string
+	Tags        []string
+	Priority    int
+	Deadline    *time.Time
+	Estimate    int // minutes
+	Notes       string
+	Recur       *Recurrence
+}
+
+// Store holds all projects and addresses projects, quests and tasks by ID.
+// Pointers returned by lookups stay valid only until the next mutation.
+type Store struct {
+	projects []Project
+	history  *History
+	unlocked []Achievement // unlocked since the last TakeUnlocked
+	actor    string
+	activity []Activity // recorded since the last TakeActivity
+
+	xp        []XPEvent // the character's XP log, oldest first
+	xpAdded   []XPEvent // logged by the running change
+	xpRemoved []XPEvent // revoked by the running change
+}
+
+// NewStore creates a store over the given projects and recalculates progress
+func NewStore(projects []Project) *Store {
+	s := &Store{projects: projects, history: NewHistory()}
+	for i := range s.projects {
+		s.projects[i].CalculateProgress()
+	}
+	return s
+}
+
+// Projects returns all projects in display order
+func (s *Store) Projects() []Project {
+	return s.projects
+}
+
+// Project looks up a project by ID
+func (s *Store) Project(id string) (*Project, error) {
+	for i := range s.projects {
+		if s.projects[i].ID == id {
+			return &s.projects[i], nil
+		}
+	}
+	return nil, &NotFoundError{Kind: KindProject, ID: id}
+}
+
+// questLocation describes where a quest lives in the tree
+type questLocation struct {
+	project  *Project
+	parent   *Quest   // nil for top-level quests
+	siblings *[]Quest // slice holding the quest
+	index    int
+}
+
+func (l questLocation) quest() *Quest {
+	return &(*l.siblings)[l.index]
+}
+
+// locateQuestIn searches a quest tree depth-first for id
+func locateQuestIn(project *Project, parent *Quest, quests *[]Quest, id string) (questLocation, bool) {
+	for i := range *quests {
+		if (*quests)[i].ID == id {
+			return questLocation{project: project, parent: parent, siblings: quests, index: i}, true
+		}
+		q := &(*quests)[i]
+		if loc, ok := locateQuestIn(project, q, &q.SubQuests, id); ok {
+			return loc, true
+		}
+	}
+	return questLocation{}, false
+}
+
+func (s *Store) locateQuest(id string) (questLocation, error) {
+	for i := range s.projects {
+		p := &s.projects[i]
+		if loc, ok := locateQuestIn(p, nil, &p.Quests, id); ok {
+			return loc, nil
+		}
+	}
+	return questLocation{}, &NotFoundError{Kind: KindQuest, ID: id}
+}
+
+// Quest looks up a quest at any depth by ID
+func (s *Store) Quest(id string) (*Quest, error) {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return nil, err
+	}
+	return loc.quest(), nil
+}
+
+// QuestProject returns the project that owns a quest
+func (s *Store) QuestProject(questID string) (*Project, error) {
+	loc, err := s.locateQuest(questID)
+	if err != nil {
+		return nil, err
+	}
+	return loc.project, nil
+}
+
+// QuestParent returns the parent of a sub-quest, or nil for a top-level quest
+func (s *Store) QuestParent(questID string) (*Quest, error) {
+	loc, err := s.locateQuest(questID)
+	if err != nil {
+		return nil, err
+	}
+	return loc.parent, nil
+}
+
+// taskLocation describes where a task lives in the tree
+type taskLocation struct {
+	project *Project
+	quest   *Quest
+	index   int
+}
+
+func locateTaskIn(project *Project, quests []Quest, id string) (taskLocation, bool) {
+	for i := range quests {
+		q := &quests[i]
+		for j := range q.Tasks {
+			if q.Tasks[j].ID == id {
+				return taskLocation{project: project, quest: q, index: j}, true
+			}
+		}
+		if loc, ok := locateTaskIn(project, q.SubQuests, id); ok {
+			return loc, true
+		}
+	}
+	return taskLocation{}, false
+}
+
+func (s *Store) locateTask(id string) (taskLocation, error) {
+	for i := range s.projects {
+		p := &s.projects[i]
+		if loc, ok := locateTaskIn(p, p.Quests, id); ok {
+			return loc, nil
+		}
+	}
+	return taskLocation{}, &NotFoundError{Kind: KindTask, ID: id}
+}
+
+// Task looks up a task by ID
+func (s *Store) Task(id string) (*Task, error) {
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return nil, err
+	}
+	return &loc.quest.Tasks[loc.index], nil
+}
+
+// TaskQuest returns the quest that owns a task
+func (s *Store) TaskQuest(taskID string) (*Quest, error) {
+	loc, err := s.locateTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	return loc.quest, nil
+}
+
+// CreateProject adds a new project
+func (s *Store) CreateProject(in ProjectInput) (*Project, error) {
+	if in.ProgressMode != "" && !ValidProgressMode(in.ProgressMode) {
+		return nil, fmt.Errorf("unknown progress mode %q (use %s or %s)", in.ProgressMode, ProgressByCount, ProgressByEffort)
+	}
+	p := Project{ID: generateID(), Name: in.Name, Quests: []Quest{}, AutoComplete: in.AutoComplete}
+	if in.ProgressMode == ProgressByEffort {
+		p.ProgressMode = in.ProgressMode
+	}
+	err := s.mutate(fmt.Sprintf("create project %q", in.Name), []string{p.ID}, func() error {
+		p.CalculateProgress()
+		s.projects = append(s.projects, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s.projects[len(s.projects)-1], nil
+}
+
+// UpdateProject renames a project
+func (s *Store) UpdateProject(id, name string) error {
+	p, err := s.Project(id)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("rename project %q", p.Name), []string{id}, func() error {
+		p.Name = name
+		return nil
+	})
+}
+
+// DeleteProject removes a project and its quests
+func (s *Store) DeleteProject(id string) error {
+	i := s.projectIndex(id)
+	if i < 0 {
+		return &NotFoundError{Kind: KindProject, ID: id}
+	}
+	return s.mutate(fmt.Sprintf("delete project %q", s.projects[i].Name), []string{id}, func() error {
+		s.projects = append(s.projects[:i], s.projects[i+1:]...)
+		return nil
+	})
+}
+
+// CreateQuest adds a new quest to a project. An empty parentID creates a
+// top-level quest; otherwise the quest becomes a sub-quest of parentID.
+func (s *Store) CreateQuest(projectID, parentID string, input QuestInput) (*Quest, error) {
+	p, err := s.Project(projectID)
+	if err != nil {
+		return nil, err
+	}
+	siblings := &p.Quests
+	if parentID != "" {
+		loc, err := s.locateQuest(parentID)
+		if err != nil {
+			return nil, err
+		}
+		if loc.project != p {
+			return nil, &NotFoundError{Kind: KindQuest, ID: parentID}
+		}
+		siblings = &loc.quest().SubQuests
+	}
+	var created *Quest
+	err = s.mutate(fmt.Sprintf("create quest %q", input.Title), []string{projectID}, func() error {
+		now := time.Now()
+		q := Quest{ID: generateID(), State: StateActive, CreatedAt: &now}
+		input.apply(&q)
+		*siblings = append(*siblings, q)
+		created = &(*siblings)[len(*siblings)-1]
+		p.CalculateProgress()
+		return nil
+	})
+	return created, err
+}
+
+// apply copies the input fields onto a quest
+func (in QuestInput) apply(q *Quest) {
+	q.Title = in.Title
+	q.Description = in.Description
+	q.Priority = in.Priority
+	q.Deadline = in.Deadline
+	q.Tags = in.Tags
+	q.Recur = in.Recur
+	q.Estimate = in.Estimate
+}
+
+// UpdateQuest updates an existing quest at any depth
+func (s *Store) UpdateQuest(id string, input QuestInput) error {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("edit quest %q", loc.quest().Title), []string{loc.project.ID}, func() error {
+		input.apply(loc.quest())
+		loc.project.CalculateProgress()
+		return nil
+	})
+}
+
+// MoveQuest moves a quest, with its sub-quests and tasks, under another
+// parent. An empty parentID moves it to the top level of projectID. A move
+// that would leave work waiting on itself fails with a CycleError.
+func (s *Store) MoveQuest(id, projectID, parentID string) error {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.Project(projectID); err != nil {
+		return err
+	}
+	if parentID != "" {
+		if parentID == id {
+			return ErrInvalidMove
+		}
+		if _, inside := locateQuestIn(loc.project, nil, &loc.quest().SubQuests, parentID); inside {
+			return ErrInvalidMove
+		}
+		parentLoc, err := s.locateQuest(parentID)
+		if err != nil {
+			return err
+		}
+		if parentLoc.project.ID != projectID {
+			return &NotFoundError{Kind: KindQuest, ID: parentID}
+		}
+		if moveMakesCycle(parentLoc.project, *loc.quest(), parentID) {
+			return &CycleError{Kind: KindQuest, Item: loc.quest().Title, Blocker: parentLoc.quest().Title}
+		}
+	}
+
+	return s.mutate(fmt.Sprintf("move quest %q", loc.quest().Title), []string{loc.project.ID, projectID}, func() error {
+		moved := *loc.quest()
+		*loc.siblings = append((*loc.siblings)[:loc.index], (*loc.siblings)[loc.index+1:]...)
+		loc.project.CalculateProgress()
+
+		// Re-resolve the destination since removal may have shifted the tree
+		dest, _ := s.Project(projectID)
+		siblings := &dest.Quests
+		if parentID != "" {
+			parentLoc, _ := s.locateQuest(parentID)
+			siblings = &parentLoc.quest().SubQuests
+		}
+		*siblings = append(*siblings, moved)
+		dest.CalculateProgress()
+		if dest != loc.project {
+			pruneBlockers(loc.project)
+			pruneBlockers(dest)
+		}
+		return nil
+	})
+}
+
+// DeleteQuest removes a quest and its sub-quests
+func (s *Store) DeleteQuest(id string) error {
+	loc, err := s.locateQuest(id)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("delete quest %q", loc.quest().Title), []string{loc.project.ID}, func() error {
+		*loc.siblings = append((*loc.siblings)[:loc.index], (*loc.siblings)[loc.index+1:]...)
+		pruneBlockers(loc.project)
+		loc.project.CalculateProgress()
+		return nil
+	})
+}
+
+// apply copies the input fields onto a task
+func (in TaskInput) apply(t *Task) {
+	t.Description = in.Description
+	t.Tags = in.Tags
+	t.Priority = in.Priority
+	t.Deadline = in.Deadline
+	t.Estimate = in.Estimate
+	t.Notes = in.Notes
+	t.Recur = in.Recur
+}
+
+// CreateTask adds a new task to a quest
+func (s *Store) CreateTask(questID string, input TaskInput) (*Task, error) {
+	loc, err := s.locateQuest(questID)
+	if err != nil {
+		return nil, err
+	}
+	var created *Task
+	err = s.mutate(fmt.Sprintf("create task %q", input.Description), []string{loc.project.ID}, func() error {
+		q := loc.quest()
+		t := Task{ID: generateID()}
+		input.apply(&t)
+		q.Tasks = append(q.Tasks, t)
+		loc.project.CalculateProgress()
+		created = &q.Tasks[len(q.Tasks)-1]
+		return nil
+	})
+	return created, err
+}
+
+// UpdateTask updates an existing task
+func (s *Store) UpdateTask(id string, input TaskInput) error {
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("edit task %q", loc.quest.Tasks[loc.index].Description), []string{loc.project.ID}, func() error {
+		input.apply(&loc.quest.Tasks[loc.index])
+		loc.project.CalculateProgress()
+		return nil
+	})
+}
+
+// ToggleTask flips a task between done and not done
+func (s *Store) ToggleTask(id string) error {
+	t, err := s.Task(id)
+	if err != nil {
+		return err
+	}
+	return s.SetTaskDone(id, !t.Done)
+}
+
+// SetTaskDone marks a task done or not done
+func (s *Store) SetTaskDone(id string, done bool) error {
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return err
+	}
+	t := &loc.quest.Tasks[loc.index]
+	label := fmt.Sprintf("complete task %q", t.Description)
+	if !done {
+		label = fmt.Sprintf("uncheck task %q", t.Description)
+	}
+	return s.mutate(label, []string{loc.project.ID}, func() error {
+		if done && !t.Done {
+			s.award(taskAward(loc.project.ID, t, time.Now()))
+		} else if !done && t.Done {
+			s.revokeXP(t.ID)
+		}
+		t.Done = done
+		if done {
+			stopRunning(t, time.Now(), "")
+		}
+		// Completing a recurring task queues up its next occurrence
+		if done && t.Recur != nil {
+			next := nextTask(*t, t.Recur.Next(t.Deadline, time.Now()))
+			t.Recur = nil
+			loc.quest.Tasks = append(loc.quest.Tasks, next)
+		}
+		loc.project.CalculateProgress()
+		s.autoComplete(loc.quest.ID)
+		return nil
+	})
+}
+
+// MoveTask moves a task to another quest
+func (s *Store) MoveTask(id, questID string) error {
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return err
+	}
+	destLoc, err := s.locateQuest(questID)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("move task %q", loc.quest.Tasks[loc.index].Description), []string{loc.project.ID, destLoc.project.ID}, func() error {
+		moved := loc.quest.Tasks[loc.index]
+		loc.quest.Tasks = append(loc.quest.Tasks[:loc.index], loc.quest.Tasks[loc.index+1:]...)
+		loc.project.CalculateProgress()
+
+		dest, _ := s.locateQuest(questID)
+		q := dest.quest()
+		q.Tasks = append(q.Tasks, moved)
+		dest.project.CalculateProgress()
+		if dest.project != loc.project {
+			pruneBlockers(loc.project)
+			pruneBlockers(dest.project)
+		}
+		return nil
+	})
+}
+
+// DeleteTask removes a task from its quest
+func (s *Store) DeleteTask(id string) error {
+	loc, err := s.locateTask(id)
+	if err != nil {
+		return err
+	}
+	return s.mutate(fmt.Sprintf("delete task %q", loc.quest.Tasks[loc.index].Description), []string{loc.project.ID}, func() error {
+		loc.quest.Tasks = append(loc.quest.Tasks[:loc.index], loc.quest.Tasks[loc.index+1:]...)
+		pruneBlockers(loc.project)
+		loc.project.CalculateProgress()
+		return nil
+	})
+}
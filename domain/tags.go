@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// NormalizeTag lowercases a tag and strips filter and hashtag prefixes
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimSpace(tag), "#+-"))
+}
+
+// ParseTags splits a comma- or space-separated list into normalized,
+// de-duplicated tags in the order given
+func ParseTags(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	var tags []string
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		tag := NormalizeTag(field)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// FormatTags joins tags for display and editing
+func FormatTags(tags []string) string {
+	return strings.Join(tags, ", ")
+}
+
+// HashTags renders tags as #tag words
+func HashTags(tags []string) string {
+	words := make([]string, len(tags))
+	for i, tag := range tags {
+		words[i] = "#" + tag
+	}
+	return strings.Join(words, " ")
+}
+
+// HasTag reports whether tags contains tag
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// collectTags adds every quest and task tag in a quest tree to set
+func collectTags(quests []Quest, set map[string]bool) {
+	for _, q := range quests {
+		for _, tag := range q.Tags {
+			set[tag] = true
+		}
+		for _, t := range q.Tasks {
+			for _, tag := range t.Tags {
+				set[tag] = true
+			}
+		}
+		collectTags(q.SubQuests, set)
+	}
+}
+
+// AllTags returns every tag in use, sorted
+func AllTags(projects []Project) []string {
+	set := make(map[string]bool)
+	for _, p := range projects {
+		collectTags(p.Quests, set)
+	}
+	tags := make([]string, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// mergeTags returns inherited followed by the own tags not already in it
+func mergeTags(inherited, own []string) []string {
+	merged := append([]string(nil), inherited...)
+	for _, tag := range own {
+		if !HasTag(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// indexTags records the effective tags of each quest and task in a tree
+func indexTags(quests []Quest, inherited []string, index map[string][]string) {
+	for _, q := range quests {
+		tags := mergeTags(inherited, q.Tags)
+		index[q.ID] = tags
+		for _, t := range q.Tasks {
+			index[t.ID] = mergeTags(tags, t.Tags)
+		}
+		indexTags(q.SubQuests, tags, index)
+	}
+}
+
+// EffectiveTags maps each quest and task ID to its tags plus those it
+// inherits: a sub-quest carries its ancestors' tags and a task its quest's
+func EffectiveTags(projects []Project) map[string][]string {
+	index := make(map[string][]string)
+	for _, p := range projects {
+		indexTags(p.Quests, nil, index)
+	}
+	return index
+}
+
+// TagFilter selects items that have every Include tag and no Exclude tag
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseTagFilter parses an expression such as "+backend -blocked". A bare
+// word counts as an included tag.
+func ParseTagFilter(expr string) TagFilter {
+	var f TagFilter
+	for _, term := range strings.Fields(expr) {
+		f.add(term)
+	}
+	return f
+}
+
+// add appends one term to the filter
+func (f *TagFilter) add(term string) {
+	tag := NormalizeTag(term)
+	if tag == "" {
+		return
+	}
+	if strings.HasPrefix(term, "-") {
+		f.Exclude = append(f.Exclude, tag)
+	} else {
+		f.Include = append(f.Include, tag)
+	}
+}
+
+// isTagTerm reports whether a word is a +tag or -tag term
+func isTagTerm(word string) bool {
+	return len(word) > 1 && (word[0] == '+' || word[0] == '-')
+}
+
+// splitTagTerms separates the +tag and -tag terms of a query from its text
+func splitTagTerms(query string) (TagFilter, string) {
+	var f TagFilter
+	var words []string
+	for _, word := range strings.Fields(query) {
+		if isTagTerm(word) {
+			f.add(word)
+		} else {
+			words = append(words, word)
+		}
+	}
+	return f, strings.Join(words, " ")
+}
+
+// IsEmpty reports whether the filter selects everything
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Matches reports whether tags satisfy the filter
+func (f TagFilter) Matches(tags []string) bool {
+	for _, tag := range f.Include {
+		if !HasTag(tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range f.Exclude {
+		if HasTag(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the filter as an expression ParseTagFilter accepts
+func (f TagFilter) String() string {
+	terms := make([]string, 0, len(f.Include)+len(f.Exclude))
+	for _, tag := range f.Include {
+		terms = append(terms, "+"+tag)
+	}
+	for _, tag := range f.Exclude {
+		terms = append(terms, "-"+tag)
+	}
+	return strings.Join(terms, " ")
+}
+
+// FilterQuests keeps the quests whose effective tags match, using an index
+// from EffectiveTags
+func (f TagFilter) FilterQuests(quests []Quest, index map[string][]string) []Quest {
+	if f.IsEmpty() {
+		return quests
+	}
+	filtered := []Quest{}
+	for _, q := range quests {
+		if f.Matches(index[q.ID]) {
+			filtered = append(filtered, q)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// listInbox is the quest that takes tasks imported from a flat task list
+// without a project
+const listInbox = "Inbox"
+
+// Flat task lists such as todo.txt and Taskwarrior name a task's quest by
+// a dotted path of quest titles, e.g. "Garden.Order-bulbs" for the sub-quest
+// "Order bulbs" of "Garden".
+
+// pathSegment turns a quest title into one segment of a quest path, with
+// spaces and dots replaced by "-"
+func pathSegment(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(title) {
+		if unicode.IsSpace(r) || r == '.' {
+			if !dash {
+				b.WriteRune('-')
+			}
+			dash = true
+			continue
+		}
+		dash = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// splitQuestPath splits a dotted quest path, dropping empty segments
+func splitQuestPath(path string) []string {
+	var segments []string
+	for _, s := range strings.Split(path, ".") {
+		if s = strings.TrimSpace(s); s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// sameSegment reports whether a quest title and a path segment match
+func sameSegment(title, segment string) bool {
+	return strings.EqualFold(pathSegment(title), pathSegment(segment))
+}
+
+// findQuestNamed returns the index of the quest a path segment names,
+// preferring an open one over finished quests of the same title, or -1
+func findQuestNamed(quests []Quest, segment string) int {
+	found := -1
+	for i, q := range quests {
+		if !sameSegment(q.Title, segment) {
+			continue
+		}
+		if q.State.IsOpen() {
+			return i
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	return found
+}
+
+// findQuestID returns the index of the quest with an ID, or -1
+func findQuestID(quests []Quest, id string) int {
+	for i, q := range quests {
+		if q.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// walkListTasks calls fn for every task of a quest tree with the path of
+// its quest
+func walkListTasks(quests []Quest, parent []string, fn func(path []string, t Task)) {
+	for _, q := range quests {
+		path := append(append([]string(nil), parent...), pathSegment(q.Title))
+		for _, t := range q.Tasks {
+			fn(path, t)
+		}
+		walkListTasks(q.SubQuests, path, fn)
+	}
+}
+
+// taskTimes returns when a task was added and last checked off, from a
+// project's event log; zero times are unknown. A task added already done
+// counts as checked off when it was added.
+func taskTimes(p *Project, taskID string) (added, done time.Time) {
+	for _, e := range p.Events {
+		if e.ItemID != taskID {
+			continue
+		}
+		switch e.Kind {
+		case EventTaskAdded:
+			if added.IsZero() {
+				added = e.At
+			}
+			if e.Done {
+				done = e.At
+			}
+		case EventTaskDone:
+			done = e.At
+		}
+	}
+	return added, done
+}
+
+// listImport builds the project a flat task list is imported as. Matched
+// tasks and the quests on their paths start as copies of the existing ones,
+// so fields the list cannot carry, such as estimates, survive the merge.
+type listImport struct {
+	base   Project            // the project imported into
+	out    Project            // what ImportProjects is given
+	tasks  map[string]Task    // the base project's tasks by ID
+	quests map[string][]Quest // the quests holding each base task, outermost first
+}
+
+// newListImport starts an import into base, which may be a new project
+// with only a name
+func newListImport(base Project) *listImport {
+	base = *cloneProject(&base)
+	l := &listImport{base: base, tasks: make(map[string]Task), quests: make(map[string][]Quest)}
+	l.out = base
+	l.out.Quests = []Quest{}
+	var walk func(quests []Quest, parents []Quest)
+	walk = func(quests []Quest, parents []Quest) {
+		for _, q := range quests {
+			chain := append(append([]Quest(nil), parents...), q)
+			for _, t := range q.Tasks {
+				l.tasks[t.ID] = t
+				l.quests[t.ID] = chain
+			}
+			walk(q.SubQuests, chain)
+		}
+	}
+	walk(base.Quests, nil)
+	return l
+}
+
+// existing returns the base project's task with an ID
+func (l *listImport) existing(id string) (Task, bool) {
+	t, ok := l.tasks[id]
+	return t, ok
+}
+
+// add places a task in the quest a path names, creating the quests that
+// neither the import nor the base project has yet. A known task whose path
+// still names the quests it is in stays there, even when other quests share
+// their titles, such as the finished occurrences of a recurring quest.
+func (l *listImport) add(path []string, t Task) {
+	if len(path) == 0 {
+		path = []string{listInbox}
+	}
+	var ids []string
+	if chain, ok := l.quests[t.ID]; ok && len(chain) == len(path) {
+		for i, q := range chain {
+			if !sameSegment(q.Title, path[i]) {
+				ids = nil
+				break
+			}
+			ids = append(ids, q.ID)
+		}
+	}
+	addListTask(&l.out.Quests, l.base.Quests, path, ids, t)
+}
+
+// addListTask adds a task under path, with existing holding the base
+// project's quests at the same level. When ids is set it names the quests
+// on the path, which are then matched by ID rather than title.
+func addListTask(quests *[]Quest, existing []Quest, path, ids []string, t Task) {
+	var i, j int
+	if ids != nil {
+		i, j = findQuestID(*quests, ids[0]), findQuestID(existing, ids[0])
+	} else {
+		i, j = findQuestNamed(*quests, path[0]), findQuestNamed(existing, path[0])
+	}
+	if i >= 0 {
+		// The quest already placed decides which existing quest lies below
+		j = findQuestID(existing, (*quests)[i].ID)
+	}
+	if i < 0 {
+		q := Quest{Title: path[0], State: StateActive, Tasks: []Task{}}
+		if j >= 0 {
+			q = existing[j]
+			q.Tasks, q.SubQuests = []Task{}, nil
+		}
+		*quests = append(*quests, q)
+		i = len(*quests) - 1
+	}
+	if len(path) == 1 {
+		(*quests)[i].Tasks = append((*quests)[i].Tasks, t)
+		return
+	}
+	var inner []Quest
+	if j >= 0 {
+		inner = existing[j].SubQuests
+	}
+	if ids != nil {
+		ids = ids[1:]
+	}
+	addListTask(&(*quests)[i].SubQuests, inner, path[1:], ids, t)
+}
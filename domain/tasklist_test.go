@@ -0,0 +1,302 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listedTasks describes every task of a project on one line, with its
+// quest path, for comparing the results of list imports
+func listedTasks(p Project) []string {
+	var lines []string
+	walkListTasks(p.Quests, nil, func(path []string, t Task) {
+		line := fmt.Sprintf("%s: %s", strings.Join(path, "."), t.Description)
+		if t.Done {
+			line += " done"
+		}
+		if t.Priority != 0 {
+			line += fmt.Sprintf(" pri=%d", t.Priority)
+		}
+		if t.Deadline != nil {
+			line += " due=" + t.Deadline.Format("2006-01-02")
+		}
+		if len(t.Tags) > 0 {
+			line += " tags=" + strings.Join(t.Tags, ",")
+		}
+		if t.Estimate != 0 {
+			line += fmt.Sprintf(" est=%d", t.Estimate)
+		}
+		if len(t.BlockedBy) > 0 {
+			line += " after=" + strings.Join(t.BlockedBy, ",")
+		}
+		lines = append(lines, line)
+	})
+	return lines
+}
+
+// auditStore holds a project whose recurring Audit quest was completed, so
+// a finished and an open quest share the title
+func auditStore(t *testing.T) *Store {
+	t.Helper()
+	s := NewStore([]Project{{ID: "p1", Name: "Office", Quests: []Quest{{
+		ID: "q1", Title: "Audit", State: StateActive, Recur: &Recurrence{Freq: FreqWeekly},
+		Tasks: []Task{{ID: "t1", Description: "Check books", Done: true}},
+	}}}})
+	if err := s.CompleteQuest("q1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.Projects()[0].Quests); n != 2 {
+		t.Fatalf("completing Audit left %d quests, want the next occurrence beside it", n)
+	}
+	return s
+}
+
+// questOfTask maps every task of a project to the ID of its quest
+func questOfTask(p Project) map[string]string {
+	quests := make(map[string]string)
+	var walk func(quests []Quest)
+	walk = func(list []Quest) {
+		for _, q := range list {
+			for _, t := range q.Tasks {
+				quests[t.ID] = q.ID
+			}
+			walk(q.SubQuests)
+		}
+	}
+	walk(p.Quests)
+	return quests
+}
+
+func TestListReimportKeepsTasksInTheirQuests(t *testing.T) {
+	formats := []struct {
+		name   string
+		export func(p Project) (string, error)
+		parse  func(text string, into Project) (Project, error)
+	}{
+		{
+			name:   "todo.txt",
+			export: func(p Project) (string, error) { return FormatTodoTxt(p), nil },
+			parse: func(text string, into Project) (Project, error) {
+				return ParseTodoTxt(strings.NewReader(text), into)
+			},
+		},
+		{
+			name:   "taskwarrior",
+			export: FormatTaskwarrior,
+			parse: func(text string, into Project) (Project, error) {
+				return ParseTaskwarrior(strings.NewReader(text), into)
+			},
+		},
+	}
+	for _, f := range formats {
+		t.Run(f.name, func(t *testing.T) {
+			s := auditStore(t)
+			before := s.Projects()[0]
+			want := questOfTask(before)
+			text, err := f.export(before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in, err := f.parse(text, before)
+			if err != nil {
+				t.Fatalf("parse failed: %v\n%s", err, text)
+			}
+			sum, err := s.ImportProjects([]Project{in})
+			if err != nil {
+				t.Fatalf("ImportProjects failed: %v", err)
+			}
+			if sum.AddedQuests != 0 || sum.AddedTasks != 0 {
+				t.Errorf("summary = %+v, want nothing added", sum)
+			}
+			after := s.Projects()[0]
+			if got := questOfTask(after); !reflect.DeepEqual(got, want) {
+				t.Errorf("tasks are in quests %v, want %v", got, want)
+			}
+			if len(after.Quests) != 2 {
+				t.Errorf("project has %d quests, want 2", len(after.Quests))
+			}
+		})
+	}
+}
+
+func TestParseTodoTxt(t *testing.T) {
+	into := Project{ID: "p1", Name: "Home", Quests: []Quest{{ID: "q1", Title: "Chores", State: StateActive,
+		Tasks: []Task{{ID: "t1", Description: "Sweep", Priority: 8, Estimate: 20, Notes: "under the sofa"}}}}}
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "priority, dates, project, context and due date",
+			in:   "(A) 2026-01-02 Call mum +Family @phone due:2026-01-10 id:t5\n",
+			want: []string{"Family: Call mum pri=10 due=2026-01-10 tags=phone"},
+		},
+		{
+			name: "completed with pri: and a nested quest path",
+			in:   "x 2026-01-03 2026-01-01 Pay rent +Home.Bills pri:B\n",
+			want: []string{"Home.Bills: Pay rent done pri=9"},
+		},
+		{
+			name: "no project goes to the inbox",
+			in:   "Buy milk\n\n",
+			want: []string{"Inbox: Buy milk"},
+		},
+		{
+			name: "further projects become tags and other keys stay in the text",
+			in:   "Read docs +Work +Reading url:example.org\n",
+			want: []string{"Work: Read docs url:example.org tags=reading"},
+		},
+		{
+			name: "low priorities count as 1",
+			in:   "(K) Someday +Ideas\n",
+			want: []string{"Ideas: Someday pri=1"},
+		},
+		{
+			name: "a known task keeps what todo.txt does not carry",
+			in:   "(C) Sweep floors +Chores id:t1\n",
+			want: []string{"Chores: Sweep floors pri=8 est=20"},
+		},
+		{
+			name: "a known task without a priority loses it",
+			in:   "Sweep +Chores id:t1\n",
+			want: []string{"Chores: Sweep est=20"},
+		},
+		{
+			name:    "invalid due date",
+			in:      "Pay +Bills due:2026-13-01\n",
+			wantErr: true,
+		},
+		{
+			name:    "no description",
+			in:      "(A) +Bills @home\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		out, err := ParseTodoTxt(strings.NewReader(tt.in), into)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: ParseTodoTxt succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParseTodoTxt failed: %v", tt.name, err)
+			continue
+		}
+		if got := listedTasks(out); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: tasks = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTaskwarrior(t *testing.T) {
+	into := Project{ID: "p1", Name: "Home", Quests: []Quest{{ID: "q1", Title: "Chores", State: StateActive,
+		Tasks: []Task{{ID: "t1", Description: "Sweep", Estimate: 20}}}}}
+	due := twDay(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC))
+	const (
+		a       = "aaaaaaaa-0000-4000-8000-000000000001"
+		b       = "aaaaaaaa-0000-4000-8000-000000000002"
+		unknown = "ffffffff-0000-4000-8000-000000000009"
+	)
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "fields of a pending task",
+			in: `[{"uuid": "` + a + `", "description": "Call mum", "status": "pending", "project": "Family.Calls",
+				"tags": ["phone"], "priority": "H", "due": "` + due + `",
+				"annotations": [{"entry": "20260101T000000Z", "description": "after six"}]}]`,
+			want: []string{"Family.Calls: Call mum pri=8 due=2026-01-10 tags=phone"},
+		},
+		{
+			name: "completed, waiting, deleted and recurring",
+			in: `[{"uuid": "` + a + `", "description": "Done", "status": "completed"},
+				{"uuid": "` + b + `", "description": "Later", "status": "waiting"},
+				{"description": "Gone", "status": "deleted"},
+				{"description": "Template", "status": "recurring"}]`,
+			want: []string{"Inbox: Done done", "Inbox: Later"},
+		},
+		{
+			name: "dependencies on imported tasks are kept and unknown ones dropped",
+			in: `[{"uuid": "` + a + `", "description": "First", "status": "pending"},
+				{"uuid": "` + b + `", "description": "Second", "status": "pending", "depends": ["` + a + `", "` + unknown + `"]}]`,
+			want: []string{"Inbox: First", "Inbox: Second after=" + a},
+		},
+		{
+			name: "dependencies on deleted tasks are dropped",
+			in: `[{"uuid": "` + a + `", "description": "Gone", "status": "deleted"},
+				{"uuid": "` + b + `", "description": "Second", "status": "pending", "depends": "` + a + `"}]`,
+			want: []string{"Inbox: Second"},
+		},
+		{
+			name: "dependencies on the project's tasks map to their IDs",
+			in:   `[{"uuid": "` + b + `", "description": "Mop", "status": "pending", "project": "Chores", "depends": "` + taskUUID("t1") + `"}]`,
+			want: []string{"Chores: Mop after=t1"},
+		},
+		{
+			name: "a known task keeps its estimate",
+			in:   `[{"uuid": "` + taskUUID("t1") + `", "description": "Sweep floors", "status": "pending", "project": "Chores"}]`,
+			want: []string{"Chores: Sweep floors est=20"},
+		},
+		{
+			name: "one task per line",
+			in: `{"uuid": "` + a + `", "description": "One", "status": "pending"}
+				{"uuid": "` + b + `", "description": "Two", "status": "pending", "depends": "` + a + `,` + unknown + `"}`,
+			want: []string{"Inbox: One", "Inbox: Two after=" + a},
+		},
+		{
+			name:    "unknown status",
+			in:      `[{"description": "Odd", "status": "paused"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown priority",
+			in:      `[{"description": "Odd", "status": "pending", "priority": "X"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "no description",
+			in:      `[{"description": " ", "status": "pending"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid due date",
+			in:      `[{"description": "Odd", "status": "pending", "due": "tomorrow"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			in:      `[{"description": `,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		out, err := ParseTaskwarrior(strings.NewReader(tt.in), into)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: ParseTaskwarrior succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParseTaskwarrior failed: %v", tt.name, err)
+			continue
+		}
+		if got := listedTasks(out); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: tasks = %q, want %q", tt.name, got, tt.want)
+		}
+		// Whatever survives must import cleanly
+		if _, err := NewStore([]Project{*cloneProject(&into)}).ImportProjects([]Project{out}); err != nil {
+			t.Errorf("%s: importing the result failed: %v", tt.name, err)
+		}
+	}
+}
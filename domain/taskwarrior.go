@@ -0,0 +1,294 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// twTime is the date layout of Taskwarrior's JSON
+const twTime = "20060102T150405Z"
+
+// twTask is a task in Taskwarrior's JSON export format
+type twTask struct {
+	UUID        string         `json:"uuid"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`
+	Entry       string         `json:"entry,omitempty"`
+	End         string         `json:"end,omitempty"`
+	Due         string         `json:"due,omitempty"`
+	Project     string         `json:"project,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Priority    string         `json:"priority,omitempty"`
+	Depends     twDepends      `json:"depends,omitempty"`
+	Annotations []twAnnotation `json:"annotations,omitempty"`
+}
+
+// twAnnotation is a timestamped note on a Taskwarrior task
+type twAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// twDepends lists the UUIDs a task depends on. Taskwarrior 2.6 writes an
+// array; older versions write one comma-separated string.
+type twDepends []string
+
+// UnmarshalJSON reads either form of depends
+func (d *twDepends) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("depends must be a list of UUIDs")
+	}
+	*d = nil
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			*d = append(*d, id)
+		}
+	}
+	return nil
+}
+
+// twPriority returns Taskwarrior's H, M or L for a priority, or ""
+func twPriority(priority int) string {
+	switch {
+	case priority >= HighPriority:
+		return "H"
+	case priority >= 4:
+		return "M"
+	case priority >= 1:
+		return "L"
+	}
+	return ""
+}
+
+// parseTwPriority reads H, M or L as 8, 5 or 2
+func parseTwPriority(s string) (int, error) {
+	switch strings.ToUpper(s) {
+	case "":
+		return 0, nil
+	case "H":
+		return 8, nil
+	case "M":
+		return 5, nil
+	case "L":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("unknown priority %q (use H, M or L)", s)
+}
+
+// isUUID reports whether s is written like a UUID
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// taskUUID returns the UUID a task is exported with: its ID when that is a
+// UUID, as for tasks imported from Taskwarrior, or one derived from the ID
+// so repeated exports agree
+func taskUUID(id string) string {
+	if isUUID(id) {
+		return strings.ToLower(id)
+	}
+	sum := sha1.Sum([]byte("quest_line task " + id))
+	sum[6] = sum[6]&0x0f | 0x50 // version 5
+	sum[8] = sum[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// twDay writes a deadline as the start of its day in local time, as
+// Taskwarrior stores due:YYYY-MM-DD
+func twDay(d time.Time) string {
+	y, m, day := d.Date()
+	return time.Date(y, m, day, 0, 0, 0, 0, time.Local).UTC().Format(twTime)
+}
+
+// parseTwTime reads a Taskwarrior timestamp
+func parseTwTime(field, s string) (time.Time, error) {
+	t, err := time.Parse(twTime, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q", field, s)
+	}
+	return t, nil
+}
+
+// FormatTaskwarrior renders a project's tasks as a Taskwarrior JSON array
+// for `task import`. The task's quest path is its project, priorities of
+// HighPriority or more are H, 4 and up M and the rest L, and notes become
+// one annotation per line. Quests without tasks are left out.
+func FormatTaskwarrior(p Project) (string, error) {
+	tasks := []twTask{}
+	now := time.Now()
+	walkListTasks(p.Quests, nil, func(path []string, t Task) {
+		added, done := taskTimes(&p, t.ID)
+		if added.IsZero() {
+			added = now
+		}
+		tw := twTask{UUID: taskUUID(t.ID), Description: t.Description, Status: "pending",
+			Entry: added.UTC().Format(twTime), Project: strings.Join(path, "."), Tags: t.Tags,
+			Priority: twPriority(t.Priority)}
+		if t.Done {
+			if done.IsZero() {
+				done = now
+			}
+			tw.Status, tw.End = "completed", done.UTC().Format(twTime)
+		}
+		if t.Deadline != nil {
+			tw.Due = twDay(*t.Deadline)
+		}
+		for _, id := range t.BlockedBy {
+			tw.Depends = append(tw.Depends, taskUUID(id))
+		}
+		if t.Notes != "" {
+			for _, line := range strings.Split(t.Notes, "\n") {
+				tw.Annotations = append(tw.Annotations, twAnnotation{Entry: tw.Entry, Description: line})
+			}
+		}
+		tasks = append(tasks, tw)
+	})
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// readTwTasks reads a JSON array of tasks, or one task object per line as
+// older versions of `task export` write
+func readTwTasks(r io.Reader) ([]twTask, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	var tasks []twTask
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &tasks); err != nil {
+			return nil, fmt.Errorf("read Taskwarrior JSON: %w", err)
+		}
+		return tasks, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	for n := 1; dec.More(); n++ {
+		var t twTask
+		if err := dec.Decode(&t); err != nil {
+			return nil, fmt.Errorf("read Taskwarrior JSON task %d: %w", n, err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// ParseTaskwarrior reads a Taskwarrior JSON export as the tasks of a
+// project to import into. The project attribute is the quest path, tasks
+// without one go to the Inbox quest, completed tasks are checked off, and
+// annotations become the notes. Deleted tasks and recurring templates are
+// skipped, and dependencies on tasks that are neither imported nor in the
+// project are dropped. A task matches an existing one
+// whose ID is its UUID or exports as it, and starts from that task so what
+// Taskwarrior does not carry, such as estimates, is kept.
+func ParseTaskwarrior(r io.Reader, into Project) (Project, error) {
+	tasks, err := readTwTasks(r)
+	if err != nil {
+		return Project{}, err
+	}
+	l := newListImport(into)
+	ids := make(map[string]string, len(l.tasks)) // UUIDs to task IDs
+	for id := range l.tasks {
+		ids[taskUUID(id)] = id
+	}
+	// Dependencies can only point at tasks the import keeps or the project
+	// already has; filtered exports routinely name others
+	known := make(map[string]bool, len(ids)+len(tasks))
+	for uuid := range ids {
+		known[uuid] = true
+	}
+	for _, tw := range tasks {
+		if tw.Status != "deleted" && tw.Status != "recurring" && tw.UUID != "" {
+			known[strings.ToLower(tw.UUID)] = true
+		}
+	}
+	idFor := func(uuid string) string {
+		uuid = strings.ToLower(uuid)
+		if id, ok := ids[uuid]; ok {
+			return id
+		}
+		return uuid
+	}
+
+	for n, tw := range tasks {
+		if tw.Status == "deleted" || tw.Status == "recurring" {
+			continue
+		}
+		if strings.TrimSpace(tw.Description) == "" {
+			return Project{}, fmt.Errorf("task %d has no description", n+1)
+		}
+		var t Task
+		if tw.UUID != "" {
+			t, _ = l.existing(idFor(tw.UUID))
+			t.ID = idFor(tw.UUID)
+		}
+		t.Description = strings.TrimSpace(tw.Description)
+		switch tw.Status {
+		case "pending", "waiting", "":
+			t.Done = false
+		case "completed":
+			t.Done = true
+		default:
+			return Project{}, fmt.Errorf("task %q has unknown status %q", t.Description, tw.Status)
+		}
+		priority, err := parseTwPriority(tw.Priority)
+		if err != nil {
+			return Project{}, fmt.Errorf("task %q: %w", t.Description, err)
+		}
+		if twPriority(t.Priority) != strings.ToUpper(tw.Priority) {
+			t.Priority = priority
+		}
+		t.Deadline = nil
+		if tw.Due != "" {
+			due, err := parseTwTime("due", tw.Due)
+			if err != nil {
+				return Project{}, fmt.Errorf("task %q: %w", t.Description, err)
+			}
+			d := civilDate(due.Local())
+			t.Deadline = &d
+		}
+		t.Tags = ParseTags(strings.Join(tw.Tags, " "))
+		var notes []string
+		for _, a := range tw.Annotations {
+			notes = append(notes, a.Description)
+		}
+		t.Notes = strings.Join(notes, "\n")
+		t.BlockedBy = nil
+		for _, uuid := range tw.Depends {
+			if known[strings.ToLower(uuid)] {
+				t.BlockedBy = append(t.BlockedBy, idFor(uuid))
+			}
+		}
+		l.add(splitQuestPath(tw.Project), t)
+	}
+	return l.out, nil
+}
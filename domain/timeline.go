@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// TimelineDependency is a quest that a timeline bar waits on
+type TimelineDependency struct {
+	QuestID  string     `json:"quest_id"`
+	Title    string     `json:"title"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+	Done     bool       `json:"done,omitempty"` // completed or cancelled, so no longer blocking
+}
+
+// TimelineBar is an open quest drawn from the day it started to its deadline
+type TimelineBar struct {
+	QuestID   string               `json:"quest_id"`
+	Title     string               `json:"title"`
+	Path      string               `json:"path,omitempty"` // titles of the parent quests
+	State     QuestState           `json:"state"`
+	Progress  float64              `json:"progress"`
+	Start     time.Time            `json:"start"` // midnight UTC, like deadlines
+	End       time.Time            `json:"end"`
+	Overdue   bool                 `json:"overdue,omitempty"`
+	BlockedBy []TimelineDependency `json:"blocked_by,omitempty"`
+}
+
+// TimelineGroup holds the bars of one project
+type TimelineGroup struct {
+	ProjectID string        `json:"project_id"`
+	Project   string        `json:"project"`
+	Bars      []TimelineBar `json:"bars"`
+}
+
+// questStart returns the day a quest was last started, or else the day it
+// was created. It is nil when neither is known.
+func questStart(q Quest) *time.Time {
+	for i := len(q.History) - 1; i >= 0; i-- {
+		if q.History[i].To == StateInProgress {
+			day := civilDate(q.History[i].At.Local())
+			return &day
+		}
+	}
+	if q.CreatedAt != nil {
+		day := civilDate(q.CreatedAt.Local())
+		return &day
+	}
+	return nil
+}
+
+// indexQuests maps the ID of every quest in a tree to the quest
+func indexQuests(quests []Quest, index map[string]Quest) {
+	for _, q := range quests {
+		index[q.ID] = q
+		indexQuests(q.SubQuests, index)
+	}
+}
+
+// collectBars appends a bar for every open quest with a deadline in a tree
+func collectBars(quests []Quest, path string, index map[string]Quest, now time.Time, bars *[]TimelineBar) {
+	for _, q := range quests {
+		if q.State.IsOpen() && q.Deadline != nil {
+			end := civilDate(*q.Deadline)
+			start := end
+			if s := questStart(q); s != nil && s.Before(end) {
+				start = *s
+			}
+			bar := TimelineBar{QuestID: q.ID, Title: q.Title, Path: path, State: q.State, Progress: q.Progress,
+				Start: start, End: end, Overdue: IsOverdue(q.Deadline, now)}
+			for _, id := range q.BlockedBy {
+				if blocker, ok := index[id]; ok {
+					bar.BlockedBy = append(bar.BlockedBy, TimelineDependency{QuestID: id, Title: blocker.Title,
+						Deadline: blocker.Deadline, Done: !blocker.State.IsOpen()})
+				}
+			}
+			*bars = append(*bars, bar)
+		}
+		sub := q.Title
+		if path != "" {
+			sub = path + " › " + q.Title
+		}
+		collectBars(q.SubQuests, sub, index, now, bars)
+	}
+}
+
+// Timeline returns the open quests with deadlines, at any depth, grouped by
+// project and ordered by start and then end. Each bar runs from the day the
+// quest was last started, or created, to its deadline. An empty projectID
+// covers every project; projects with nothing to draw are left out.
+func Timeline(projects []Project, projectID string, now time.Time) []TimelineGroup {
+	var groups []TimelineGroup
+	for _, p := range projects {
+		if projectID != "" && p.ID != projectID {
+			continue
+		}
+		index := make(map[string]Quest)
+		indexQuests(p.Quests, index)
+		var bars []TimelineBar
+		collectBars(p.Quests, "", index, now, &bars)
+		if len(bars) == 0 {
+			continue
+		}
+		sort.SliceStable(bars, func(i, j int) bool {
+			if !bars[i].Start.Equal(bars[j].Start) {
+				return bars[i].Start.Before(bars[j].Start)
+			}
+			return bars[i].End.Before(bars[j].End)
+		})
+		groups = append(groups, TimelineGroup{ProjectID: p.ID, Project: p.Name, Bars: bars})
+	}
+	return groups
+}
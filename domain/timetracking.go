@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrNoTimer is returned when stopping a timer while none is running
+var ErrNoTimer = errors.New("no timer is running")
+
+// TimeEntry is one stretch of work on a task. End is nil while the timer
+// is still running.
+type TimeEntry struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+	Note  string     `json:"note,omitempty"`
+}
+
+// Duration returns the length of the entry, counting a running one up to now
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	if e.End == nil {
+		return now.Sub(e.Start)
+	}
+	return e.End.Sub(e.Start)
+}
+
+// Running returns the task's running time entry, or nil. A running entry is
+// always the task's last one.
+func (t *Task) Running() *TimeEntry {
+	if n := len(t.TimeEntries); n > 0 && t.TimeEntries[n-1].End == nil {
+		return &t.TimeEntries[n-1]
+	}
+	return nil
+}
+
+// TimeSpent totals the time logged on a task
+func (t Task) TimeSpent(now time.Time) time.Duration {
+	var total time.Duration
+	for _, e := range t.TimeEntries {
+		total += e.Duration(now)
+	}
+	return total
+}
+
+// TimeSpent totals the time logged on a quest's tasks and sub-quests
+func (q Quest) TimeSpent(now time.Time) time.Duration {
+	var total time.Duration
+	for _, t := range q.Tasks {
+		total += t.TimeSpent(now)
+	}
+	for _, sub := range q.SubQuests {
+		total += sub.TimeSpent(now)
+	}
+	return total
+}
+
+// TimeSpent totals the time logged across a project
+func (p Project) TimeSpent(now time.Time) time.Duration {
+	var total time.Duration
+	for _, q := range p.Quests {
+		total += q.TimeSpent(now)
+	}
+	return total
+}
+
+// FormatDuration renders a duration to the minute, e.g. "1h30m" or "0m"
+func FormatDuration(d time.Duration) string {
+	return FormatEstimate(int(d / time.Minute))
+}
+
+// FormatClock renders a running duration as h:mm:ss
+func FormatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// findRunningTimer searches a quest tree for the task with a running timer
+func findRunningTimer(projectID string, quests []Quest) (PlannedTask, bool) {
+	for _, q := range quests {
+		for i := range q.Tasks {
+			if q.Tasks[i].Running() != nil {
+				return PlannedTask{Task: q.Tasks[i], QuestID: q.ID, QuestTitle: q.Title, ProjectID: projectID}, true
+			}
+		}
+		if pt, ok := findRunningTimer(projectID, q.SubQuests); ok {
+			return pt, true
+		}
+	}
+	return PlannedTask{}, false
+}
+
+// RunningTimer returns the task whose timer is running, if any
+func RunningTimer(projects []Project) (PlannedTask, bool) {
+	for _, p := range projects {
+		if pt, ok := findRunningTimer(p.ID, p.Quests); ok {
+			return pt, true
+		}
+	}
+	return PlannedTask{}, false
+}
+
+// stopRunning ends the running entry of a task at now, adding note if set
+func stopRunning(t *Task, now time.Time, note string) {
+	if e := t.Running(); e != nil {
+		e.End = &now
+		if note != "" {
+			e.Note = note
+		}
+	}
+}
+
+// StartTimer starts timing a task. Only one timer runs at a time, so any
+// other running timer is stopped first.
+func (s *Store) StartTimer(taskID, note string) error {
+	loc, err := s.locateTask(taskID)
+	if err != nil {
+		return err
+	}
+	t := &loc.quest.Tasks[loc.index]
+	if t.Running() != nil {
+		return fmt.Errorf("the timer on %q is already running", t.Description)
+	}
+	projectIDs := []string{loc.project.ID}
+	running, hasRunning := RunningTimer(s.projects)
+	if hasRunning {
+		projectIDs = append(projectIDs, running.ProjectID)
+	}
+	return s.mutate(fmt.Sprintf("start timer on %q", t.Description), projectIDs, func() error {
+		now := time.Now()
+		if hasRunning {
+			other, _ := s.Task(running.Task.ID)
+			stopRunning(other, now, "")
+		}
+		t.TimeEntries = append(t.TimeEntries, TimeEntry{Start: now, Note: note})
+		return nil
+	})
+}
+
+// StopTimer stops the running timer, recording note on its entry if given,
+// and returns the task it was timing
+func (s *Store) StopTimer(note string) (Task, error) {
+	running, ok := RunningTimer(s.projects)
+	if !ok {
+		return Task{}, ErrNoTimer
+	}
+	loc, err := s.locateTask(running.Task.ID)
+	if err != nil {
+		return Task{}, err
+	}
+	t := &loc.quest.Tasks[loc.index]
+	err = s.mutate(fmt.Sprintf("stop timer on %q", t.Description), []string{loc.project.ID}, func() error {
+		stopRunning(t, time.Now(), note)
+		return nil
+	})
+	return *t, err
+}
+
+// TimesheetRow is one time entry with the task, quest and project it belongs to
+type TimesheetRow struct {
+	ProjectID   string        `json:"project_id"`
+	ProjectName string        `json:"project"`
+	QuestID     string        `json:"quest_id"`
+	QuestTitle  string        `json:"quest"`
+	TaskID      string        `json:"task_id"`
+	Task        string        `json:"task"`
+	Start       time.Time     `json:"start"`
+	End         *time.Time    `json:"end,omitempty"`
+	Duration    time.Duration `json:"-"`
+	Seconds     int64         `json:"seconds"`
+	Note        string        `json:"note,omitempty"`
+}
+
+// collectTimesheet appends the entries of a quest tree that start in [from, to)
+func collectTimesheet(p *Project, quests []Quest, from, to, now time.Time, rows *[]TimesheetRow) {
+	for _, q := range quests {
+		for _, t := range q.Tasks {
+			for _, e := range t.TimeEntries {
+				if e.Start.Before(from) || !e.Start.Before(to) {
+					continue
+				}
+				d := e.Duration(now)
+				*rows = append(*rows, TimesheetRow{
+					ProjectID: p.ID, ProjectName: p.Name, QuestID: q.ID, QuestTitle: q.Title,
+					TaskID: t.ID, Task: t.Description, Start: e.Start, End: e.End,
+					Duration: d, Seconds: int64(d / time.Second), Note: e.Note,
+				})
+			}
+		}
+		collectTimesheet(p, q.SubQuests, from, to, now, rows)
+	}
+}
+
+// Timesheet lists the time entries that started in [from, to), oldest
+// first. An empty projectID covers every project.
+func Timesheet(projects []Project, projectID string, from, to, now time.Time) []TimesheetRow {
+	var rows []TimesheetRow
+	for i := range projects {
+		if projectID == "" || projects[i].ID == projectID {
+			collectTimesheet(&projects[i], projects[i].Quests, from, to, now, &rows)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Start.Before(rows[j].Start) })
+	return rows
+}
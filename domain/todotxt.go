@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// todoDate is the date layout of todo.txt
+const todoDate = "2006-01-02"
+
+// todoPriority returns the todo.txt priority letter for a priority, A for
+// 10 down to J for 1, or "" for none
+func todoPriority(priority int) string {
+	if priority <= 0 {
+		return ""
+	}
+	if priority > 10 {
+		priority = 10
+	}
+	return string(rune('A' + 10 - priority))
+}
+
+// parseTodoPriority reads a todo.txt priority letter; K to Z count as 1
+func parseTodoPriority(letter string) int {
+	if p := 10 - int(letter[0]-'A'); p > 1 {
+		return p
+	}
+	return 1
+}
+
+// isTodoPriority reports whether a word is a priority such as "(A)"
+func isTodoPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[2] == ')' && word[1] >= 'A' && word[1] <= 'Z'
+}
+
+// isTodoDate reports whether a word is a YYYY-MM-DD date
+func isTodoDate(word string) bool {
+	_, err := time.Parse(todoDate, word)
+	return err == nil
+}
+
+// FormatTodoTxt renders a project's tasks as todo.txt lines. The task's
+// quest path is its +project, tags are @contexts, and the deadline and ID
+// are due: and id: extensions. Priorities 10 to 1 become (A) to (J), kept
+// as pri: on completed tasks. Quests without tasks are left out.
+func FormatTodoTxt(p Project) string {
+	var b strings.Builder
+	walkListTasks(p.Quests, nil, func(path []string, t Task) {
+		var words []string
+		added, done := taskTimes(&p, t.ID)
+		priority := todoPriority(t.Priority)
+		if t.Done {
+			words = append(words, "x")
+			// A completion date needs a creation date after it
+			if !added.IsZero() && !done.IsZero() {
+				words = append(words, done.Local().Format(todoDate), added.Local().Format(todoDate))
+			}
+		} else {
+			if priority != "" {
+				words = append(words, "("+priority+")")
+			}
+			if !added.IsZero() {
+				words = append(words, added.Local().Format(todoDate))
+			}
+		}
+		words = append(words, strings.Fields(t.Description)...)
+		words = append(words, "+"+strings.Join(path, "."))
+		for _, tag := range t.Tags {
+			words = append(words, "@"+tag)
+		}
+		if t.Deadline != nil {
+			words = append(words, "due:"+t.Deadline.Format(todoDate))
+		}
+		if t.Done && priority != "" {
+			words = append(words, "pri:"+priority)
+		}
+		words = append(words, "id:"+t.ID)
+		b.WriteString(strings.Join(words, " ") + "\n")
+	})
+	return b.String()
+}
+
+// todoItem is one todo.txt line split into its parts
+type todoItem struct {
+	done     bool
+	priority string   // the letter, or ""
+	projects []string // +project words without the "+"
+	contexts []string // @context words without the "@"
+	due      *time.Time
+	id       string
+	words    []string // the description
+}
+
+// parseTodoLine splits a todo.txt line. Key:value words other than due:,
+// id: and pri: stay in the description.
+func parseTodoLine(line string) (todoItem, error) {
+	var item todoItem
+	words := strings.Fields(line)
+	if len(words) > 0 && words[0] == "x" {
+		item.done = true
+		words = words[1:]
+	}
+	if len(words) > 0 && isTodoPriority(words[0]) {
+		item.priority = words[0][1:2]
+		words = words[1:]
+	}
+	// A completion date, a creation date or both; quest_line keeps neither
+	for dates := 0; dates < 2 && len(words) > 0 && isTodoDate(words[0]); dates++ {
+		words = words[1:]
+	}
+	for _, w := range words {
+		switch {
+		case len(w) > 1 && w[0] == '+':
+			item.projects = append(item.projects, w[1:])
+			continue
+		case len(w) > 1 && w[0] == '@':
+			item.contexts = append(item.contexts, w[1:])
+			continue
+		}
+		key, value, ok := strings.Cut(w, ":")
+		if ok && value != "" {
+			switch key {
+			case "due":
+				d, err := time.Parse(todoDate, value)
+				if err != nil {
+					return todoItem{}, fmt.Errorf("invalid due date %q (use YYYY-MM-DD)", value)
+				}
+				item.due = &d
+				continue
+			case "id":
+				item.id = value
+				continue
+			case "pri":
+				if len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z' {
+					if item.priority == "" {
+						item.priority = value
+					}
+					continue
+				}
+			}
+		}
+		item.words = append(item.words, w)
+	}
+	if len(item.words) == 0 {
+		return todoItem{}, fmt.Errorf("task has no description")
+	}
+	return item, nil
+}
+
+// ParseTodoTxt reads todo.txt lines as the tasks of a project to import
+// into. The first +project of a line is its quest path, tasks without one
+// go to the Inbox quest, and @contexts and further +projects become tags.
+// A task whose id: matches one in into starts from it, keeping what
+// todo.txt does not carry, such as estimates and notes.
+func ParseTodoTxt(r io.Reader, into Project) (Project, error) {
+	l := newListImport(into)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		item, err := parseTodoLine(line)
+		if err != nil {
+			return Project{}, fmt.Errorf("line %d: %w", n, err)
+		}
+		t, _ := l.existing(item.id)
+		t.ID = item.id
+		t.Description = strings.Join(item.words, " ")
+		t.Done = item.done
+		t.Deadline = item.due
+		if item.priority == "" {
+			t.Priority = 0
+		} else if todoPriority(t.Priority) != item.priority {
+			t.Priority = parseTodoPriority(item.priority)
+		}
+		var path []string
+		tags := item.contexts
+		if len(item.projects) > 0 {
+			path = splitQuestPath(item.projects[0])
+			tags = append(tags, item.projects[1:]...)
+		}
+		t.Tags = ParseTags(strings.Join(tags, " "))
+		l.add(path, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return Project{}, err
+	}
+	return l.out, nil
+}
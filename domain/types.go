@@ -8,6 +8,8 @@ const (
 	StateActive QuestState = iota
 	StateCompleted
 	StateCancelled
+	StateArchived
+	StateInProgress
 )
 
 func (s QuestState) String() string {
@@ -18,32 +20,77 @@ func (s QuestState) String() string {
 		return "Completed"
 	case StateCancelled:
 		return "Cancelled"
+	case StateArchived:
+		return "Archived"
+	case StateInProgress:
+		return "In Progress"
 	default:
 		return "Unknown"
 	}
 }
 
 type Task struct {
-	ID          string
-	Description string
-	Done        bool
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Done        bool     `json:"done"`
+	Tags        []string `json:"tags,omitempty"`
+
+	Priority int         `json:"priority,omitempty"`
+	Deadline *time.Time  `json:"deadline,omitempty"`
+	Estimate int         `json:"estimate_minutes,omitempty"` // minutes
+	Notes    string      `json:"notes,omitempty"`
+	Recur    *Recurrence `json:"recur,omitempty"`
+
+	// IDs of tasks in the same project that must be done first
+	BlockedBy []string `json:"blocked_by,omitempty"`
+
+	TimeEntries []TimeEntry `json:"time_entries,omitempty"`
 }
 
 type Quest struct {
-	ID          string
-	Title       string
-	Description string
-	Tasks       []Task
-	Progress    float64 // 0.0 → 100.0
-
-	Priority int
-	Deadline *time.Time
-	State    QuestState
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tasks       []Task   `json:"tasks"`
+	SubQuests   []Quest  `json:"sub_quests,omitempty"`
+	Progress    float64  `json:"progress"` // 0.0 → 100.0
+	Tags        []string `json:"tags,omitempty"`
+
+	Priority int         `json:"priority"`
+	Deadline *time.Time  `json:"deadline,omitempty"`
+	State    QuestState  `json:"state"`
+	Recur    *Recurrence `json:"recur,omitempty"`
+	Estimate int         `json:"estimate_minutes,omitempty"` // minutes; see Effort
+
+	// Column is the board column the quest was last moved to; see BuildBoard
+	Column string `json:"column,omitempty"`
+
+	// IDs of quests in the same project that must be finished first
+	BlockedBy []string `json:"blocked_by,omitempty"`
+
+	// Lifecycle timestamps
+	CreatedAt   *time.Time    `json:"created_at,omitempty"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	CancelledAt *time.Time    `json:"cancelled_at,omitempty"`
+	ArchivedAt  *time.Time    `json:"archived_at,omitempty"`
+	History     []StateChange `json:"history,omitempty"`
 }
 
 type Project struct {
-	ID       string
-	Name     string
-	Quests   []Quest
-	Progress float64 // 0.0 → 100.0
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Quests   []Quest `json:"quests"`
+	Progress float64 `json:"progress"` // 0.0 → 100.0
+
+	// AutoComplete completes a quest once all its tasks are done
+	AutoComplete bool `json:"auto_complete,omitempty"`
+
+	// ProgressMode is ProgressByCount (the default when empty) or ProgressByEffort
+	ProgressMode string `json:"progress_mode,omitempty"`
+
+	// BoardColumns are the project's board columns; empty uses DefaultBoardColumns
+	BoardColumns []BoardColumn `json:"board_columns,omitempty"`
+
+	// Events logs when quests and tasks were added, finished and removed
+	Events []Event `json:"events,omitempty"`
 }
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// Base XP for finishing a task or a quest, before priority and effort
+const (
+	TaskXP  = 10
+	QuestXP = 50
+)
+
+// XPFor returns the XP earned for finishing a task or quest. The base grows
+// by a tenth per priority point, and every 6 minutes of estimated effort
+// adds one more (10 XP an hour).
+func XPFor(kind string, priority, effort int) int {
+	base := TaskXP
+	if kind == KindQuest {
+		base = QuestXP
+	}
+	if priority < 0 {
+		priority = 0
+	}
+	return base*(10+priority)/10 + effort/6
+}
+
+// XPToAdvance returns the XP needed to go from level to the next one
+func XPToAdvance(level int) int {
+	return 100 * level
+}
+
+// LevelFor returns the level reached with xp, the XP earned since reaching
+// it and the XP needed for the next level
+func LevelFor(xp int) (level, into, next int) {
+	level = 1
+	for xp >= XPToAdvance(level) {
+		xp -= XPToAdvance(level)
+		level++
+	}
+	return level, xp, XPToAdvance(level)
+}
+
+// XPEvent records a completed task or quest, or an unlocked achievement. The
+// XP it is worth is derived from the recorded priority and effort (or the
+// achievement's reward), so totals can always be recomputed from the log.
+// The log belongs to the character rather than to a project, so deleting a
+// project keeps the XP earned in it.
+type XPEvent struct {
+	At        time.Time `json:"at"`
+	Kind      string    `json:"kind"` // KindTask, KindQuest or KindAchievement
+	ItemID    string    `json:"item_id"`
+	Title     string    `json:"title"`
+	ProjectID string    `json:"project_id,omitempty"` // empty for achievements
+	Priority  int       `json:"priority,omitempty"`
+	Effort    int       `json:"effort_minutes,omitempty"`
+}
+
+// XP returns the XP the event is worth
+func (e XPEvent) XP() int {
+	if e.Kind == KindAchievement {
+		a, _ := FindAchievement(e.ItemID)
+		return a.XP
+	}
+	return XPFor(e.Kind, e.Priority, e.Effort)
+}
+
+// XPLog returns the character's XP log, oldest first
+func (s *Store) XPLog() []XPEvent {
+	return s.xp
+}
+
+// Data returns the projects and the XP log for saving
+func (s *Store) Data() Data {
+	return Data{Projects: s.projects, XPLog: s.xp}
+}
+
+// SetXPLog replaces the XP log, e.g. with one loaded from disk
+func (s *Store) SetXPLog(log []XPEvent) {
+	s.xp = log
+}
+
+// award logs an XP event, recording it for the current change
+func (s *Store) award(e XPEvent) {
+	s.xp = append(s.xp, e)
+	s.xpAdded = append(s.xpAdded, e)
+}
+
+// taskAward is the log entry for completing a task in a project
+func taskAward(projectID string, t *Task, at time.Time) XPEvent {
+	return XPEvent{At: at, Kind: KindTask, ItemID: t.ID, Title: t.Description, ProjectID: projectID, Priority: t.Priority, Effort: t.Estimate}
+}
+
+// questAward is the log entry for completing a quest in a project
+func questAward(projectID string, q *Quest, at time.Time) XPEvent {
+	return XPEvent{At: at, Kind: KindQuest, ItemID: q.ID, Title: q.Title, ProjectID: projectID, Priority: q.Priority, Effort: q.Effort()}
+}
+
+// revokeXP drops the latest completion logged for an item that was
+// unchecked or reopened, so toggling never earns XP twice
+func (s *Store) revokeXP(itemID string) {
+	for i := len(s.xp) - 1; i >= 0; i-- {
+		if s.xp[i].ItemID == itemID {
+			s.xpRemoved = append(s.xpRemoved, s.xp[i])
+			s.xp = append(s.xp[:i], s.xp[i+1:]...)
+			return
+		}
+	}
+}
+
+// sameXPEvent reports whether two log entries record the same award
+func sameXPEvent(a, b XPEvent) bool {
+	return a.At.Equal(b.At) && a.Kind == b.Kind && a.ItemID == b.ItemID && a.ProjectID == b.ProjectID
+}
+
+// dropXP removes the latest entry recording e from a log
+func dropXP(log []XPEvent, e XPEvent) []XPEvent {
+	for i := len(log) - 1; i >= 0; i-- {
+		if sameXPEvent(log[i], e) {
+			return append(log[:i], log[i+1:]...)
+		}
+	}
+	return log
+}
+
+// insertXP puts e back into a log in time order, after entries of the same time
+func insertXP(log []XPEvent, e XPEvent) []XPEvent {
+	i := sort.Search(len(log), func(i int) bool { return log[i].At.After(e.At) })
+	log = append(log, XPEvent{})
+	copy(log[i+1:], log[i:])
+	log[i] = e
+	return log
+}
+
+// XPAward is a logged completion with the XP it earned
+type XPAward struct {
+	XPEvent
+	XP      int    `json:"xp"`
+	Project string `json:"project"` // the project's name, or "" once it is deleted
+}
+
+// Character sums up the XP and lifetime stats of every project
+type Character struct {
+	Level        int           `json:"level"`
+	XP           int           `json:"xp"`
+	LevelXP      int           `json:"level_xp"`      // earned since reaching Level
+	NextLevelXP  int           `json:"next_level_xp"` // needed in all for the next level
+	TasksDone    int           `json:"tasks_done"`
+	QuestsDone   int           `json:"quests_completed"`
+	Achievements int           `json:"achievements"`
+	Streak       int           `json:"streak_days"`
+	BestStreak   int           `json:"longest_streak_days"`
+	TimeLogged   time.Duration `json:"-"`
+	Seconds      int64         `json:"time_logged_seconds"`
+	Since        *time.Time    `json:"since,omitempty"` // first logged completion
+	Recent       []XPAward     `json:"recent"`          // newest first
+}
+
+// CharacterSheet builds the character from the XP log, naming the project
+// each award was earned in, and lists up to recent of the latest awards
+func CharacterSheet(projects []Project, log []XPEvent, now time.Time, recent int) Character {
+	var c Character
+	names := make(map[string]string, len(projects))
+	for _, p := range projects {
+		names[p.ID] = p.Name
+		c.TimeLogged += p.TimeSpent(now)
+	}
+	awards := make([]XPAward, 0, len(log))
+	for _, e := range log {
+		awards = append(awards, XPAward{XPEvent: e, XP: e.XP(), Project: names[e.ProjectID]})
+		c.XP += e.XP()
+		switch e.Kind {
+		case KindTask:
+			c.TasksDone++
+		case KindQuest:
+			c.QuestsDone++
+		case KindAchievement:
+			c.Achievements++
+		}
+	}
+	c.Seconds = int64(c.TimeLogged / time.Second)
+	c.Level, c.LevelXP, c.NextLevelXP = LevelFor(c.XP)
+	c.Streak, c.BestStreak = Streak(log, now)
+	sort.SliceStable(awards, func(i, j int) bool { return awards[i].At.After(awards[j].At) })
+	if len(awards) > 0 {
+		since := awards[len(awards)-1].At
+		c.Since = &since
+	}
+	if recent < 0 {
+		recent = 0
+	}
+	if len(awards) > recent {
+		awards = awards[:recent]
+	}
+	c.Recent = awards
+	return c
+}
@@ -1,14 +1,47 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"quest_line/cli"
+	"quest_line/config"
+	"quest_line/domain"
 	"quest_line/tui"
 )
 
 func main() {
-	model := tui.InitialModel()
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "quest_line:", err)
+		os.Exit(1)
+	}
+}
+
+// run starts a headless subcommand when one is given, or the TUI otherwise
+func run(args []string) error {
+	cfg, args, err := config.Parse(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	storage, err := domain.OpenStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	if len(args) > 0 {
+		return cli.Run(storage, cfg.UndoFile, cfg.Activity, args, os.Stdout)
+	}
+
+	model, err := tui.InitialModel(storage, cfg.UndoFile, cfg.Activity)
+	if err != nil {
+		return err
+	}
 	program := tea.NewProgram(&model, tea.WithAltScreen())
-	if _, err := program.Run(); err != nil {
-		panic(err)
+	_, err = program.Run()
+	if flushErr := model.Flush(); err == nil {
+		err = flushErr
 	}
+	return err
 }
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"quest_line/domain"
+)
+
+// AchievementsModel lists every achievement with its progress
+type AchievementsModel struct {
+	achievements []domain.AchievementStatus
+	streak       int
+	bestStreak   int
+}
+
+// NewAchievementsModel builds the achievement list from the XP log
+func NewAchievementsModel(projects []domain.Project, xpLog []domain.XPEvent) AchievementsModel {
+	now := time.Now()
+	streak, best := domain.Streak(xpLog, now)
+	return AchievementsModel{
+		achievements: domain.AchievementProgress(projects, xpLog, now),
+		streak:       streak,
+		bestStreak:   best,
+	}
+}
+
+// View renders the achievements, unlocked ones first
+func (m AchievementsModel) View() string {
+	var b strings.Builder
+
+	unlocked := 0
+	for _, a := range m.achievements {
+		if a.UnlockedAt != nil {
+			unlocked++
+		}
+	}
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Achievements (%d/%d)", unlocked, len(m.achievements))))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Streak: %s (best %d)\n\n", domain.FormatStreak(m.streak), m.bestStreak))
+
+	for _, a := range m.achievements {
+		if a.UnlockedAt == nil {
+			continue
+		}
+		b.WriteString(timerStyle.Render("✓ "+a.Name) + " - " + a.Description)
+		b.WriteString(fmt.Sprintf("  +%d XP  %s\n", a.XP, a.UnlockedAt.Local().Format("2006-01-02")))
+	}
+	for _, a := range m.achievements {
+		if a.UnlockedAt != nil {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  %s - %s  %d/%d\n", a.Name, a.Description, a.Progress, a.Target))
+	}
+	return b.String()
+}
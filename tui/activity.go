@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"quest_line/domain"
+)
+
+// ActivityModel browses the activity log of a project or quest, newest
+// entry first, with the changes of the selected entry below the list
+type ActivityModel struct {
+	entries  []domain.Activity // newest first
+	scope    string            // what the entries are about, for the title
+	selected int
+	height   int
+}
+
+// NewActivityModel lists entries, given oldest first as the log stores them
+func NewActivityModel(entries []domain.Activity, scope string, height int) ActivityModel {
+	m := ActivityModel{scope: scope, height: height}
+	for i := len(entries) - 1; i >= 0; i-- {
+		m.entries = append(m.entries, entries[i])
+	}
+	return m
+}
+
+// Update moves the selection
+func (m ActivityModel) Update(msg tea.Msg) (ActivityModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down", "j":
+			if m.selected < len(m.entries)-1 {
+				m.selected++
+			}
+		case "home":
+			m.selected = 0
+		case "end":
+			if len(m.entries) > 0 {
+				m.selected = len(m.entries) - 1
+			}
+		}
+	}
+	return m, nil
+}
+
+// visibleRows returns the first entry shown and how many fit, keeping the
+// selected entry in view
+func (m ActivityModel) visibleRows() (first, count int) {
+	count = len(m.entries)
+	// Leave room for the title, the selected entry's changes, status and help
+	fit := 15
+	if m.height > 0 {
+		fit = m.height / 2
+	}
+	if fit < 3 {
+		fit = 3
+	}
+	if count <= fit {
+		return 0, count
+	}
+	if m.selected >= fit {
+		first = m.selected - fit + 1
+	}
+	return first, fit
+}
+
+// View renders the entries and the changes of the selected one
+func (m ActivityModel) View() string {
+	var b strings.Builder
+
+	title := "Activity"
+	if m.scope != "" {
+		title += " - " + m.scope
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n\n")
+	if len(m.entries) == 0 {
+		b.WriteString("No activity recorded yet.\n")
+		return b.String()
+	}
+
+	first, count := m.visibleRows()
+	if first > 0 {
+		b.WriteString(searchPathStyle.Render(fmt.Sprintf("↑ %d newer", first)) + "\n")
+	}
+	for i := first; i < first+count; i++ {
+		a := m.entries[i]
+		line := fmt.Sprintf("%s  %-10s %s", a.At.Local().Format("2006-01-02 15:04"), truncate(a.Actor, 10), a.Summary())
+		if i == m.selected {
+			b.WriteString(selectedStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	if rest := len(m.entries) - first - count; rest > 0 {
+		b.WriteString(searchPathStyle.Render(fmt.Sprintf("↓ %d older", rest)) + "\n")
+	}
+
+	a := m.entries[m.selected]
+	b.WriteString("\n" + columnHeaderStyle.Render(a.Action) + "\n")
+	b.WriteString(searchPathStyle.Render(fmt.Sprintf("%s %s by %s at %s", a.Kind, a.ID, a.Actor, a.At.Local().Format("2006-01-02 15:04:05"))) + "\n")
+	for _, c := range a.Changes {
+		b.WriteString("  " + c.String() + "\n")
+	}
+	return b.String()
+}
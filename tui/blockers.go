@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"quest_line/domain"
+)
+
+// blockerChoice is a quest or task that could block the item being edited
+type blockerChoice struct {
+	id    string
+	label string
+	open  bool // an active quest or a task not yet done
+}
+
+// BlockerPickerModel lists the quests or tasks of a project so the ones
+// blocking a quest or task can be ticked off
+type BlockerPickerModel struct {
+	kind        string // domain.KindQuest or domain.KindTask
+	itemID      string
+	itemName    string
+	blockedBy   []string
+	choices     []blockerChoice
+	selectedIdx int
+}
+
+// NewBlockerPickerModel creates a picker for the blockers of a quest or task
+func NewBlockerPickerModel(store *domain.Store, kind, itemID string) BlockerPickerModel {
+	m := BlockerPickerModel{kind: kind, itemID: itemID}
+	var project *domain.Project
+	if kind == domain.KindTask {
+		t, err := store.Task(itemID)
+		if err != nil {
+			return m
+		}
+		m.itemName, m.blockedBy = t.Description, t.BlockedBy
+		q, _ := store.TaskQuest(itemID)
+		project, _ = store.QuestProject(q.ID)
+	} else {
+		q, err := store.Quest(itemID)
+		if err != nil {
+			return m
+		}
+		m.itemName, m.blockedBy = q.Title, q.BlockedBy
+		project, _ = store.QuestProject(itemID)
+	}
+	if project != nil {
+		m.collectChoices(project.Quests, "")
+	}
+	return m
+}
+
+// collectChoices lists every quest or task in a tree except the item itself
+func (m *BlockerPickerModel) collectChoices(quests []domain.Quest, path string) {
+	for _, q := range quests {
+		questPath := q.Title
+		if path != "" {
+			questPath = path + " › " + q.Title
+		}
+		if m.kind == domain.KindQuest && q.ID != m.itemID {
+			m.choices = append(m.choices, blockerChoice{id: q.ID, label: questPath, open: q.State.IsOpen()})
+		}
+		if m.kind == domain.KindTask {
+			for _, t := range q.Tasks {
+				if t.ID != m.itemID {
+					m.choices = append(m.choices, blockerChoice{id: t.ID, label: questPath + " › " + t.Description, open: !t.Done})
+				}
+			}
+		}
+		m.collectChoices(q.SubQuests, questPath)
+	}
+}
+
+// Update moves the cursor
+func (m BlockerPickerModel) Update(msg tea.Msg) (BlockerPickerModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		k := msg.String()
+		if k == "k" || k == "up" {
+			if m.selectedIdx > 0 {
+				m.selectedIdx--
+			}
+		} else if k == "j" || k == "down" {
+			if m.selectedIdx < len(m.choices)-1 {
+				m.selectedIdx++
+			}
+		}
+	}
+	return m, nil
+}
+
+// Selected returns the ID under the cursor and whether it already blocks the item
+func (m BlockerPickerModel) Selected() (string, bool) {
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.choices) {
+		return "", false
+	}
+	id := m.choices[m.selectedIdx].id
+	for _, blockerID := range m.blockedBy {
+		if blockerID == id {
+			return id, true
+		}
+	}
+	return id, false
+}
+
+// View renders the checklist of possible blockers
+func (m BlockerPickerModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Blocked By: " + m.itemName))
+	b.WriteString("\n\n")
+
+	if len(m.choices) == 0 {
+		fmt.Fprintf(&b, "No other %ss in this project.\n", m.kind)
+		return b.String()
+	}
+	blocking := make(map[string]bool, len(m.blockedBy))
+	for _, id := range m.blockedBy {
+		blocking[id] = true
+	}
+	for i, c := range m.choices {
+		box := "[ ]"
+		if blocking[c.id] {
+			box = "[x]"
+		}
+		line := box + " " + c.label
+		if !c.open {
+			line += " ✓"
+		}
+		if i == m.selectedIdx {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
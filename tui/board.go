@@ -0,0 +1,258 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"quest_line/domain"
+)
+
+const (
+	// defaultBoardWidth is used until the terminal reports its size
+	defaultBoardWidth = 80
+	// minColumnWidth is the narrowest a column gets before the board scrolls sideways
+	minColumnWidth = 20
+	// cardHeight is the usual number of lines a card takes, border included
+	cardHeight = 6
+)
+
+// BoardModel shows a project's quests as cards in its board columns
+type BoardModel struct {
+	projectID   string
+	projectName string
+	board       domain.Board
+	col, row    int // cursor: column and card within it
+	width       int
+	height      int
+}
+
+// NewBoardModel builds the board of a project sized for a width x height terminal
+func NewBoardModel(projects []domain.Project, projectID string, width, height int) BoardModel {
+	m := BoardModel{projectID: projectID, width: width, height: height}
+	for _, p := range projects {
+		if p.ID == projectID {
+			m.projectName = p.Name
+			m.board = domain.BuildBoard(p, time.Now())
+		}
+	}
+	return m
+}
+
+// Update moves the cursor between columns and cards
+func (m BoardModel) Update(msg tea.Msg) (BoardModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "left":
+			if m.col > 0 {
+				m.col--
+			}
+		case "right":
+			if m.col < len(m.board.Columns)-1 {
+				m.col++
+			}
+		case "up", "k":
+			if m.row > 0 {
+				m.row--
+			}
+		case "down", "j":
+			m.row++
+		}
+		m.clamp()
+	}
+	return m, nil
+}
+
+// clamp keeps the cursor on an existing column and card
+func (m *BoardModel) clamp() {
+	if m.col >= len(m.board.Columns) {
+		m.col = len(m.board.Columns) - 1
+	}
+	if m.col < 0 {
+		m.col = 0
+		m.row = 0
+		return
+	}
+	if n := len(m.board.Cards[m.col]); m.row >= n {
+		m.row = n - 1
+	}
+	if m.row < 0 {
+		m.row = 0
+	}
+}
+
+// SelectedQuestID returns the ID of the quest under the cursor
+func (m BoardModel) SelectedQuestID() string {
+	if m.col < 0 || m.col >= len(m.board.Cards) || m.row >= len(m.board.Cards[m.col]) {
+		return ""
+	}
+	return m.board.Cards[m.col][m.row].ID
+}
+
+// NeighbourColumn returns the name of the column delta steps from the cursor
+func (m BoardModel) NeighbourColumn(delta int) (string, bool) {
+	i := m.col + delta
+	if i < 0 || i >= len(m.board.Columns) {
+		return "", false
+	}
+	return m.board.Columns[i].Name, true
+}
+
+// Follow puts the cursor on a quest's card, wherever it now is
+func (m *BoardModel) Follow(questID string) {
+	for c, cards := range m.board.Cards {
+		for r, card := range cards {
+			if card.ID == questID {
+				m.col, m.row = c, r
+				return
+			}
+		}
+	}
+	m.clamp()
+}
+
+// layout returns how many columns fit the width, their width and the first
+// one shown so the cursor stays visible
+func (m BoardModel) layout() (visible, width, first int) {
+	available := m.width
+	if available <= 0 {
+		available = defaultBoardWidth
+	}
+	// appStyle pads the screen by 2 cells on each side
+	available -= 4
+	visible = available / minColumnWidth
+	if visible < 1 {
+		visible = 1
+	}
+	if visible > len(m.board.Columns) {
+		visible = len(m.board.Columns)
+	}
+	width = available / visible
+	if m.col >= visible {
+		first = m.col - visible + 1
+	}
+	return visible, width, first
+}
+
+// visibleCards returns how many cards of a column fit the terminal height
+// and the first one shown, or every card when the height is unknown
+func (m BoardModel) visibleCards(col int) (count, first int) {
+	count = len(m.board.Cards[col])
+	if m.height <= 0 {
+		return count, 0
+	}
+	// Leave room for the title, column headers, status lines and help
+	fit := (m.height - 12) / cardHeight
+	if fit < 1 {
+		fit = 1
+	}
+	if count <= fit {
+		return count, 0
+	}
+	if col == m.col && m.row >= fit {
+		first = m.row - fit + 1
+	}
+	return fit, first
+}
+
+// truncate shortens s to at most width cells, marking the cut with "…"
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// renderCard draws one card as a box width cells wide
+func renderCard(card domain.Card, width int, selected bool) string {
+	inner := width - 4 // border and padding
+	if inner < 4 {
+		inner = 4
+	}
+	lines := []string{truncate(card.Title, inner)}
+	if card.Path != "" {
+		lines = append(lines, searchPathStyle.Render(truncate(card.Path, inner)))
+	}
+	percent := fmt.Sprintf(" %3.0f%%", card.Progress)
+	barWidth := inner - len(percent)
+	if barWidth < 1 {
+		barWidth = 1
+	}
+	lines = append(lines, timerStyle.Render(xpBar(int(card.Progress), 100, barWidth))+percent)
+	if card.Deadline != nil {
+		due := "Due " + card.Deadline.Format("2006-01-02")
+		if card.Overdue {
+			due = errorStyle.Render(due + " overdue")
+		}
+		lines = append(lines, due)
+	}
+	style := cardStyle.Width(width - 2)
+	if selected {
+		style = style.BorderForeground(lipgloss.Color("#d90368"))
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// View renders the visible columns side by side
+func (m BoardModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Board - " + m.projectName))
+	b.WriteString("\n\n")
+	if m.projectName == "" {
+		b.WriteString("Select a project to see its board.\n")
+		return b.String()
+	}
+
+	visible, width, first := m.layout()
+	columns := make([]string, 0, visible)
+	for c := first; c < first+visible; c++ {
+		column := m.board.Columns[c]
+		cards := m.board.Cards[c]
+		header := truncate(fmt.Sprintf("%s (%d)", column.Name, len(cards)), width-1)
+		if c == m.col {
+			header = selectedStyle.Render(header)
+		} else {
+			header = columnHeaderStyle.Render(header)
+		}
+		parts := []string{header}
+		count, from := m.visibleCards(c)
+		if from > 0 {
+			parts = append(parts, searchPathStyle.Render(fmt.Sprintf("↑ %d more", from)))
+		}
+		for r := from; r < from+count; r++ {
+			parts = append(parts, renderCard(cards[r], width-1, c == m.col && r == m.row))
+		}
+		if rest := len(cards) - from - count; rest > 0 {
+			parts = append(parts, searchPathStyle.Render(fmt.Sprintf("↓ %d more", rest)))
+		}
+		if len(cards) == 0 {
+			parts = append(parts, searchPathStyle.Render("(empty)"))
+		}
+		columns = append(columns, lipgloss.NewStyle().Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, parts...)))
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+	b.WriteString("\n")
+	if hidden := len(m.board.Columns) - visible; hidden > 0 {
+		b.WriteString(searchPathStyle.Render(fmt.Sprintf("Columns %d-%d of %d; widen the terminal to see more", first+1, first+visible, len(m.board.Columns))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Styling for the board
+var (
+	cardStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("241")).
+			Padding(0, 1)
+
+	columnHeaderStyle = lipgloss.NewStyle().
+				Bold(true)
+)
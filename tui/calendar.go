@@ -0,0 +1,299 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"quest_line/domain"
+)
+
+// monthCellItems is the most items a day of the month grid lists
+const monthCellItems = 2
+
+// calendarCursor is the part of the calendar kept when it is rebuilt
+type calendarCursor struct {
+	day    time.Time // selected day, midnight UTC like deadlines
+	week   bool      // week view instead of month view
+	inList bool      // moving through the selected day's items
+	item   int
+	moving string // ID of the item being rescheduled, if any
+}
+
+// CalendarModel places quest and task deadlines on a month or week grid
+type CalendarModel struct {
+	calendarCursor
+	projectName string
+	items       map[time.Time][]domain.CalendarItem
+	titles      map[string]string // item ID to title, for the rescheduling banner
+	today       time.Time
+	width       int
+	height      int
+}
+
+// NewCalendarModel gathers the deadlines of a project, or of every project
+// when projectID is empty
+func NewCalendarModel(projects []domain.Project, projectID string, width, height int) CalendarModel {
+	now := time.Now()
+	m := CalendarModel{
+		items:  make(map[time.Time][]domain.CalendarItem),
+		titles: make(map[string]string),
+		today:  domain.Day(now),
+		width:  width,
+		height: height,
+	}
+	m.day = m.today
+	for _, p := range projects {
+		if p.ID == projectID {
+			m.projectName = p.Name
+		}
+	}
+	for _, item := range domain.CalendarItems(projects, projectID, time.Time{}, time.Time{}, now) {
+		m.items[item.Due] = append(m.items[item.Due], item)
+		m.titles[item.ID] = item.Title
+	}
+	return m
+}
+
+// Update moves the selected day, or the selected item of the day
+func (m CalendarModel) Update(msg tea.Msg) (CalendarModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.inList {
+		switch keyMsg.String() {
+		case "up", "k":
+			if m.item > 0 {
+				m.item--
+			}
+		case "down", "j":
+			if m.item < len(m.items[m.day])-1 {
+				m.item++
+			}
+		}
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "left":
+		m.day = m.day.AddDate(0, 0, -1)
+	case "right":
+		m.day = m.day.AddDate(0, 0, 1)
+	case "up", "k":
+		m.day = m.day.AddDate(0, 0, -7)
+	case "down", "j":
+		m.day = m.day.AddDate(0, 0, 7)
+	case "[":
+		if m.week {
+			m.day = m.day.AddDate(0, 0, -7)
+		} else {
+			m.day = addMonths(m.day, -1)
+		}
+	case "]":
+		if m.week {
+			m.day = m.day.AddDate(0, 0, 7)
+		} else {
+			m.day = addMonths(m.day, 1)
+		}
+	case ".":
+		m.day = m.today
+	case "v":
+		m.week = !m.week
+	}
+	return m, nil
+}
+
+// Selected returns the item under the cursor of the day's list
+func (m CalendarModel) Selected() (domain.CalendarItem, bool) {
+	items := m.items[m.day]
+	if !m.inList || m.item < 0 || m.item >= len(items) {
+		return domain.CalendarItem{}, false
+	}
+	return items[m.item], true
+}
+
+// EnterList moves the cursor into the selected day's items, if it has any
+func (m *CalendarModel) EnterList() bool {
+	if len(m.items[m.day]) == 0 {
+		return false
+	}
+	m.inList, m.item = true, 0
+	return true
+}
+
+// Follow selects an item on its new day after it was rescheduled
+func (m *CalendarModel) Follow(id string, day time.Time) {
+	m.day = domain.Day(day)
+	for i, item := range m.items[m.day] {
+		if item.ID == id {
+			m.inList, m.item = true, i
+			return
+		}
+	}
+	m.inList = false
+}
+
+// addMonths moves day by months, keeping to the last day of shorter months
+func addMonths(day time.Time, months int) time.Time {
+	first := time.Date(day.Year(), day.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1); day.Day() > last.Day() {
+		return last
+	}
+	return first.AddDate(0, 0, day.Day()-1)
+}
+
+// cellWidth returns the width of a day column for the terminal width
+func (m CalendarModel) cellWidth() int {
+	width := m.width
+	if width <= 0 {
+		width = defaultBoardWidth
+	}
+	// appStyle pads the screen by 2 cells on each side
+	if w := (width - 4) / 7; w > 6 {
+		return w
+	}
+	return 6
+}
+
+// cellItems returns how many items the days of a month grid of weeks rows
+// list, fewer than monthCellItems when the terminal is short
+func (m CalendarModel) cellItems(weeks int) int {
+	if m.height <= 0 {
+		return monthCellItems
+	}
+	// Leave room for the title, weekday header, the day's list and help
+	n := (m.height-16)/weeks - 1
+	if n > monthCellItems {
+		return monthCellItems
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// renderItem renders a calendar entry, marking finished and overdue ones
+func renderItem(item domain.CalendarItem, width int) string {
+	text := item.Title
+	if item.Kind == domain.KindQuest {
+		text = "◆ " + text
+	}
+	text = truncate(text, width)
+	switch {
+	case item.Done:
+		return searchPathStyle.Render(text)
+	case item.Overdue:
+		return errorStyle.Render(text)
+	}
+	return text
+}
+
+// renderDay renders the cell of one day listing up to limit items, or all
+// of them when limit is negative
+func (m CalendarModel) renderDay(day time.Time, month time.Month, width, limit int) string {
+	label := fmt.Sprintf("%2d", day.Day())
+	if m.week {
+		label = day.Format("Mon 2")
+	}
+	if day.Equal(m.today) {
+		label += " today"
+	}
+	switch {
+	case day.Equal(m.day):
+		label = selectedStyle.Render(label)
+	case day.Equal(m.today):
+		label = timerStyle.Render(label)
+	case day.Month() != month:
+		label = searchPathStyle.Render(label)
+	}
+	lines := []string{label}
+	items := m.items[day]
+	for i, item := range items {
+		if limit >= 0 && i == limit && len(items) > limit {
+			lines = append(lines, searchPathStyle.Render(fmt.Sprintf("+%d more", len(items)-limit)))
+			break
+		}
+		lines = append(lines, renderItem(item, width-1))
+	}
+	height := limit + 2
+	if limit < 0 {
+		height = 0
+	}
+	return lipgloss.NewStyle().Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// View renders the grid and the selected day's items
+func (m CalendarModel) View() string {
+	var b strings.Builder
+
+	title := "Calendar"
+	if m.projectName != "" {
+		title += " - " + m.projectName
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	width := m.cellWidth()
+	start := domain.WeekStart(m.day)
+	weeks := 1
+	if m.week {
+		b.WriteString(fmt.Sprintf("Week of %s\n\n", start.Format("January 2, 2006")))
+	} else {
+		first := time.Date(m.day.Year(), m.day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		start = domain.WeekStart(first)
+		last := first.AddDate(0, 1, -1)
+		weeks = int(last.Sub(start).Hours()/24)/7 + 1
+		b.WriteString(m.day.Format("January 2006") + "\n")
+		var header []string
+		for i := 0; i < 7; i++ {
+			header = append(header, lipgloss.NewStyle().Width(width).Render(start.AddDate(0, 0, i).Format("Mon")))
+		}
+		b.WriteString(columnHeaderStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, header...)) + "\n")
+	}
+	for w := 0; w < weeks; w++ {
+		var cells []string
+		for i := 0; i < 7; i++ {
+			day := start.AddDate(0, 0, 7*w+i)
+			limit := m.cellItems(weeks)
+			if m.week {
+				limit = -1
+			}
+			cells = append(cells, m.renderDay(day, m.day.Month(), width, limit))
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, cells...) + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.moving != "" {
+		b.WriteString(timerStyle.Render(fmt.Sprintf("Moving %q: pick a day and press enter (esc to cancel)", m.titles[m.moving])))
+		b.WriteString("\n")
+	}
+	b.WriteString(columnHeaderStyle.Render(m.day.Format("Monday, January 2, 2006")) + "\n")
+	items := m.items[m.day]
+	if len(items) == 0 {
+		b.WriteString("Nothing due.\n")
+	}
+	for i, item := range items {
+		line := item.Title
+		if item.Kind == domain.KindTask {
+			line = fmt.Sprintf("%s (%s)", item.Title, item.QuestTitle)
+		} else {
+			line = "◆ " + line
+		}
+		switch {
+		case item.Done:
+			line += " - done"
+		case item.Overdue:
+			line += " - overdue"
+		}
+		if m.inList && i == m.item {
+			line = selectedStyle.Render(line)
+		} else if item.Overdue {
+			line = errorStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"quest_line/domain"
+)
+
+// recentAwards is how many XP awards the character screen lists
+const recentAwards = 10
+
+// CharacterModel shows the level, XP and lifetime stats earned across all projects
+type CharacterModel struct {
+	character domain.Character
+}
+
+// NewCharacterModel builds the character sheet from the XP log
+func NewCharacterModel(projects []domain.Project, xpLog []domain.XPEvent) CharacterModel {
+	return CharacterModel{character: domain.CharacterSheet(projects, xpLog, time.Now(), recentAwards)}
+}
+
+// xpBar renders the XP earned towards the next level as a bar of width cells
+func xpBar(into, next, width int) string {
+	filled := 0
+	if next > 0 {
+		filled = into * width / next
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// View renders the character sheet
+func (m CharacterModel) View() string {
+	var b strings.Builder
+	c := m.character
+
+	b.WriteString(titleStyle.Render("Character"))
+	b.WriteString("\n\n")
+
+	b.WriteString(fmt.Sprintf("Level %d  %s  %d/%d XP\n", c.Level, timerStyle.Render(xpBar(c.LevelXP, c.NextLevelXP, 20)), c.LevelXP, c.NextLevelXP))
+	b.WriteString(fmt.Sprintf("%d XP to level %d\n\n", c.NextLevelXP-c.LevelXP, c.Level+1))
+
+	b.WriteString(fmt.Sprintf("Total XP: %d\n", c.XP))
+	b.WriteString(fmt.Sprintf("Tasks done: %d | Quests completed: %d | Achievements: %d\n", c.TasksDone, c.QuestsDone, c.Achievements))
+	b.WriteString(fmt.Sprintf("Streak: %s (best %d)\n", domain.FormatStreak(c.Streak), c.BestStreak))
+	b.WriteString("Time logged: " + domain.FormatDuration(c.TimeLogged) + "\n")
+	if c.Since != nil {
+		b.WriteString("Adventuring since: " + c.Since.Local().Format("2006-01-02") + "\n")
+	}
+
+	b.WriteString("\nRecent XP:\n")
+	if len(c.Recent) == 0 {
+		b.WriteString("Complete a task or quest to earn XP.\n")
+		return b.String()
+	}
+	for _, award := range c.Recent {
+		// Achievements and awards from deleted projects have no project to name
+		project := ""
+		if award.Project != "" {
+			project = " (" + award.Project + ")"
+		}
+		b.WriteString(fmt.Sprintf("  +%d XP  %s %q%s  %s\n", award.XP, award.Kind, award.Title, project, award.At.Local().Format("2006-01-02 15:04")))
+	}
+	return b.String()
+}
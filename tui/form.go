@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -20,6 +21,14 @@ type FormModel struct {
 	labels    []string
 	fieldInfo map[string]string // store processed field values
 	errorMsg  string
+
+	// Tag autocomplete
+	tagIdx     int // index of the tags input, or -1
+	tagChoices []string
+
+	// Multi-line notes, shown in place of inputs[notesIdx]
+	notes    textarea.Model
+	notesIdx int // -1 when the form has no notes field
 }
 
 // NewProjectForm creates a new form for project creation/editing
@@ -37,12 +46,15 @@ func NewProjectForm(title string, initialName string) FormModel {
 		title:     title,
 		labels:    []string{"Name:"},
 		fieldInfo: make(map[string]string),
+		tagIdx:    -1,
+		notesIdx:  -1,
 	}
 }
 
-// NewQuestForm creates a new form for quest creation/editing
-func NewQuestForm(title string, initial *domain.Quest) FormModel {
-	inputs := make([]textinput.Model, 4)
+// NewQuestForm creates a new form for quest creation/editing. Tags
+// autocomplete from tagChoices.
+func NewQuestForm(title string, initial *domain.Quest, tagChoices []string) FormModel {
+	inputs := make([]textinput.Model, 7)
 	for i := range inputs {
 		inputs[i] = textinput.New()
 	}
@@ -52,6 +64,9 @@ func NewQuestForm(title string, initial *domain.Quest) FormModel {
 	inputs[1].Placeholder = "Description"
 	inputs[2].Placeholder = "Priority (0-10)"
 	inputs[3].Placeholder = "Deadline (YYYY-MM-DD)"
+	inputs[4].Placeholder = "Estimate (e.g. 4h); blank adds up the tasks"
+	inputs[5].Placeholder = repeatPlaceholder
+	inputs[6].Placeholder = "tag, another-tag"
 
 	if initial != nil {
 		inputs[0].SetValue(initial.Title)
@@ -60,34 +75,108 @@ func NewQuestForm(title string, initial *domain.Quest) FormModel {
 		if initial.Deadline != nil {
 			inputs[3].SetValue(initial.Deadline.Format("2006-01-02"))
 		}
+		if initial.Estimate != 0 {
+			inputs[4].SetValue(domain.FormatEstimate(initial.Estimate))
+		}
+		if initial.Recur != nil {
+			inputs[5].SetValue(initial.Recur.String())
+		}
+		inputs[6].SetValue(domain.FormatTags(initial.Tags))
 	}
 
-	return FormModel{
-		inputs:    inputs,
-		focusIdx:  0,
-		formType:  ViewCreateQuest,
-		title:     title,
-		labels:    []string{"Title:", "Description:", "Priority (0-10):", "Deadline (YYYY-MM-DD):"},
-		fieldInfo: make(map[string]string),
+	f := FormModel{
+		inputs:     inputs,
+		focusIdx:   0,
+		formType:   ViewCreateQuest,
+		title:      title,
+		labels:     []string{"Title:", "Description:", "Priority (0-10):", "Deadline (YYYY-MM-DD):", "Estimate:", "Repeat:", "Tags:"},
+		fieldInfo:  make(map[string]string),
+		tagIdx:     6,
+		tagChoices: tagChoices,
+		notesIdx:   -1,
 	}
+	f.updateTagSuggestions()
+	return f
 }
 
-// NewTaskForm creates a new form for task creation/editing
-func NewTaskForm(title string, initialDesc string) FormModel {
-	inputs := make([]textinput.Model, 1)
-	inputs[0] = textinput.New()
+// NewTaskForm creates a new form for task creation/editing. Tags
+// autocomplete from tagChoices.
+func NewTaskForm(title string, initial *domain.Task, tagChoices []string) FormModel {
+	inputs := make([]textinput.Model, 7)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+	}
 	inputs[0].Placeholder = "Task Description"
-	inputs[0].SetValue(initialDesc)
 	inputs[0].Focus()
+	inputs[1].Placeholder = "Priority (0-10)"
+	inputs[2].Placeholder = "Deadline (YYYY-MM-DD)"
+	inputs[3].Placeholder = "Estimate (e.g. 45m, 1h30m)"
+	inputs[4].Placeholder = repeatPlaceholder
+	inputs[6].Placeholder = "tag, another-tag"
 
-	return FormModel{
-		inputs:    inputs,
-		focusIdx:  0,
-		formType:  ViewCreateTask,
-		title:     title,
-		labels:    []string{"Description:"},
-		fieldInfo: make(map[string]string),
+	notes := textarea.New()
+	notes.Placeholder = "Notes (enter adds a line, tab moves on)"
+	notes.ShowLineNumbers = false
+	notes.SetWidth(60)
+	notes.SetHeight(4)
+
+	if initial != nil {
+		inputs[0].SetValue(initial.Description)
+		if initial.Priority != 0 {
+			inputs[1].SetValue(strconv.Itoa(initial.Priority))
+		}
+		if initial.Deadline != nil {
+			inputs[2].SetValue(initial.Deadline.Format("2006-01-02"))
+		}
+		if initial.Estimate != 0 {
+			inputs[3].SetValue(domain.FormatEstimate(initial.Estimate))
+		}
+		if initial.Recur != nil {
+			inputs[4].SetValue(initial.Recur.String())
+		}
+		notes.SetValue(initial.Notes)
+		inputs[6].SetValue(domain.FormatTags(initial.Tags))
 	}
+
+	f := FormModel{
+		inputs:     inputs,
+		focusIdx:   0,
+		formType:   ViewCreateTask,
+		title:      title,
+		labels:     []string{"Description:", "Priority (0-10):", "Deadline (YYYY-MM-DD):", "Estimate:", "Repeat:", "Notes:", "Tags:"},
+		fieldInfo:  make(map[string]string),
+		tagIdx:     6,
+		tagChoices: tagChoices,
+		notes:      notes,
+		notesIdx:   5,
+	}
+	f.updateTagSuggestions()
+	return f
+}
+
+// repeatPlaceholder lists example repeat rules
+const repeatPlaceholder = "daily, weekly on mon,thu, monthly on 15, after 7 days"
+
+// NewTagFilterForm creates the one-field form for a dashboard tag filter
+func NewTagFilterForm(current string, tagChoices []string) FormModel {
+	input := textinput.New()
+	input.Placeholder = "+tag -other-tag"
+	input.SetValue(current)
+	input.Focus()
+
+	f := FormModel{
+		inputs:     []textinput.Model{input},
+		focusIdx:   0,
+		formType:   ViewTagFilter,
+		title:      "Filter by Tags",
+		labels:     []string{"Tags:"},
+		fieldInfo:  make(map[string]string),
+		tagIdx:     0,
+		tagChoices: tagChoices,
+		notesIdx:   -1,
+	}
+	f.updateTagSuggestions()
+	return f
 }
 
 // Update handles form input
@@ -98,10 +187,19 @@ func (f FormModel) Update(msg tea.Msg) (FormModel, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab":
+			// Tab completes a tag first, and only then moves on
+			if f.focusIdx == f.tagIdx && f.hasTagCompletion() {
+				f.inputs[f.focusIdx], cmd = f.inputs[f.focusIdx].Update(msg)
+				f.updateTagSuggestions()
+				return f, cmd
+			}
 			f.nextInput()
 		case "shift+tab":
 			f.prevInput()
 		case "enter":
+			if f.InNotes() {
+				break // a new line in the notes
+			}
 			if f.focusIdx == len(f.inputs)-1 {
 				// Submit form
 				return f, nil
@@ -110,10 +208,63 @@ func (f FormModel) Update(msg tea.Msg) (FormModel, tea.Cmd) {
 		}
 	}
 
+	if f.InNotes() {
+		f.notes, cmd = f.notes.Update(msg)
+		return f, cmd
+	}
 	f.inputs[f.focusIdx], cmd = f.inputs[f.focusIdx].Update(msg)
+	if f.focusIdx == f.tagIdx {
+		f.updateTagSuggestions()
+	}
 	return f, cmd
 }
 
+// tagPrefix splits the tags input into what comes before the tag being
+// typed and the partial tag itself
+func tagPrefix(value string) (string, string) {
+	i := strings.LastIndexAny(value, ", ")
+	return value[:i+1], value[i+1:]
+}
+
+// updateTagSuggestions offers each unused existing tag as a completion of
+// the tag being typed. Suggestions are whole values because textinput
+// matches them against the full input.
+func (f *FormModel) updateTagSuggestions() {
+	if f.tagIdx < 0 {
+		return
+	}
+	input := &f.inputs[f.tagIdx]
+	input.ShowSuggestions = true
+	prefix, partial := tagPrefix(input.Value())
+	// Keep the + or - of a filter term
+	if strings.HasPrefix(partial, "+") || strings.HasPrefix(partial, "-") {
+		prefix += partial[:1]
+		partial = partial[1:]
+	}
+	used := domain.ParseTags(prefix)
+	var suggestions []string
+	if partial != "" {
+		for _, tag := range f.tagChoices {
+			if !domain.HasTag(used, tag) {
+				suggestions = append(suggestions, prefix+tag)
+			}
+		}
+	}
+	input.SetSuggestions(suggestions)
+}
+
+// hasTagCompletion reports whether tab would complete the tag being typed
+func (f FormModel) hasTagCompletion() bool {
+	input := f.inputs[f.tagIdx]
+	value := strings.ToLower(input.Value())
+	for _, s := range input.AvailableSuggestions() {
+		if len(s) > len(value) && strings.HasPrefix(strings.ToLower(s), value) {
+			return true
+		}
+	}
+	return false
+}
+
 // View renders the form
 func (f FormModel) View() string {
 	var b strings.Builder
@@ -124,7 +275,11 @@ func (f FormModel) View() string {
 	for i, input := range f.inputs {
 		b.WriteString(f.labels[i])
 		b.WriteString("\n")
-		b.WriteString(input.View())
+		if i == f.notesIdx {
+			b.WriteString(f.notes.View())
+		} else {
+			b.WriteString(input.View())
+		}
 		if i == f.focusIdx {
 			b.WriteString(" ← editing")
 		}
@@ -163,6 +318,18 @@ func (f *FormModel) updateInputFocus() {
 			f.inputs[i].Blur()
 		}
 	}
+	if f.notesIdx >= 0 {
+		if f.focusIdx == f.notesIdx {
+			f.notes.Focus()
+		} else {
+			f.notes.Blur()
+		}
+	}
+}
+
+// InNotes reports whether the multi-line notes field has focus
+func (f FormModel) InNotes() bool {
+	return f.notesIdx >= 0 && f.focusIdx == f.notesIdx
 }
 
 // GetValues returns the form values as a map
@@ -171,6 +338,9 @@ func (f FormModel) GetValues() map[string]string {
 	for i, input := range f.inputs {
 		values[f.labels[i]] = input.Value()
 	}
+	if f.notesIdx >= 0 {
+		values[f.labels[f.notesIdx]] = f.notes.Value()
+	}
 	return values
 }
 
@@ -187,19 +357,42 @@ func (f FormModel) Validate() error {
 		}
 		// Validate priority is a number
 		if p := strings.TrimSpace(f.inputs[2].Value()); p != "" {
-			// Just check if it parses as int
-			_ = p
+			if _, err := strconv.Atoi(p); err != nil {
+				return ErrInvalidPriority
+			}
 		}
 		// Validate deadline format
 		if d := strings.TrimSpace(f.inputs[3].Value()); d != "" {
-			if _, err := time.Parse("2006-01-02", d); err != nil {
+			if _, err := time.ParseInLocation("2006-01-02", d, time.Local); err != nil {
 				return ErrInvalidDateFormat
 			}
 		}
+		if _, err := domain.ParseEstimate(f.inputs[4].Value()); err != nil {
+			return ErrInvalidEstimate
+		}
+		if _, err := domain.ParseRecurrence(f.inputs[5].Value()); err != nil {
+			return NewValidationError(err.Error())
+		}
 	case ViewCreateTask, ViewEditTask:
 		if strings.TrimSpace(f.inputs[0].Value()) == "" {
 			return ErrTaskDescRequired
 		}
+		if p := strings.TrimSpace(f.inputs[1].Value()); p != "" {
+			if _, err := strconv.Atoi(p); err != nil {
+				return ErrInvalidPriority
+			}
+		}
+		if d := strings.TrimSpace(f.inputs[2].Value()); d != "" {
+			if _, err := time.ParseInLocation("2006-01-02", d, time.Local); err != nil {
+				return ErrInvalidDateFormat
+			}
+		}
+		if _, err := domain.ParseEstimate(f.inputs[3].Value()); err != nil {
+			return ErrInvalidEstimate
+		}
+		if _, err := domain.ParseRecurrence(f.inputs[4].Value()); err != nil {
+			return NewValidationError(err.Error())
+		}
 	}
 	return nil
 }
@@ -210,6 +403,8 @@ var (
 	ErrQuestTitleRequired  = NewValidationError("quest title is required")
 	ErrTaskDescRequired    = NewValidationError("task description is required")
 	ErrInvalidDateFormat   = NewValidationError("invalid date format (use YYYY-MM-DD)")
+	ErrInvalidPriority     = NewValidationError("priority must be a number")
+	ErrInvalidEstimate     = NewValidationError("invalid estimate (use e.g. 45m or 1h30m)")
 )
 
 // ValidationError represents a form validation error
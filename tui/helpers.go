@@ -1,8 +1,10 @@
 package tui
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,65 +16,84 @@ func (m *RootModel) startCreateProject() {
 	m.form = NewProjectForm("Create Project", "")
 	m.currentView = ViewCreateProject
 	m.inForm = true
-	m.editingIdx = -1
+	m.editingID = ""
 }
 
 func (m *RootModel) startEditProject() {
-	if m.selectedProjectIdx >= 0 && m.selectedProjectIdx < len(m.projects) {
-		p := &m.projects[m.selectedProjectIdx]
+	if p := m.projectList.SelectedProject(); p != nil {
 		m.form = NewProjectForm("Edit Project", p.Name)
 		m.currentView = ViewEditProject
 		m.inForm = true
-		m.editingIdx = m.selectedProjectIdx
+		m.editingID = p.ID
 	}
 }
 
 func (m *RootModel) startCreateQuest() {
-	if m.selectedProjectIdx >= 0 {
-		m.form = NewQuestForm("Create Quest", nil)
+	if m.selectedProjectID != "" {
+		m.form = NewQuestForm("Create Quest", nil, m.tagChoices())
 		m.currentView = ViewCreateQuest
 		m.inForm = true
-		m.editingIdx = -1
+		m.editingID = ""
 	}
 }
 
 func (m *RootModel) startEditQuest() {
-	if m.selectedProjectIdx >= 0 && m.selectedQuestIdx >= 0 &&
-		m.selectedProjectIdx < len(m.projects) &&
-		m.selectedQuestIdx < len(m.projects[m.selectedProjectIdx].Quests) {
-		q := &m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx]
-		m.form = NewQuestForm("Edit Quest", q)
+	if q, err := m.store.Quest(m.selectedQuestID); err == nil {
+		m.form = NewQuestForm("Edit Quest", q, m.tagChoices())
 		m.currentView = ViewEditQuest
 		m.inForm = true
-		m.editingIdx = m.selectedQuestIdx
+		m.editingID = q.ID
+	}
+}
+
+func (m *RootModel) startCreateSubQuest() {
+	if _, err := m.store.Quest(m.selectedQuestID); err == nil {
+		m.form = NewQuestForm("Create Sub-quest", nil, m.tagChoices())
+		m.currentView = ViewCreateSubQuest
+		m.inForm = true
+		m.editingID = ""
+	}
+}
+
+func (m *RootModel) startEditSubQuest(questID string) {
+	if q, err := m.store.Quest(questID); err == nil {
+		m.form = NewQuestForm("Edit Sub-quest", q, m.tagChoices())
+		m.currentView = ViewEditSubQuest
+		m.inForm = true
+		m.editingID = q.ID
 	}
 }
 
 func (m *RootModel) startCreateTask() {
-	if m.selectedQuestIdx >= 0 {
-		m.form = NewTaskForm("Create Task", "")
+	if m.selectedQuestID != "" {
+		m.form = NewTaskForm("Create Task", nil, m.tagChoices())
 		m.currentView = ViewCreateTask
 		m.inForm = true
-		m.editingIdx = -1
+		m.editingID = ""
 	}
 }
 
 func (m *RootModel) startEditTask() {
-	if m.selectedProjectIdx >= 0 && m.selectedQuestIdx >= 0 &&
-		m.selectedProjectIdx < len(m.projects) &&
-		m.selectedQuestIdx < len(m.projects[m.selectedProjectIdx].Quests) &&
-		m.taskList.SelectedTaskIndex() >= 0 {
-		taskIdx := m.taskList.SelectedTaskIndex()
-		if taskIdx < len(m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks) {
-			desc := m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks[taskIdx].Description
-			m.form = NewTaskForm("Edit Task", desc)
-			m.currentView = ViewEditTask
-			m.inForm = true
-			m.editingIdx = taskIdx
-		}
+	if t, err := m.store.Task(m.taskList.SelectedTaskID()); err == nil {
+		m.form = NewTaskForm("Edit Task", t, m.tagChoices())
+		m.currentView = ViewEditTask
+		m.inForm = true
+		m.editingID = t.ID
 	}
 }
 
+// tagChoices lists the existing tags for form autocomplete
+func (m *RootModel) tagChoices() []string {
+	return domain.AllTags(m.store.Projects())
+}
+
+func (m *RootModel) startTagFilter() {
+	m.form = NewTagFilterForm(m.tagFilter.String(), m.tagChoices())
+	m.currentView = ViewTagFilter
+	m.inForm = true
+	m.editingID = ""
+}
+
 // Form submission and cancellation
 func (m *RootModel) submitForm() {
 	switch m.currentView {
@@ -82,12 +103,17 @@ func (m *RootModel) submitForm() {
 		m.updateProject()
 	case ViewCreateQuest:
 		m.createQuest()
-	case ViewEditQuest:
+	case ViewEditQuest, ViewEditSubQuest:
 		m.updateQuest()
+	case ViewCreateSubQuest:
+		m.createSubQuest()
 	case ViewCreateTask:
 		m.createTask()
 	case ViewEditTask:
 		m.updateTask()
+	case ViewTagFilter:
+		m.tagFilter = domain.ParseTagFilter(m.form.GetValues()["Tags:"])
+		m.updateScreenModels()
 	}
 	m.exitForm()
 }
@@ -98,23 +124,33 @@ func (m *RootModel) cancelForm() {
 
 func (m *RootModel) exitForm() {
 	m.inForm = false
-	m.editingIdx = -1
+	m.editingID = ""
 	switch m.currentView {
 	case ViewCreateProject, ViewEditProject:
 		m.currentView = ViewProjectList
-	case ViewCreateQuest, ViewEditQuest:
+	case ViewCreateQuest, ViewEditQuest, ViewTagFilter:
 		m.currentView = ViewDashboard
-	case ViewCreateTask, ViewEditTask:
+	case ViewCreateTask, ViewEditTask, ViewCreateSubQuest, ViewEditSubQuest:
 		m.currentView = ViewQuestDetail
 	}
 }
 
+// setError records a failed store operation for display
+func (m *RootModel) setError(err error) {
+	if err != nil {
+		m.errorMsg = err.Error()
+	}
+}
+
 // CRUD Operations
 func (m *RootModel) createProject() {
 	values := m.form.GetValues()
 	name := strings.TrimSpace(values["Name:"])
 	if name != "" {
-		domain.CreateProject(&m.projects, name)
+		if _, err := m.store.CreateProject(domain.ProjectInput{Name: name}); err != nil {
+			m.errorMsg = err.Error()
+			return
+		}
 		m.updateScreenModels()
 	}
 }
@@ -122,176 +158,354 @@ func (m *RootModel) createProject() {
 func (m *RootModel) updateProject() {
 	values := m.form.GetValues()
 	name := strings.TrimSpace(values["Name:"])
-	if name != "" && m.editingIdx >= 0 {
-		domain.UpdateProject(&m.projects, m.editingIdx, name)
+	if name != "" && m.editingID != "" {
+		m.setError(m.store.UpdateProject(m.editingID, name))
 		m.updateScreenModels()
 	}
 }
 
 func (m *RootModel) startDeleteProject() {
-	idx := m.projectList.SelectedIndex()
-	if idx >= 0 && idx < len(m.projects) {
+	if id := m.projectList.SelectedProjectID(); id != "" {
 		m.pendingDelete = true
-		m.deleteType = "project"
-		m.deleteIndices = [3]int{idx, -1, -1}
+		m.deleteType = domain.KindProject
+		m.deleteID = id
 	}
 }
 
 func (m *RootModel) confirmDelete() {
 	switch m.deleteType {
-	case "project":
-		idx := m.deleteIndices[0]
-		if idx >= 0 && idx < len(m.projects) {
-			domain.DeleteProject(&m.projects, idx)
-			if m.selectedProjectIdx == idx {
-				m.selectedProjectIdx = -1
-			} else if m.selectedProjectIdx > idx {
-				m.selectedProjectIdx--
-			}
-			m.updateScreenModels()
-		}
-	case "quest":
-		if m.selectedProjectIdx >= 0 {
-			idx := m.deleteIndices[1]
-			if idx >= 0 && idx < len(m.projects[m.selectedProjectIdx].Quests) {
-				domain.DeleteQuest(&m.projects, m.selectedProjectIdx, idx)
-				if m.selectedQuestIdx == idx {
-					m.selectedQuestIdx = -1
-				} else if m.selectedQuestIdx > idx {
-					m.selectedQuestIdx--
-				}
-				m.updateScreenModels()
-			}
+	case domain.KindProject:
+		m.setError(m.store.DeleteProject(m.deleteID))
+		if m.selectedProjectID == m.deleteID {
+			m.selectedProjectID = ""
+			m.selectedQuestID = ""
 		}
-	case "task":
-		if m.selectedProjectIdx >= 0 && m.selectedQuestIdx >= 0 {
-			idx := m.deleteIndices[2]
-			if idx >= 0 && idx < len(m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks) {
-				domain.DeleteTask(&m.projects, m.selectedProjectIdx, m.selectedQuestIdx, idx)
-				m.updateScreenModels()
-			}
+	case domain.KindQuest:
+		// Deleting a quest also deletes the selected quest if it is inside it
+		if m.selectedQuestID != "" && m.questWithin(m.selectedQuestID, m.deleteID) {
+			m.selectedQuestID = ""
 		}
+		m.setError(m.store.DeleteQuest(m.deleteID))
+	case domain.KindTask:
+		m.setError(m.store.DeleteTask(m.deleteID))
 	}
+	m.updateScreenModels()
 	m.pendingDelete = false
+	m.deleteID = ""
 }
 
-func (m *RootModel) createQuest() {
-	if m.selectedProjectIdx < 0 {
-		return
+// questWithin reports whether questID is ancestorID or one of its sub-quests
+func (m *RootModel) questWithin(questID, ancestorID string) bool {
+	for questID != "" {
+		if questID == ancestorID {
+			return true
+		}
+		parent, err := m.store.QuestParent(questID)
+		if err != nil || parent == nil {
+			return false
+		}
+		questID = parent.ID
 	}
+	return false
+}
+
+// questFormValues reads the quest fields from the current form
+func (m *RootModel) questFormValues() domain.QuestInput {
 	values := m.form.GetValues()
-	title := strings.TrimSpace(values["Title:"])
-	if title == "" {
-		return
+	input := domain.QuestInput{
+		Title:       strings.TrimSpace(values["Title:"]),
+		Description: strings.TrimSpace(values["Description:"]),
+		Tags:        domain.ParseTags(values["Tags:"]),
 	}
-
-	desc := strings.TrimSpace(values["Description:"])
 	priorityStr := strings.TrimSpace(values["Priority (0-10):"])
-	priority := 0
 	if p, err := strconv.Atoi(priorityStr); err == nil {
-		priority = p
+		input.Priority = p
 	}
 
-	var deadline *time.Time
 	deadlineStr := strings.TrimSpace(values["Deadline (YYYY-MM-DD):"])
 	if deadlineStr != "" {
-		if d, err := time.Parse("2006-01-02", deadlineStr); err == nil {
-			deadline = &d
+		if d, err := time.ParseInLocation("2006-01-02", deadlineStr, time.Local); err == nil {
+			due := domain.Day(d)
+			input.Deadline = &due
 		}
 	}
+	input.Estimate, _ = domain.ParseEstimate(values["Estimate:"])
+	input.Recur, _ = domain.ParseRecurrence(values["Repeat:"])
+	return input
+}
 
-	domain.CreateQuest(&m.projects, m.selectedProjectIdx, title, desc, priority, deadline)
+func (m *RootModel) createQuest() {
+	if m.selectedProjectID == "" {
+		return
+	}
+	input := m.questFormValues()
+	if input.Title == "" {
+		return
+	}
+
+	_, err := m.store.CreateQuest(m.selectedProjectID, "", input)
+	m.setError(err)
 	m.updateScreenModels()
 }
 
-func (m *RootModel) updateQuest() {
-	if m.selectedProjectIdx < 0 || m.editingIdx < 0 {
+func (m *RootModel) createSubQuest() {
+	if m.selectedProjectID == "" || m.selectedQuestID == "" {
 		return
 	}
-	values := m.form.GetValues()
-	title := strings.TrimSpace(values["Title:"])
-	if title == "" {
+	input := m.questFormValues()
+	if input.Title == "" {
 		return
 	}
 
-	desc := strings.TrimSpace(values["Description:"])
-	priorityStr := strings.TrimSpace(values["Priority (0-10):"])
-	priority := 0
-	if p, err := strconv.Atoi(priorityStr); err == nil {
-		priority = p
-	}
+	_, err := m.store.CreateQuest(m.selectedProjectID, m.selectedQuestID, input)
+	m.setError(err)
+	m.updateScreenModels()
+}
 
-	var deadline *time.Time
-	deadlineStr := strings.TrimSpace(values["Deadline (YYYY-MM-DD):"])
-	if deadlineStr != "" {
-		if d, err := time.Parse("2006-01-02", deadlineStr); err == nil {
-			deadline = &d
-		}
+func (m *RootModel) updateQuest() {
+	if m.editingID == "" {
+		return
+	}
+	input := m.questFormValues()
+	if input.Title == "" {
+		return
 	}
 
-	domain.UpdateQuest(&m.projects, m.selectedProjectIdx, m.editingIdx, title, desc, priority, deadline)
+	m.setError(m.store.UpdateQuest(m.editingID, input))
 	m.updateScreenModels()
 }
 
+// taskFormValues reads the task fields from the current form
+func (m *RootModel) taskFormValues() domain.TaskInput {
+	values := m.form.GetValues()
+	input := domain.TaskInput{
+		Description: strings.TrimSpace(values["Description:"]),
+		Tags:        domain.ParseTags(values["Tags:"]),
+		Notes:       strings.TrimSpace(values["Notes:"]),
+	}
+	if p, err := strconv.Atoi(strings.TrimSpace(values["Priority (0-10):"])); err == nil {
+		input.Priority = p
+	}
+	if d, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(values["Deadline (YYYY-MM-DD):"]), time.Local); err == nil {
+		due := domain.Day(d)
+		input.Deadline = &due
+	}
+	if e, err := domain.ParseEstimate(values["Estimate:"]); err == nil {
+		input.Estimate = e
+	}
+	input.Recur, _ = domain.ParseRecurrence(values["Repeat:"])
+	return input
+}
+
 func (m *RootModel) createTask() {
-	if m.selectedProjectIdx < 0 || m.selectedQuestIdx < 0 {
+	if m.selectedQuestID == "" {
 		return
 	}
-	values := m.form.GetValues()
-	desc := strings.TrimSpace(values["Description:"])
-	if desc == "" {
+	input := m.taskFormValues()
+	if input.Description == "" {
 		return
 	}
 
-	domain.CreateTask(&m.projects, m.selectedProjectIdx, m.selectedQuestIdx, desc)
+	_, err := m.store.CreateTask(m.selectedQuestID, input)
+	m.setError(err)
 	m.updateScreenModels()
 }
 
 func (m *RootModel) updateTask() {
-	if m.selectedProjectIdx < 0 || m.selectedQuestIdx < 0 || m.editingIdx < 0 {
+	if m.editingID == "" {
 		return
 	}
-	values := m.form.GetValues()
-	desc := strings.TrimSpace(values["Description:"])
-	if desc == "" {
+	input := m.taskFormValues()
+	if input.Description == "" {
 		return
 	}
 
-	domain.UpdateTask(&m.projects, m.selectedProjectIdx, m.selectedQuestIdx, m.editingIdx, desc)
+	m.setError(m.store.UpdateTask(m.editingID, input))
 	m.updateScreenModels()
 }
 
 func (m *RootModel) startDeleteTask() {
-	idx := m.taskList.SelectedTaskIndex()
-	if idx >= 0 && m.selectedProjectIdx >= 0 && m.selectedQuestIdx >= 0 &&
-		idx < len(m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks) {
+	if id := m.taskList.SelectedTaskID(); id != "" {
 		m.pendingDelete = true
-		m.deleteType = "task"
-		m.deleteIndices = [3]int{m.selectedProjectIdx, m.selectedQuestIdx, idx}
+		m.deleteType = domain.KindTask
+		m.deleteID = id
 	}
 }
 
+func (m *RootModel) startDeleteSubQuest(questID string) {
+	m.pendingDelete = true
+	m.deleteType = domain.KindQuest
+	m.deleteID = questID
+}
+
 func (m *RootModel) toggleTask() {
-	if m.selectedProjectIdx < 0 || m.selectedQuestIdx < 0 {
-		return
-	}
-	taskIdx := m.taskList.SelectedTaskIndex()
-	if taskIdx >= 0 && taskIdx < len(m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks) {
-		m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks[taskIdx].Done =
-			!m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks[taskIdx].Done
-		m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].CalculateProgress()
-		m.projects[m.selectedProjectIdx].CalculateProgress()
+	if id := m.taskList.SelectedTaskID(); id != "" {
+		m.setError(m.store.ToggleTask(id))
 		m.updateScreenModels()
 	}
 }
 
+// toggleTimer starts the timer on the selected task, or stops it if it is
+// the one running
+func (m *RootModel) toggleTimer() tea.Cmd {
+	id := m.taskList.SelectedTaskID()
+	if id == "" {
+		return nil
+	}
+	t, err := m.store.Task(id)
+	if err != nil {
+		m.setError(err)
+		return nil
+	}
+	if t.Running() != nil {
+		_, err = m.store.StopTimer("")
+	} else {
+		err = m.store.StartTimer(id, "")
+	}
+	if err != nil {
+		m.setError(err)
+		return nil
+	}
+	m.updateScreenModels()
+	return tea.Batch(m.saveProjectsCmd(), m.ensureTicking())
+}
+
+// hasRunningTimer reports whether any task is being timed
+func hasRunningTimer(projects []domain.Project) bool {
+	_, ok := domain.RunningTimer(projects)
+	return ok
+}
+
+// toastDuration is how long an achievement toast stays on screen
+const toastDuration = 5 * time.Second
+
+// queueToasts shows a toast for each achievement unlocked since the last
+// message and schedules their removal
+func (m *RootModel) queueToasts() tea.Cmd {
+	unlocked := m.store.TakeUnlocked()
+	if len(unlocked) == 0 {
+		return nil
+	}
+	for _, a := range unlocked {
+		m.toasts = append(m.toasts, fmt.Sprintf("🏆 Achievement unlocked: %s - %s (+%d XP)", a.Name, a.Description, a.XP))
+	}
+	count := len(unlocked)
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return ToastExpiredMsg{Count: count}
+	})
+}
+
+// tickTimer schedules the next redraw of the running timer
+func tickTimer() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return TimerTickMsg{}
+	})
+}
+
+// ensureTicking starts the timer redraws if a timer runs and none are scheduled
+func (m *RootModel) ensureTicking() tea.Cmd {
+	if m.ticking || !hasRunningTimer(m.store.Projects()) {
+		return nil
+	}
+	m.ticking = true
+	return tickTimer()
+}
+
+// saveProjectsCmd queues a save of the current state and returns a Cmd
+// writing whatever is queued
 func (m *RootModel) saveProjectsCmd() tea.Cmd {
+	m.queueSave()
 	return func() tea.Msg {
-		err := domain.SaveProjects(m.projects)
-		return SaveCompleteMsg{Err: err}
+		return SaveCompleteMsg{Err: m.saves.flush()}
+	}
+}
+
+// queueSave encodes the current state and queues it to be written. A state
+// that cannot be encoded queues its error, so the write reports it.
+func (m *RootModel) queueSave() {
+	write, err := m.encodeSave()
+	if err != nil {
+		write = func() error { return err }
+	}
+	m.saves.add(write)
+}
+
+// Flush writes any saves still queued, such as the one made on quit. Call
+// it after the program exits.
+func (m *RootModel) Flush() error {
+	return m.saves.flush()
+}
+
+// encodeSave encodes the projects, the activity of the changes since the
+// last save and the undo history when it is kept, and returns a function
+// writing them. Encoding happens here, on the Update goroutine, so the
+// write never reads the store while the next change modifies it.
+func (m *RootModel) encodeSave() (func() error, error) {
+	data, err := domain.EncodeData(m.store.Data())
+	if err != nil {
+		return nil, err
+	}
+	var history []byte
+	if m.undoFile != "" {
+		if history, err = domain.EncodeHistory(m.store.History()); err != nil {
+			return nil, err
+		}
 	}
+	activity := m.store.TakeActivity()
+	storage, activityLog, undoFile := m.storage, m.activityLog, m.undoFile
+	return func() error {
+		if err := storage.Save(data); err != nil {
+			return err
+		}
+		if err := activityLog.Append(activity); err != nil {
+			return err
+		}
+		if undoFile != "" {
+			return domain.WriteHistory(undoFile, history)
+		}
+		return nil
+	}, nil
 }
 
-func (m *RootModel) saveProjects() {
-	_ = domain.SaveProjects(m.projects)
+// saveQueue writes saves in the order they were encoded. Each save Cmd
+// runs on its own goroutine, and those may start in any order or not at
+// all once the program quits, so a Cmd writes everything queued so far
+// rather than just its own save. Queueing never waits on a write.
+type saveQueue struct {
+	mu      sync.Mutex // guards pending
+	writing sync.Mutex // held while writing, so writes never overlap
+	pending []func() error
+}
+
+func newSaveQueue() *saveQueue {
+	return &saveQueue{}
+}
+
+// add queues a write; call it in the order saves are encoded
+func (q *saveQueue) add(write func() error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, write)
+}
+
+// flush runs the queued writes in order, including any queued while it
+// runs, and returns the first error. Later writes still run after a failure,
+// since each one saves the whole state.
+func (q *saveQueue) flush() error {
+	q.writing.Lock()
+	defer q.writing.Unlock()
+	var first error
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return first
+		}
+		write := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+		if err := write(); err != nil && first == nil {
+			first = err
+		}
+	}
 }
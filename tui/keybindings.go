@@ -10,6 +10,11 @@ type KeyMap struct {
 	Dashboard key.Binding
 	Projects  key.Binding
 	QuestList key.Binding
+	Board     key.Binding
+	Calendar  key.Binding
+	Timeline  key.Binding
+	Stats     key.Binding
+	Activity  key.Binding
 	Up        key.Binding
 	Down      key.Binding
 	Enter     key.Binding
@@ -17,12 +22,36 @@ type KeyMap struct {
 	Edit      key.Binding
 	Delete    key.Binding
 	Toggle    key.Binding
-	Tab       key.Binding
-	ShiftTab  key.Binding
-	Submit    key.Binding
-	Cancel    key.Binding
-	Help      key.Binding
-	Quit      key.Binding
+	SubQuest  key.Binding
+	Back      key.Binding
+	Search    key.Binding
+	TagFilter key.Binding
+	Block     key.Binding
+	Timer     key.Binding
+	Undo      key.Binding
+	Redo      key.Binding
+
+	// Character screens
+	Character    key.Binding
+	Achievements key.Binding
+
+	// Quest lifecycle
+	Complete     key.Binding
+	Abandon      key.Binding
+	Reopen       key.Binding
+	Archive      key.Binding
+	Finished     key.Binding
+	AutoComplete key.Binding
+	ProgressMode key.Binding
+	MoveLeft     key.Binding
+	MoveRight    key.Binding
+
+	Tab      key.Binding
+	ShiftTab key.Binding
+	Submit   key.Binding
+	Cancel   key.Binding
+	Help     key.Binding
+	Quit     key.Binding
 }
 
 // DefaultKeyMap returns the default keybindings
@@ -40,6 +69,26 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("l"),
 			key.WithHelp("l", "quest list"),
 		),
+		Board: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "board"),
+		),
+		Calendar: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "calendar"),
+		),
+		Timeline: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "timeline"),
+		),
+		Stats: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "stats"),
+		),
+		Activity: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "activity log"),
+		),
 		Up: key.NewBinding(
 			key.WithKeys("k", "up"),
 			key.WithHelp("k/↑", "move up"),
@@ -68,6 +117,82 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys(" "),
 			key.WithHelp("space", "toggle"),
 		),
+		SubQuest: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new sub-quest"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("backspace", "esc"),
+			key.WithHelp("backspace", "up a level"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		),
+		TagFilter: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "filter by tags"),
+		),
+		Block: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "blocked by"),
+		),
+		Timer: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "start/stop timer"),
+		),
+		Character: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "character"),
+		),
+		Achievements: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "achievements"),
+		),
+		Undo: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "undo"),
+		),
+		Redo: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "redo"),
+		),
+		Complete: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "complete quest"),
+		),
+		Abandon: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "cancel quest"),
+		),
+		Reopen: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "reopen"),
+		),
+		Archive: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "archive"),
+		),
+		Finished: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "finished quests"),
+		),
+		AutoComplete: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "toggle auto-complete"),
+		),
+		ProgressMode: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "toggle effort progress"),
+		),
+		MoveLeft: key.NewBinding(
+			key.WithKeys("<", "shift+left"),
+			key.WithHelp("<", "move card left"),
+		),
+		MoveRight: key.NewBinding(
+			key.WithKeys(">", "shift+right"),
+			key.WithHelp(">", "move card right"),
+		),
 		Tab: key.NewBinding(
 			key.WithKeys("tab"),
 			key.WithHelp("tab", "next field"),
@@ -102,11 +227,53 @@ func (k KeyMap) ShortHelpForView(view View) []key.Binding {
 		return []key.Binding{k.Up, k.Down, k.Enter, k.Quit}
 	case ViewDashboard:
 		createQuestKey := key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "create quest"))
-		return []key.Binding{k.Up, k.Down, k.Enter, createQuestKey, k.Edit, k.Delete, k.Help, k.Quit}
+		return []key.Binding{k.Up, k.Down, k.Enter, createQuestKey, k.Edit, k.Delete, k.Complete, k.Abandon, k.Finished, k.Projects, k.Board, k.Calendar, k.Timeline, k.Stats, k.Activity, k.Character, k.Achievements, k.Search, k.TagFilter, k.Help, k.Quit}
 	case ViewProjectList:
-		return []key.Binding{k.Up, k.Down, k.Enter, k.Create, k.Edit, k.Delete, k.Dashboard, k.Help, k.Quit}
+		return []key.Binding{k.Up, k.Down, k.Enter, k.Create, k.Edit, k.Delete, k.AutoComplete, k.ProgressMode, k.Board, k.Calendar, k.Timeline, k.Stats, k.Activity, k.Character, k.Achievements, k.Dashboard, k.Help, k.Quit}
 	case ViewQuestDetail:
-		return []key.Binding{k.Up, k.Down, k.Enter, k.Create, k.Edit, k.Delete, k.Dashboard, k.Help, k.Quit}
+		return []key.Binding{k.Up, k.Down, k.Enter, k.Create, k.SubQuest, k.Edit, k.Delete, k.Complete, k.Abandon, k.Reopen, k.Block, k.Timer, k.Activity, k.Back, k.Dashboard, k.Help, k.Quit}
+	case ViewBlockers:
+		return []key.Binding{k.Up, k.Down,
+			key.NewBinding(key.WithKeys(" ", "enter"), key.WithHelp("space", "toggle blocker")),
+			k.Back, k.Help, k.Quit}
+	case ViewSearch:
+		return []key.Binding{
+			key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑/↓", "move")),
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open")),
+			key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "close")),
+		}
+	case ViewFinished:
+		return []key.Binding{k.Up, k.Down, k.Enter, k.Reopen, k.Archive, k.Dashboard, k.Help, k.Quit}
+	case ViewBoard:
+		return []key.Binding{
+			key.NewBinding(key.WithKeys("left", "right"), key.WithHelp("←/→", "column")),
+			k.Up, k.Down, k.MoveLeft, k.MoveRight,
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open quest")),
+			k.Back, k.Help, k.Quit}
+	case ViewCalendar:
+		return []key.Binding{
+			key.NewBinding(key.WithKeys("left", "right", "up", "down"), key.WithHelp("←/→/↑/↓", "day/week")),
+			key.NewBinding(key.WithKeys("[", "]"), key.WithHelp("[/]", "prev/next month")),
+			key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "month/week")),
+			key.NewBinding(key.WithKeys("."), key.WithHelp(".", "today")),
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "day's items/open")),
+			key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "reschedule")),
+			key.NewBinding(key.WithKeys("<", ">"), key.WithHelp("</>", "a day earlier/later")),
+			k.Back, k.Help, k.Quit}
+	case ViewTimeline:
+		return []key.Binding{k.Up, k.Down,
+			key.NewBinding(key.WithKeys("left", "right"), key.WithHelp("←/→", "scroll a week")),
+			key.NewBinding(key.WithKeys("."), key.WithHelp(".", "this week")),
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open quest")),
+			k.Back, k.Help, k.Quit}
+	case ViewStats:
+		return []key.Binding{k.Back, k.Dashboard, k.Projects, k.Help, k.Quit}
+	case ViewActivity:
+		return []key.Binding{k.Up, k.Down, k.Back, k.Dashboard, k.Projects, k.Help, k.Quit}
+	case ViewCharacter:
+		return []key.Binding{k.Achievements, k.Back, k.Dashboard, k.Projects, k.Help, k.Quit}
+	case ViewAchievements:
+		return []key.Binding{k.Character, k.Back, k.Dashboard, k.Projects, k.Help, k.Quit}
 	default:
 		return []key.Binding{k.Help, k.Quit}
 	}
@@ -121,9 +288,10 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Enter, k.Create, k.Edit, k.Delete},
-		{k.Toggle, k.Dashboard, k.Projects, k.QuestList},
+		{k.Toggle, k.SubQuest, k.Block, k.Timer, k.Back, k.Dashboard, k.Projects, k.QuestList, k.Board, k.Calendar, k.Timeline, k.Stats, k.Activity, k.Character, k.Achievements},
+		{k.Complete, k.Abandon, k.Reopen, k.Archive, k.Finished, k.AutoComplete, k.ProgressMode, k.MoveLeft, k.MoveRight},
 		{k.Tab, k.ShiftTab, k.Submit, k.Cancel},
-		{k.Help, k.Quit},
+		{k.Search, k.TagFilter, k.Undo, k.Redo, k.Help, k.Quit},
 	}
 }
 
@@ -9,20 +9,24 @@ type DataChangedMsg struct {
 
 // ItemToggleMsg is sent when a task/item is toggled
 type ItemToggleMsg struct {
-	ProjectIdx int
-	QuestIdx   int
-	TaskIdx    int
+	TaskID string
 }
 
 // ItemDeleteMsg is sent when an item is deleted
 type ItemDeleteMsg struct {
-	ItemType   string // "project", "quest", "task"
-	ProjectIdx int
-	QuestIdx   int
-	TaskIdx    int
+	ItemType string // "project", "quest", "task"
+	ID       string
 }
 
 // SaveCompleteMsg is sent when save is complete
 type SaveCompleteMsg struct {
 	Err error
 }
+
+// TimerTickMsg redraws the running timer once a second
+type TimerTickMsg struct{}
+
+// ToastExpiredMsg removes the oldest Count toasts
+type ToastExpiredMsg struct {
+	Count int
+}
@@ -12,37 +12,71 @@ type RootModel struct {
 	help        HelpModel
 
 	// Data
-	projects []domain.Project
+	store       *domain.Store
+	storage     domain.Storage
+	undoFile    string // empty keeps undo history for the session only
+	activityLog domain.ActivityLog
+	saves       *saveQueue
 
 	// Screen models
 	projectSelection ProjectSelectionModel
 	dashboard        DashboardModel
+	finished         FinishedModel
 	projectList      ProjectListModel
 	taskList         QuestDetailModel
 	form             FormModel
 	inForm           bool
+	blockers         BlockerPickerModel
+	search           SearchModel
+	character        CharacterModel
+	achievements     AchievementsModel
+	board            BoardModel
+	calendar         CalendarModel
+	timeline         TimelineModel
+	stats            StatsModel
+	activity         ActivityModel
+	searchReturn     View // view to go back to when search is closed
+	activityReturn   View // view that opened the activity log
 
 	// List keys
 	listKeys     *listKeyMap
 	delegateKeys *delegateKeyMap
 
-	// Navigation context (using indices for safety)
-	selectedProjectIdx int
-	selectedQuestIdx   int
-	editingIdx         int // for edit operations
+	// Navigation context (IDs stay valid across deletes and re-sorts)
+	selectedProjectID string
+	selectedQuestID   string
+	editingID         string // for edit operations
+	tagFilter         domain.TagFilter
 
 	// Delete confirmation
 	pendingDelete bool
 	deleteType    string // "project", "quest", "task"
-	deleteIndices [3]int // projectIdx, questIdx, taskIdx
+	deleteID      string
+
+	// Terminal size from the last WindowSizeMsg, 0 until one arrives
+	width, height int
+
+	// ticking is set while a TimerTickMsg is scheduled
+	ticking bool
+
+	// toasts announce newly unlocked achievements until they expire
+	toasts []string
 
 	// Error handling
-	errorMsg string
+	errorMsg  string
+	statusMsg string
 }
 
-// InitialModel creates the initial root model
-func InitialModel() RootModel {
-	projects, _ := domain.LoadProjects()
+// InitialModel creates the initial root model backed by storage. A load
+// error is returned rather than replacing unreadable data with a sample.
+// A non-empty undoFile keeps the undo history across restarts, and every
+// change is appended to the activity log.
+func InitialModel(storage domain.Storage, undoFile string, activity domain.ActivityLog) (RootModel, error) {
+	data, err := storage.Load()
+	if err != nil {
+		return RootModel{}, err
+	}
+	projects := data.Projects
 
 	// If no projects loaded, add a sample project
 	if len(projects) == 0 {
@@ -54,15 +88,23 @@ func InitialModel() RootModel {
 		}
 		projects = []domain.Project{sampleProject}
 		// Save the initial sample project
-		_ = domain.SaveProjects(projects)
+		if sample, err := domain.EncodeData(domain.Data{Projects: projects, XPLog: data.XPLog}); err == nil {
+			_ = storage.Save(sample)
+		}
 	}
 
-	// Calculate progress for all quests and projects (in case loaded from JSON without progress)
-	for i := range projects {
-		for j := range projects[i].Quests {
-			projects[i].Quests[j].CalculateProgress()
+	// The store calculates progress for all quests and projects
+	// (in case loaded from JSON without progress)
+	store := domain.NewStore(projects)
+	store.SetXPLog(data.XPLog)
+	projects = store.Projects()
+	store.SetActor(activity.Actor)
+	if undoFile != "" {
+		history, err := domain.LoadHistory(undoFile, projects)
+		if err != nil {
+			return RootModel{}, err
 		}
-		projects[i].CalculateProgress()
+		store.SetHistory(history)
 	}
 
 	keymap := DefaultKeyMap()
@@ -72,41 +114,44 @@ func InitialModel() RootModel {
 
 	// Determine initial view and selected project
 	var currentView View
-	var selectedProjectIdx int
+	var selectedProjectID string
 	projectSelection := NewProjectSelectionModel(projects, keymap)
 
 	if len(projects) == 0 {
 		// Shouldn't happen, but handle
 		currentView = ViewDashboard
-		selectedProjectIdx = -1
 	} else if len(projects) == 1 {
 		currentView = ViewDashboard
-		selectedProjectIdx = 0
+		selectedProjectID = projects[0].ID
 	} else {
 		currentView = ViewProjectSelection
-		selectedProjectIdx = -1
 	}
 
 	// Create screen models
-	dashboard := NewDashboardModel(projects, selectedProjectIdx, keymap)
+	dashboard := NewDashboardModel(projects, data.XPLog, selectedProjectID, domain.TagFilter{}, keymap)
+	finished := NewFinishedModel(projects, selectedProjectID, keymap)
 	projectList := NewProjectListModel(projects, keymap)
-	taskList := NewQuestDetailModel(projects, -1, -1, keymap)
+	taskList := NewQuestDetailModel(store, "", keymap)
 
 	return RootModel{
-		currentView:        currentView,
-		keymap:             keymap,
-		help:               help,
-		projects:           projects,
-		projectSelection:   projectSelection,
-		dashboard:          dashboard,
-		projectList:        projectList,
-		taskList:           taskList,
-		listKeys:           listKeys,
-		delegateKeys:       delegateKeys,
-		inForm:             false,
-		selectedProjectIdx: selectedProjectIdx,
-		selectedQuestIdx:   -1,
-		editingIdx:         -1,
-		pendingDelete:      false,
-	}
+		currentView:       currentView,
+		keymap:            keymap,
+		help:              help,
+		store:             store,
+		storage:           storage,
+		undoFile:          undoFile,
+		activityLog:       activity,
+		saves:             newSaveQueue(),
+		projectSelection:  projectSelection,
+		dashboard:         dashboard,
+		finished:          finished,
+		projectList:       projectList,
+		taskList:          taskList,
+		listKeys:          listKeys,
+		delegateKeys:      delegateKeys,
+		inForm:            false,
+		selectedProjectID: selectedProjectID,
+		pendingDelete:     false,
+		ticking:           hasRunningTimer(projects),
+	}, nil
 }
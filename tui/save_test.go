@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"quest_line/domain"
+)
+
+// recorder collects the order writes ran in
+type recorder struct {
+	mu    sync.Mutex
+	wrote []int
+}
+
+// write returns a queued write recording n, failing with err if given
+func (r *recorder) write(n int, err error) func() error {
+	return func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.wrote = append(r.wrote, n)
+		return err
+	}
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fmt.Sprint(r.wrote)
+}
+
+func TestSaveQueueWritesInOrder(t *testing.T) {
+	q := newSaveQueue()
+	r := &recorder{}
+	var flushes []func() error
+	for n := 1; n <= 5; n++ {
+		q.add(r.write(n, nil))
+		flushes = append(flushes, q.flush)
+	}
+	if got := r.String(); got != "[]" {
+		t.Fatalf("queueing wrote %s, want nothing until a flush", got)
+	}
+
+	// The Cmds start in reverse order, as goroutines may
+	var wg sync.WaitGroup
+	for i := len(flushes) - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func(flush func() error) {
+			defer wg.Done()
+			if err := flush(); err != nil {
+				t.Error(err)
+			}
+		}(flushes[i])
+	}
+	wg.Wait()
+	if got := r.String(); got != "[1 2 3 4 5]" {
+		t.Errorf("wrote %s, want each save once in the order queued", got)
+	}
+}
+
+func TestSaveQueueNeverWaitsOnCmdsThatDoNotRun(t *testing.T) {
+	q := newSaveQueue()
+	r := &recorder{}
+	// Saves whose Cmds never run before the program quits, then the save
+	// queued on quit and flushed after the program exits
+	q.add(r.write(1, nil))
+	q.add(r.write(2, nil))
+	q.add(r.write(3, nil))
+	if err := q.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.String(); got != "[1 2 3]" {
+		t.Errorf("wrote %s, want every queued save", got)
+	}
+	if err := q.flush(); err != nil {
+		t.Errorf("flushing an empty queue failed: %v", err)
+	}
+	if got := r.String(); got != "[1 2 3]" {
+		t.Errorf("a second flush wrote again: %s", got)
+	}
+}
+
+func TestSaveQueueKeepsWritingAfterAnError(t *testing.T) {
+	q := newSaveQueue()
+	r := &recorder{}
+	first, second := errors.New("disk full"), errors.New("still full")
+	q.add(r.write(1, first))
+	q.add(r.write(2, second))
+	q.add(r.write(3, nil))
+	if err := q.flush(); err != first {
+		t.Errorf("flush returned %v, want the first error", err)
+	}
+	if got := r.String(); got != "[1 2 3]" {
+		t.Errorf("wrote %s, want the later saves still written", got)
+	}
+}
+
+func TestQuitSavesAfterTheProgramExits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quests.json")
+	storage := domain.NewJSONStorage(path)
+	m, err := InitialModel(storage, "", domain.ActivityLog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.store.CreateProject(domain.ProjectInput{Name: "Garden"}); err != nil {
+		t.Fatal(err)
+	}
+	// A batched save whose Cmd the program never gets to run
+	_ = tea.Batch(m.saveProjectsCmd(), nil)
+
+	quit := make(chan tea.Cmd)
+	go func() {
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		quit <- cmd
+	}()
+	select {
+	case cmd := <-quit:
+		if cmd == nil {
+			t.Fatal("q did not quit")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("quitting waited on a save that never ran")
+	}
+
+	if err := m.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	data, err := storage.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(data.Projects); n != 2 || data.Projects[1].Name != "Garden" {
+		t.Errorf("saved %d projects %+v, want the sample and Garden", n, data.Projects)
+	}
+}
@@ -8,38 +8,71 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"quest_line/domain"
 	"strings"
+	"time"
 )
 
 // DashboardModel displays today's active quests
 type DashboardModel struct {
-	projects           []domain.Project
-	selectedProjectIdx int
-	keymap             KeyMap
-	selectedIdx        int
+	projects          []domain.Project
+	xpLog             []domain.XPEvent
+	selectedProjectID string
+	tagFilter         domain.TagFilter
+	keymap            KeyMap
+	selectedIdx       int
 }
 
 // NewDashboardModel creates a new dashboard model
-func NewDashboardModel(projects []domain.Project, selectedProjectIdx int, keymap KeyMap) DashboardModel {
+func NewDashboardModel(projects []domain.Project, xpLog []domain.XPEvent, selectedProjectID string, tagFilter domain.TagFilter, keymap KeyMap) DashboardModel {
 	return DashboardModel{
-		projects:           projects,
-		selectedProjectIdx: selectedProjectIdx,
-		keymap:             keymap,
-		selectedIdx:        0,
+		projects:          projects,
+		xpLog:             xpLog,
+		selectedProjectID: selectedProjectID,
+		tagFilter:         tagFilter,
+		keymap:            keymap,
+		selectedIdx:       0,
 	}
 }
 
+// activeQuests returns the planner output for the selected project, or all
+// projects, narrowed by the tag filter
+func (m DashboardModel) activeQuests() []domain.Quest {
+	var quests []domain.Quest
+	if m.selectedProjectID != "" {
+		quests = domain.DailyPlannerForProject(m.projects, m.selectedProjectID)
+	} else {
+		quests = domain.DailyPlanner(m.projects)
+	}
+	return m.tagFilter.FilterQuests(quests, domain.EffectiveTags(m.projects))
+}
+
+// overdueTasks returns the overdue tasks listed above the quests
+func (m DashboardModel) overdueTasks() []domain.PlannedTask {
+	tasks := domain.OverdueTasks(m.projects, m.selectedProjectID, time.Now())
+	if m.tagFilter.IsEmpty() {
+		return tasks
+	}
+	index := domain.EffectiveTags(m.projects)
+	filtered := tasks[:0]
+	for _, t := range tasks {
+		if m.tagFilter.Matches(index[t.Task.ID]) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
 // Update handles messages for the dashboard
 func (m DashboardModel) Update(msg tea.Msg) (DashboardModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		k := msg.String()
-		activeQuests := domain.DailyPlanner(m.projects)
+		rows := len(m.overdueTasks()) + len(m.activeQuests())
 		if k == "k" || k == "up" {
 			if m.selectedIdx > 0 {
 				m.selectedIdx--
 			}
 		} else if k == "j" || k == "down" {
-			if m.selectedIdx < len(activeQuests)-1 {
+			if m.selectedIdx < rows-1 {
 				m.selectedIdx++
 			}
 		}
@@ -52,48 +85,184 @@ func (m DashboardModel) View() string {
 	var b strings.Builder
 
 	projectName := ""
-	if m.selectedProjectIdx >= 0 && m.selectedProjectIdx < len(m.projects) {
-		projectName = m.projects[m.selectedProjectIdx].Name + " - "
+	for _, project := range m.projects {
+		if project.ID == m.selectedProjectID {
+			projectName = project.Name + " - "
+		}
 	}
 	b.WriteString(titleStyle.Render("Dashboard - " + projectName + "Today's Active Quests"))
 	b.WriteString("\n\n")
-
-	var activeQuests []domain.Quest
-	if m.selectedProjectIdx >= 0 {
-		activeQuests = domain.DailyPlannerForProject(m.projects, m.selectedProjectIdx)
-	} else {
-		activeQuests = domain.DailyPlanner(m.projects)
+	if streak, _ := domain.Streak(m.xpLog, time.Now()); streak > 0 {
+		b.WriteString(timerStyle.Render("🔥 "+domain.FormatStreak(streak)+" in a row") + "\n\n")
+	}
+	if !m.tagFilter.IsEmpty() {
+		b.WriteString(fmt.Sprintf("Filter: %s\n\n", m.tagFilter))
 	}
 
-	if m.selectedIdx >= len(activeQuests) {
+	activeQuests := m.activeQuests()
+	overdue := m.overdueTasks()
+
+	if m.selectedIdx >= len(overdue)+len(activeQuests) {
 		m.selectedIdx = 0
 	}
 
-	if len(activeQuests) == 0 {
+	if len(overdue) > 0 {
+		b.WriteString(errorStyle.Render("Overdue Tasks"))
+		b.WriteString("\n")
+		for i, t := range overdue {
+			line := fmt.Sprintf("%s (%s) - due %s", t.Task.Description, t.QuestTitle, t.Task.Deadline.Format("2006-01-02"))
+			if t.Blocked {
+				line += " (blocked)"
+			}
+			if i == m.selectedIdx {
+				line = selectedStyle.Render(line)
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(activeQuests) == 0 && !m.tagFilter.IsEmpty() {
+		b.WriteString("No active quests match the filter.\n\n")
+	} else if len(activeQuests) == 0 {
 		b.WriteString("No active quests!\n")
 		b.WriteString("Create a quest to get started.\n\n")
 	} else {
 		for i, quest := range activeQuests {
 			line := fmt.Sprintf("%s: %.1f%% complete", quest.Title, quest.Progress)
-			if i == m.selectedIdx {
+			if len(overdue)+i == m.selectedIdx {
 				line = selectedStyle.Render(line)
 			}
 			b.WriteString(line + "\n")
 			if quest.Deadline != nil {
 				b.WriteString(fmt.Sprintf("  Due: %s\n", quest.Deadline.Format("2006-01-02")))
 			}
-			b.WriteString(fmt.Sprintf("  Priority: %d\n\n", quest.Priority))
+			if quest.Recur != nil {
+				b.WriteString(fmt.Sprintf("  Repeats: %s\n", quest.Recur))
+			}
+			b.WriteString(fmt.Sprintf("  Priority: %d\n", quest.Priority))
+			if len(quest.Tags) > 0 {
+				b.WriteString("  " + domain.HashTags(quest.Tags) + "\n")
+			}
+			b.WriteString("\n")
 		}
 	}
 
+	// Blocked quests are listed but cannot be selected until they are free
+	if blocked := m.blockedQuests(); len(blocked) > 0 {
+		b.WriteString(searchPathStyle.Render(fmt.Sprintf("Blocked (%d): %s", len(blocked), questNames(blocked, false))))
+		b.WriteString("\n")
+	}
+
 	return b.String()
 }
 
+// blockedQuests returns the quests the planner holds back, narrowed by the tag filter
+func (m DashboardModel) blockedQuests() []domain.Quest {
+	blocked := domain.BlockedQuests(m.projects, m.selectedProjectID)
+	return m.tagFilter.FilterQuests(blocked, domain.EffectiveTags(m.projects))
+}
+
 // SelectedIndex returns the currently selected index
 func (m DashboardModel) SelectedIndex() int {
 	return m.selectedIdx
 }
 
+// SelectedQuestID returns the ID of the selected quest, or "" if none or
+// an overdue task is selected
+func (m DashboardModel) SelectedQuestID() string {
+	activeQuests := m.activeQuests()
+	i := m.selectedIdx - len(m.overdueTasks())
+	if i >= 0 && i < len(activeQuests) {
+		return activeQuests[i].ID
+	}
+	return ""
+}
+
+// SelectedTask returns the selected overdue task, if one is selected
+func (m DashboardModel) SelectedTask() (domain.PlannedTask, bool) {
+	overdue := m.overdueTasks()
+	if m.selectedIdx >= 0 && m.selectedIdx < len(overdue) {
+		return overdue[m.selectedIdx], true
+	}
+	return domain.PlannedTask{}, false
+}
+
+// FinishedModel lists completed and cancelled quests
+type FinishedModel struct {
+	projects          []domain.Project
+	selectedProjectID string
+	keymap            KeyMap
+	selectedIdx       int
+}
+
+// NewFinishedModel creates a new finished quests model
+func NewFinishedModel(projects []domain.Project, selectedProjectID string, keymap KeyMap) FinishedModel {
+	return FinishedModel{
+		projects:          projects,
+		selectedProjectID: selectedProjectID,
+		keymap:            keymap,
+	}
+}
+
+// finishedQuests returns the finished quests of the selected project, or all projects
+func (m FinishedModel) finishedQuests() []domain.Quest {
+	return domain.FinishedQuests(m.projects, m.selectedProjectID)
+}
+
+// Update handles messages for the finished quests list
+func (m FinishedModel) Update(msg tea.Msg) (FinishedModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		k := msg.String()
+		if k == "k" || k == "up" {
+			if m.selectedIdx > 0 {
+				m.selectedIdx--
+			}
+		} else if k == "j" || k == "down" {
+			if m.selectedIdx < len(m.finishedQuests())-1 {
+				m.selectedIdx++
+			}
+		}
+	}
+	return m, nil
+}
+
+// View renders the finished quests list
+func (m FinishedModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Finished Quests"))
+	b.WriteString("\n\n")
+
+	quests := m.finishedQuests()
+	if len(quests) == 0 {
+		b.WriteString("No completed or cancelled quests yet.\n\n")
+		return b.String()
+	}
+	for i, quest := range quests {
+		line := fmt.Sprintf("[%s] %s: %.1f%% complete", quest.State, quest.Title, quest.Progress)
+		if i == m.selectedIdx {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+		if at := quest.FinishedAt(); at != nil {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", quest.State, at.Local().Format("2006-01-02 15:04")))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// SelectedQuestID returns the ID of the selected quest, or "" if none
+func (m FinishedModel) SelectedQuestID() string {
+	quests := m.finishedQuests()
+	if m.selectedIdx >= 0 && m.selectedIdx < len(quests) {
+		return quests[m.selectedIdx].ID
+	}
+	return ""
+}
+
 // ProjectListModel displays all projects
 type ProjectListModel struct {
 	projects    []domain.Project
@@ -141,6 +310,15 @@ func (m ProjectListModel) View() string {
 	} else {
 		for i, project := range m.projects {
 			line := fmt.Sprintf("%2d. %s (%d quests)", i+1, project.Name, len(project.Quests))
+			if project.AutoComplete {
+				line += " [auto-complete]"
+			}
+			if project.ProgressMode == domain.ProgressByEffort {
+				line += " [effort]"
+			}
+			if spent := project.TimeSpent(time.Now()); spent > 0 {
+				line += " " + domain.FormatDuration(spent) + " logged"
+			}
 			if i == m.selectedIdx {
 				line = selectedStyle.Render(line)
 			}
@@ -226,6 +404,14 @@ func (m ProjectSelectionModel) SelectedIndex() int {
 	return m.selectedIdx
 }
 
+// SelectedProjectID returns the ID of the selected project, or "" if none
+func (m ProjectSelectionModel) SelectedProjectID() string {
+	if m.selectedIdx >= 0 && m.selectedIdx < len(m.projects) {
+		return m.projects[m.selectedIdx].ID
+	}
+	return ""
+}
+
 // MoveUp moves selection up
 func (m *ProjectListModel) MoveUp() {
 	if m.selectedIdx > 0 {
@@ -253,32 +439,56 @@ func (m ProjectListModel) SelectedIndex() int {
 	return m.selectedIdx
 }
 
-// QuestDetailModel displays a single quest with its tasks
+// SelectedProjectID returns the ID of the selected project, or "" if none
+func (m ProjectListModel) SelectedProjectID() string {
+	if p := m.SelectedProject(); p != nil {
+		return p.ID
+	}
+	return ""
+}
+
+// QuestDetailModel displays a single quest with its sub-quests and tasks
 type QuestDetailModel struct {
-	projects         []domain.Project
-	selectedProjIdx  int
-	selectedQuestIdx int
-	selectedTaskIdx  int
-	keymap           KeyMap
-	taskList         list.Model
+	store           *domain.Store
+	questID         string
+	selectedTaskIdx int
+	keymap          KeyMap
+	taskList        list.Model
 }
 
-// NewQuestDetailModel creates a new quest detail model
-func NewQuestDetailModel(projects []domain.Project, projIdx int, questIdx int, keymap KeyMap) QuestDetailModel {
+// questItems builds list items for a quest: sub-quests first, then tasks
+func questItems(quest *domain.Quest, blocked map[string]bool) []list.Item {
 	var items []list.Item
-	if projIdx >= 0 && questIdx >= 0 && projIdx < len(projects) && questIdx < len(projects[projIdx].Quests) {
-		for i := range projects[projIdx].Quests[questIdx].Tasks {
-			items = append(items, TaskItem{task: &projects[projIdx].Quests[questIdx].Tasks[i]})
-		}
+	if quest == nil {
+		return items
+	}
+	for i := range quest.SubQuests {
+		items = append(items, QuestItem{quest: &quest.SubQuests[i], blocked: blocked[quest.SubQuests[i].ID]})
+	}
+	for i := range quest.Tasks {
+		items = append(items, TaskItem{task: &quest.Tasks[i], blocked: blocked[quest.Tasks[i].ID]})
+	}
+	return items
+}
+
+// NewQuestDetailModel creates a new quest detail model
+func NewQuestDetailModel(store *domain.Store, questID string, keymap KeyMap) QuestDetailModel {
+	m := QuestDetailModel{
+		store:           store,
+		questID:         questID,
+		selectedTaskIdx: 0,
+		keymap:          keymap,
 	}
 
 	taskDelegate := newTaskDelegate(newDelegateKeyMap())
-	taskList := list.New(items, taskDelegate, 0, 0)
-	taskList.Title = "Tasks"
+	taskList := list.New(questItems(m.quest(), m.blocked()), taskDelegate, 0, 0)
+	taskList.Title = "Sub-quests & Tasks"
 	taskList.Styles.Title = titleStyle
 
 	// Set default size in case WindowSizeMsg is not received
 	taskList.SetSize(80, 15)
+	// "/" opens the global search instead
+	taskList.SetFilteringEnabled(false)
 
 	taskList.AdditionalFullHelpKeys = func() []key.Binding {
 		return []key.Binding{
@@ -289,14 +499,28 @@ func NewQuestDetailModel(projects []domain.Project, projIdx int, questIdx int, k
 		}
 	}
 
-	return QuestDetailModel{
-		projects:         projects,
-		selectedProjIdx:  projIdx,
-		selectedQuestIdx: questIdx,
-		selectedTaskIdx:  0,
-		keymap:           keymap,
-		taskList:         taskList,
+	m.taskList = taskList
+	return m
+}
+
+// quest returns the quest being displayed, or nil if none
+func (m QuestDetailModel) quest() *domain.Quest {
+	if m.store == nil || m.questID == "" {
+		return nil
+	}
+	q, err := m.store.Quest(m.questID)
+	if err != nil {
+		return nil
+	}
+	return q
+}
+
+// blocked returns the IDs of blocked quests and tasks
+func (m QuestDetailModel) blocked() map[string]bool {
+	if m.store == nil {
+		return nil
 	}
+	return domain.BlockedItems(m.store.Projects())
 }
 
 // Update handles messages for the quest detail
@@ -308,56 +532,23 @@ func (m QuestDetailModel) Update(msg tea.Msg) (QuestDetailModel, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			// Toggle selected task
-			if item, ok := m.taskList.SelectedItem().(TaskItem); ok && item.task != nil {
-				item.task.Done = !item.task.Done
-				// Update progress
-				if m.selectedProjIdx >= 0 && m.selectedQuestIdx >= 0 &&
-					m.selectedProjIdx < len(m.projects) &&
-					m.selectedQuestIdx < len(m.projects[m.selectedProjIdx].Quests) {
-					quest := &m.projects[m.selectedProjIdx].Quests[m.selectedQuestIdx]
-					quest.CalculateProgress()
-					m.projects[m.selectedProjIdx].CalculateProgress()
-					// Send save command
-					cmd = func() tea.Msg {
-						err := domain.SaveProjects(m.projects)
-						return SaveCompleteMsg{Err: err}
-					}
-				}
-				// Refresh task list items
-				var items []list.Item
-				if m.selectedProjIdx >= 0 && m.selectedQuestIdx >= 0 &&
-					m.selectedProjIdx < len(m.projects) &&
-					m.selectedQuestIdx < len(m.projects[m.selectedProjIdx].Quests) {
-					for i := range m.projects[m.selectedProjIdx].Quests[m.selectedQuestIdx].Tasks {
-						items = append(items, TaskItem{task: &m.projects[m.selectedProjIdx].Quests[m.selectedQuestIdx].Tasks[i]})
-					}
+			// Ask the root model to toggle and save the selected task
+			if id := m.SelectedTaskID(); id != "" {
+				cmd = func() tea.Msg {
+					return ItemToggleMsg{TaskID: id}
 				}
-				m.taskList.SetItems(items)
 			}
 		default:
 			// Delegate to list
 			m.taskList, cmd = m.taskList.Update(msg)
 		}
 	case DataChangedMsg:
-		m.projects = msg.Projects
-		// Keep indices valid
-		if m.selectedProjIdx >= len(m.projects) {
-			m.selectedProjIdx = -1
-			m.selectedQuestIdx = -1
-		} else if m.selectedQuestIdx >= len(m.projects[m.selectedProjIdx].Quests) {
-			m.selectedQuestIdx = -1
-		}
-		// Update items
-		var items []list.Item
-		if m.selectedProjIdx >= 0 && m.selectedQuestIdx >= 0 &&
-			m.selectedProjIdx < len(m.projects) &&
-			m.selectedQuestIdx < len(m.projects[m.selectedProjIdx].Quests) {
-			for i := range m.projects[m.selectedProjIdx].Quests[m.selectedQuestIdx].Tasks {
-				items = append(items, TaskItem{task: &m.projects[m.selectedProjIdx].Quests[m.selectedQuestIdx].Tasks[i]})
-			}
+		m.store = domain.NewStore(msg.Projects)
+		// Keep the selection valid
+		if m.quest() == nil {
+			m.questID = ""
 		}
-		m.taskList.SetItems(items)
+		m.taskList.SetItems(questItems(m.quest(), m.blocked()))
 	}
 	m.selectedTaskIdx = m.taskList.Index()
 	return m, cmd
@@ -365,17 +556,14 @@ func (m QuestDetailModel) Update(msg tea.Msg) (QuestDetailModel, tea.Cmd) {
 
 // View renders the quest detail
 func (m QuestDetailModel) View() string {
-	if m.selectedProjIdx < 0 || m.selectedQuestIdx < 0 ||
-		m.selectedProjIdx >= len(m.projects) ||
-		m.selectedQuestIdx >= len(m.projects[m.selectedProjIdx].Quests) {
+	quest := m.quest()
+	if quest == nil {
 		return "No quest selected."
 	}
 
-	quest := &m.projects[m.selectedProjIdx].Quests[m.selectedQuestIdx]
-
 	var b strings.Builder
 
-	b.WriteString(titleStyle.Render("Quest: " + quest.Title))
+	b.WriteString(titleStyle.Render("Quest: " + m.breadcrumb()))
 	b.WriteString("\n")
 	b.WriteString(quest.Description)
 	b.WriteString("\n\n")
@@ -388,6 +576,26 @@ func (m QuestDetailModel) View() string {
 	if quest.Deadline != nil {
 		b.WriteString(fmt.Sprintf("Deadline: %s\n", quest.Deadline.Format("2006-01-02")))
 	}
+	if quest.Recur != nil {
+		b.WriteString(fmt.Sprintf("Repeats: %s\n", quest.Recur))
+	}
+	if quest.Estimate > 0 {
+		b.WriteString("Estimate: " + domain.FormatEstimate(quest.Estimate) + "\n")
+	} else if effort := quest.Effort(); effort > 0 {
+		b.WriteString("Estimate: " + domain.FormatEstimate(effort) + " (from tasks)\n")
+	}
+	if len(quest.Tags) > 0 {
+		b.WriteString("Tags: " + domain.HashTags(quest.Tags) + "\n")
+	}
+	if spent := quest.TimeSpent(time.Now()); spent > 0 {
+		b.WriteString("Time logged: " + domain.FormatDuration(spent) + "\n")
+	}
+	if blockers, _ := m.store.QuestBlockers(quest.ID); len(blockers) > 0 {
+		b.WriteString("Blocked by: " + questNames(blockers, true) + "\n")
+	}
+	if dependents, _ := m.store.QuestDependents(quest.ID); len(dependents) > 0 {
+		b.WriteString("Unblocks: " + questNames(dependents, false) + "\n")
+	}
 
 	b.WriteString("\n\n")
 
@@ -396,9 +604,67 @@ func (m QuestDetailModel) View() string {
 	return b.String()
 }
 
-// SelectedTaskIndex returns the selected task index
-func (m QuestDetailModel) SelectedTaskIndex() int {
-	return m.taskList.Index()
+// questNames joins quest titles, marking finished ones with ✓ when withState is set
+func questNames(quests []domain.Quest, withState bool) string {
+	names := make([]string, len(quests))
+	for i, q := range quests {
+		names[i] = q.Title
+		if withState && !q.State.IsOpen() {
+			names[i] += " ✓"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// breadcrumb renders the titles from the top-level quest down to the current one
+func (m QuestDetailModel) breadcrumb() string {
+	var titles []string
+	for id := m.questID; id != ""; {
+		q, err := m.store.Quest(id)
+		if err != nil {
+			break
+		}
+		titles = append([]string{q.Title}, titles...)
+		id = ""
+		if parent, err := m.store.QuestParent(q.ID); err == nil && parent != nil {
+			id = parent.ID
+		}
+	}
+	return strings.Join(titles, " › ")
+}
+
+// SelectItem moves the cursor to the sub-quest or task with the given ID
+func (m *QuestDetailModel) SelectItem(id string) {
+	for i, item := range m.taskList.Items() {
+		switch item := item.(type) {
+		case TaskItem:
+			if item.task.ID == id {
+				m.taskList.Select(i)
+				return
+			}
+		case QuestItem:
+			if item.quest.ID == id {
+				m.taskList.Select(i)
+				return
+			}
+		}
+	}
+}
+
+// SelectedTaskID returns the ID of the selected task, or "" if a sub-quest is selected
+func (m QuestDetailModel) SelectedTaskID() string {
+	if item, ok := m.taskList.SelectedItem().(TaskItem); ok && item.task != nil {
+		return item.task.ID
+	}
+	return ""
+}
+
+// SelectedSubQuestID returns the ID of the selected sub-quest, or "" if a task is selected
+func (m QuestDetailModel) SelectedSubQuestID() string {
+	if item, ok := m.taskList.SelectedItem().(QuestItem); ok && item.quest != nil {
+		return item.quest.ID
+	}
+	return ""
 }
 
 // Styling for tasks
@@ -410,4 +676,14 @@ var (
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("1")).
 			Bold(true)
+
+	timerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#25A065")).
+			Bold(true)
+
+	toastStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#d90368")).
+			Padding(0, 1).
+			Bold(true)
 )
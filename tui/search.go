@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"quest_line/domain"
+)
+
+// maxSearchResults caps how many matches the overlay shows
+const maxSearchResults = 15
+
+// SearchModel is the global fuzzy search overlay
+type SearchModel struct {
+	projects    []domain.Project
+	input       textinput.Model
+	results     []domain.SearchResult
+	selectedIdx int
+}
+
+// NewSearchModel creates an empty search over the given projects
+func NewSearchModel(projects []domain.Project) SearchModel {
+	input := textinput.New()
+	input.Placeholder = "Search projects, quests and tasks"
+	input.Prompt = "/ "
+	input.Focus()
+	return SearchModel{projects: projects, input: input}
+}
+
+// Update handles typing and moving through the results
+func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "up", "ctrl+p", "ctrl+k":
+			if m.selectedIdx > 0 {
+				m.selectedIdx--
+			}
+			return m, nil
+		case "down", "ctrl+n", "ctrl+j":
+			if m.selectedIdx < len(m.visibleResults())-1 {
+				m.selectedIdx++
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	query := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != query {
+		m.results = domain.Search(m.projects, m.input.Value())
+		m.selectedIdx = 0
+	}
+	return m, cmd
+}
+
+// visibleResults returns the results shown in the overlay
+func (m SearchModel) visibleResults() []domain.SearchResult {
+	if len(m.results) > maxSearchResults {
+		return m.results[:maxSearchResults]
+	}
+	return m.results
+}
+
+// Selected returns the highlighted result, if any
+func (m SearchModel) Selected() (domain.SearchResult, bool) {
+	results := m.visibleResults()
+	if m.selectedIdx >= 0 && m.selectedIdx < len(results) {
+		return results[m.selectedIdx], true
+	}
+	return domain.SearchResult{}, false
+}
+
+// View renders the search box and its results
+func (m SearchModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Search"))
+	b.WriteString("\n\n")
+	b.WriteString(m.input.View())
+	b.WriteString("\n\n")
+
+	results := m.visibleResults()
+	if m.input.Value() != "" && len(results) == 0 {
+		b.WriteString("No matches.\n")
+	}
+	for i, r := range results {
+		line := fmt.Sprintf("%-7s %s", r.Kind, highlightMatches(r.Text, r.MatchedIndexes))
+		if i == m.selectedIdx {
+			line = selectedStyle.Render(fmt.Sprintf("%-7s %s", r.Kind, r.Text))
+		}
+		b.WriteString(line + "\n")
+		b.WriteString(searchPathStyle.Render("        "+r.Path) + "\n")
+	}
+	if len(m.results) > len(results) {
+		b.WriteString(fmt.Sprintf("\n…and %d more\n", len(m.results)-len(results)))
+	}
+	return b.String()
+}
+
+// highlightMatches renders the matched bytes of text in the match style
+func highlightMatches(text string, indexes []int) string {
+	matched := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		matched[i] = true
+	}
+	var b strings.Builder
+	for i, r := range text {
+		if matched[i] {
+			b.WriteString(matchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var (
+	matchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#d90368")).
+			Bold(true)
+
+	searchPathStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+)
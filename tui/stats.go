@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"quest_line/domain"
+)
+
+// How far back the stats screen looks
+const (
+	statsDays  = 14 // days in the daily chart
+	statsWeeks = 8  // weeks in the weekly chart and the burndowns
+)
+
+// statsBarWidth is the longest a bar of the completion charts gets
+const statsBarWidth = 40
+
+// sparkLevels are the cells of a sparkline, lowest first
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// StatsModel charts completions, burndowns and overdue counts from the
+// event logs of one project, or of every project
+type StatsModel struct {
+	projectName string
+	report      domain.Report
+	width       int
+}
+
+// NewStatsModel builds the report of a project, or of every project when
+// projectID is empty
+func NewStatsModel(projects []domain.Project, projectID string, width int) StatsModel {
+	now := time.Now()
+	today := domain.Day(now)
+	from := domain.WeekStart(today).AddDate(0, 0, -7*(statsWeeks-1))
+	m := StatsModel{report: domain.BuildReport(projects, projectID, from, today, now), width: width}
+	for _, p := range projects {
+		if p.ID == projectID {
+			m.projectName = p.Name
+		}
+	}
+	return m
+}
+
+// barChart draws one labelled bar per count, scaled to the largest
+func barChart(counts []domain.DayCount, label func(time.Time) string) string {
+	most := 0
+	for _, c := range counts {
+		if c.Count > most {
+			most = c.Count
+		}
+	}
+	var b strings.Builder
+	for _, c := range counts {
+		length := 0
+		if most > 0 {
+			length = (c.Count*statsBarWidth + most - 1) / most
+		}
+		b.WriteString(fmt.Sprintf("  %s %s %d\n", label(c.Day), timerStyle.Render(strings.Repeat("█", length)), c.Count))
+	}
+	return b.String()
+}
+
+// sparkline draws counts as one cell each, scaled from zero to the largest
+func sparkline(counts []domain.DayCount) string {
+	most := 0
+	for _, c := range counts {
+		if c.Count > most {
+			most = c.Count
+		}
+	}
+	var b strings.Builder
+	for _, c := range counts {
+		level := 0
+		if most > 0 {
+			level = c.Count * (len(sparkLevels) - 1) / most
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// View renders the charts and counts
+func (m StatsModel) View() string {
+	var b strings.Builder
+	r := m.report
+
+	title := "Stats"
+	if m.projectName != "" {
+		title += " - " + m.projectName
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	days := r.CompletedPerDay
+	if len(days) > statsDays {
+		days = days[len(days)-statsDays:]
+	}
+	b.WriteString(columnHeaderStyle.Render("Tasks completed per day") + "\n")
+	b.WriteString(barChart(days, func(day time.Time) string { return day.Format("Mon Jan 02") }))
+	b.WriteString("\n")
+	b.WriteString(columnHeaderStyle.Render("Tasks completed per week") + "\n")
+	b.WriteString(barChart(r.CompletedPerWeek, func(day time.Time) string { return "    " + day.Format("Jan 02") }))
+	b.WriteString("\n")
+
+	b.WriteString(columnHeaderStyle.Render("Open tasks per day") + "\n")
+	if len(r.Projects) == 0 {
+		b.WriteString("  No projects yet.\n")
+	}
+	// Keep each sparkline to the last days that fit beside its label
+	labelWidth := 0
+	for _, p := range r.Projects {
+		if w := lipgloss.Width(p.Project); w > labelWidth {
+			labelWidth = w
+		}
+	}
+	if labelWidth > timelineLabelWidth {
+		labelWidth = timelineLabelWidth
+	}
+	width := m.width
+	if width <= 0 {
+		width = defaultBoardWidth
+	}
+	spark := width - 4 - labelWidth - 28
+	for _, p := range r.Projects {
+		points := p.Burndown
+		if spark > 0 && len(points) > spark {
+			points = points[len(points)-spark:]
+		}
+		line := fmt.Sprintf("  %-*s ", labelWidth, truncate(p.Project, labelWidth)) + timerStyle.Render(sparkline(points))
+		if len(points) > 0 {
+			line += fmt.Sprintf(" %d → %d since %s", points[0].Count, points[len(points)-1].Count, points[0].Day.Format("Jan 2"))
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+
+	if r.QuestsMeasured > 0 {
+		b.WriteString(fmt.Sprintf("Average quest time: %s over %d completed quests\n", domain.FormatSpan(r.AvgQuestTime), r.QuestsMeasured))
+	} else {
+		b.WriteString("Average quest time: no completed quests yet\n")
+	}
+	overdue := fmt.Sprintf("Overdue: %d quests, %d tasks", r.OverdueQuests, r.OverdueTasks)
+	if r.OverdueQuests+r.OverdueTasks > 0 {
+		overdue = errorStyle.Render(overdue)
+	}
+	b.WriteString(overdue + "\n")
+	if m.projectName == "" {
+		for _, p := range r.Projects {
+			if p.OverdueQuests+p.OverdueTasks > 0 {
+				b.WriteString(searchPathStyle.Render(fmt.Sprintf("  %s: %d quests, %d tasks", p.Project, p.OverdueQuests, p.OverdueTasks)) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,305 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"quest_line/domain"
+)
+
+// timelineLabelWidth is the widest the quest titles left of the bars get
+const timelineLabelWidth = 28
+
+// timelineRow is a project heading or a quest bar of the timeline
+type timelineRow struct {
+	project string // set for headings
+	bar     domain.TimelineBar
+}
+
+// TimelineModel draws the open quests of every project as bars from their
+// start to their deadline, a week at a time
+type TimelineModel struct {
+	rows     []timelineRow
+	bars     []int // indexes of the bar rows
+	selected int   // index into bars
+	offset   int   // weeks scrolled from the default window
+	today    time.Time
+	width    int
+	height   int
+}
+
+// NewTimelineModel lays out the timeline of every project
+func NewTimelineModel(projects []domain.Project, width, height int) TimelineModel {
+	now := time.Now()
+	m := TimelineModel{today: domain.Day(now), width: width, height: height}
+	for _, group := range domain.Timeline(projects, "", now) {
+		m.rows = append(m.rows, timelineRow{project: group.Project})
+		for _, bar := range group.Bars {
+			m.bars = append(m.bars, len(m.rows))
+			m.rows = append(m.rows, timelineRow{bar: bar})
+		}
+	}
+	return m
+}
+
+// Update selects bars and scrolls the window by a week
+func (m TimelineModel) Update(msg tea.Msg) (TimelineModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down", "j":
+			if m.selected < len(m.bars)-1 {
+				m.selected++
+			}
+		case "left":
+			m.offset--
+		case "right":
+			m.offset++
+		case ".":
+			m.offset = 0
+		}
+	}
+	return m, nil
+}
+
+// SelectedQuestID returns the quest of the selected bar
+func (m TimelineModel) SelectedQuestID() string {
+	if m.selected < 0 || m.selected >= len(m.bars) {
+		return ""
+	}
+	return m.rows[m.bars[m.selected]].bar.QuestID
+}
+
+// window returns the first day shown, the number of days that fit and the
+// width of the title column. The default window starts the week before
+// this one.
+func (m TimelineModel) window() (first time.Time, days, labelWidth int) {
+	width := m.width
+	if width <= 0 {
+		width = defaultBoardWidth
+	}
+	// appStyle pads the screen by 2 cells on each side
+	width -= 4
+	labelWidth = width / 3
+	if labelWidth > timelineLabelWidth {
+		labelWidth = timelineLabelWidth
+	}
+	days = width - labelWidth - 1
+	if days < 7 {
+		days = 7
+	}
+	first = domain.WeekStart(m.today).AddDate(0, 0, 7*(m.offset-1))
+	return first, days, labelWidth
+}
+
+// visibleRows returns the first row shown and how many fit, keeping the
+// selected bar in view
+func (m TimelineModel) visibleRows() (first, count int) {
+	count = len(m.rows)
+	if m.height <= 0 {
+		return 0, count
+	}
+	// Leave room for the title, date headers, legend, status lines and help
+	fit := m.height - 12
+	if fit < 3 {
+		fit = 3
+	}
+	if count <= fit {
+		return 0, count
+	}
+	if len(m.bars) > 0 {
+		if row := m.bars[m.selected]; row >= fit {
+			first = row - fit + 1
+		}
+	}
+	return first, fit
+}
+
+// cellRun collects cells of the same style so each run is rendered once
+type cellRun struct {
+	b     strings.Builder
+	style *lipgloss.Style
+	text  strings.Builder
+}
+
+// add appends a cell drawn in style, nil for plain text
+func (r *cellRun) add(cell string, style *lipgloss.Style) {
+	if style != r.style {
+		r.flush()
+		r.style = style
+	}
+	r.text.WriteString(cell)
+}
+
+// flush renders the pending run
+func (r *cellRun) flush() {
+	if r.text.Len() == 0 {
+		return
+	}
+	if r.style != nil {
+		r.b.WriteString(r.style.Render(r.text.String()))
+	} else {
+		r.b.WriteString(r.text.String())
+	}
+	r.text.Reset()
+}
+
+// String returns everything added
+func (r *cellRun) String() string {
+	r.flush()
+	return r.b.String()
+}
+
+// renderBar draws one quest's cells from first for days days. The part of
+// the bar matching its progress is solid; an overdue bar is drawn in the
+// warning colour and trails on to today. Blockers show at their deadlines.
+func (m TimelineModel) renderBar(bar domain.TimelineBar, first time.Time, days int) string {
+	markers := make(map[time.Time]string)
+	for _, dep := range bar.BlockedBy {
+		if dep.Deadline != nil {
+			marker := "◆"
+			if dep.Done {
+				marker = "◇"
+			}
+			markers[domain.Day(*dep.Deadline)] = marker
+		}
+	}
+	length := int(bar.End.Sub(bar.Start).Hours()/24) + 1
+	done := int(float64(length) * bar.Progress / 100)
+	style := &timerStyle
+	if bar.Overdue {
+		style = &errorStyle
+	}
+	var r cellRun
+	for i := 0; i < days; i++ {
+		day := first.AddDate(0, 0, i)
+		switch {
+		case markers[day] != "":
+			r.add(markers[day], &dependencyStyle)
+		case !day.Before(bar.Start) && !day.After(bar.End):
+			cell := "▒"
+			if int(day.Sub(bar.Start).Hours()/24) < done {
+				cell = "█"
+			}
+			if i == 0 && day.After(bar.Start) {
+				cell = "◀"
+			} else if i == days-1 && day.Before(bar.End) {
+				cell = "▶"
+			}
+			r.add(cell, style)
+		case bar.Overdue && day.After(bar.End) && day.Before(m.today):
+			r.add("░", &errorStyle)
+		case day.Equal(m.today):
+			r.add("│", &todayStyle)
+		case day.Weekday() == time.Monday:
+			r.add("·", &searchPathStyle)
+		default:
+			r.add(" ", nil)
+		}
+	}
+	return r.String()
+}
+
+// header returns the date and tick lines above the bars
+func (m TimelineModel) header(first time.Time, days int) (dates, ticks string) {
+	line := []rune(strings.Repeat(" ", days))
+	var r cellRun
+	for i := 0; i < days; i++ {
+		day := first.AddDate(0, 0, i)
+		if day.Weekday() == time.Monday {
+			label := []rune(day.Format("Jan 2"))
+			if i+len(label) <= days {
+				copy(line[i:], label)
+			}
+		}
+		switch {
+		case day.Equal(m.today):
+			r.add("▼", &todayStyle)
+		case day.Weekday() == time.Monday:
+			r.add("|", &searchPathStyle)
+		default:
+			r.add(" ", nil)
+		}
+	}
+	return string(line), r.String()
+}
+
+// View renders the project headings and quest bars
+func (m TimelineModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Timeline"))
+	b.WriteString("\n\n")
+	if len(m.bars) == 0 {
+		b.WriteString("No open quests with deadlines.\n")
+		b.WriteString("Give a quest a deadline to see it here.\n")
+		return b.String()
+	}
+
+	first, days, labelWidth := m.window()
+	label := lipgloss.NewStyle().Width(labelWidth + 1)
+	dates, ticks := m.header(first, days)
+	b.WriteString(label.Render("") + dates + "\n")
+	b.WriteString(label.Render("") + ticks + "\n")
+
+	from, count := m.visibleRows()
+	selectedRow := -1
+	if len(m.bars) > 0 {
+		selectedRow = m.bars[m.selected]
+	}
+	for i := from; i < from+count; i++ {
+		row := m.rows[i]
+		if row.project != "" {
+			b.WriteString(columnHeaderStyle.Render(truncate(row.project, labelWidth)) + "\n")
+			continue
+		}
+		title := truncate("  "+row.bar.Title, labelWidth)
+		if i == selectedRow {
+			title = selectedStyle.Render(title)
+		} else if row.bar.Overdue {
+			title = errorStyle.Render(title)
+		}
+		b.WriteString(label.Render(title) + m.renderBar(row.bar, first, days) + "\n")
+	}
+	if rest := len(m.rows) - from - count; rest > 0 {
+		b.WriteString(searchPathStyle.Render(fmt.Sprintf("↓ %d more", rest)) + "\n")
+	}
+
+	b.WriteString("\n")
+	bar := m.rows[selectedRow].bar
+	detail := fmt.Sprintf("%s: %s → %s, %.0f%% complete", bar.Title, bar.Start.Format("2006-01-02"), bar.End.Format("2006-01-02"), bar.Progress)
+	if bar.Overdue {
+		detail += " - overdue"
+	}
+	b.WriteString(detail + "\n")
+	for _, dep := range bar.BlockedBy {
+		due := "no deadline"
+		if dep.Deadline != nil {
+			due = "due " + dep.Deadline.Format("2006-01-02")
+		}
+		state := "waits on"
+		if dep.Done {
+			state = "waited on"
+		}
+		b.WriteString(searchPathStyle.Render(fmt.Sprintf("  %s %s (%s)", state, dep.Title, due)) + "\n")
+	}
+	b.WriteString(searchPathStyle.Render("█ done  ▒ to do  ░ overdue  ◆ open blocker's deadline  ◇ finished blocker's deadline  │ today") + "\n")
+	return b.String()
+}
+
+// Styling for the timeline
+var (
+	todayStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#d90368")).
+			Bold(true)
+
+	dependencyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFB000")).
+			Bold(true)
+)
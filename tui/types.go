@@ -2,6 +2,8 @@ package tui
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -24,6 +26,19 @@ const (
 	ViewEditQuest
 	ViewCreateTask
 	ViewEditTask
+	ViewCreateSubQuest
+	ViewEditSubQuest
+	ViewFinished
+	ViewSearch
+	ViewTagFilter
+	ViewBlockers
+	ViewCharacter
+	ViewAchievements
+	ViewBoard
+	ViewCalendar
+	ViewTimeline
+	ViewStats
+	ViewActivity
 )
 
 // ProjectItem represents a project in the list
@@ -48,7 +63,8 @@ func (p ProjectItem) Description() string {
 
 // QuestItem represents a quest in the list
 type QuestItem struct {
-	quest *domain.Quest
+	quest   *domain.Quest
+	blocked bool
 }
 
 // FilterValue returns the value to filter by
@@ -63,12 +79,37 @@ func (q QuestItem) Title() string {
 
 // Description returns the description
 func (q QuestItem) Description() string {
-	return fmt.Sprintf("%s\n%.1f%% complete - %d tasks", q.quest.Description, q.quest.Progress, len(q.quest.Tasks))
+	var summary string
+	switch {
+	case !q.quest.State.IsOpen():
+		summary = fmt.Sprintf("%s - %.1f%% complete", q.quest.State, q.quest.Progress)
+	case !q.quest.IsLeaf():
+		summary = fmt.Sprintf("%.1f%% complete - %d sub-quests, %d tasks", q.quest.Progress, len(q.quest.SubQuests), len(q.quest.Tasks))
+	default:
+		summary = fmt.Sprintf("%.1f%% complete - %d tasks", q.quest.Progress, len(q.quest.Tasks))
+	}
+	if q.quest.State == domain.StateInProgress {
+		summary = "In Progress - " + summary
+	}
+	if q.blocked && q.quest.State.IsOpen() {
+		summary = "Blocked - " + summary
+	}
+	if q.quest.Recur != nil {
+		summary += " - repeats " + q.quest.Recur.String()
+	}
+	if effort := q.quest.Effort(); effort > 0 {
+		summary += " - est " + domain.FormatEstimate(effort)
+	}
+	if len(q.quest.Tags) > 0 {
+		summary += " " + domain.HashTags(q.quest.Tags)
+	}
+	return q.quest.Description + "\n" + summary
 }
 
 // TaskItem represents a task in the list
 type TaskItem struct {
-	task *domain.Task
+	task    *domain.Task
+	blocked bool
 }
 
 // FilterValue returns the value to filter by
@@ -85,9 +126,46 @@ func (t TaskItem) Title() string {
 	return fmt.Sprintf("%s %s", status, t.task.Description)
 }
 
-// Description returns the description
+// Description returns the task's priority, deadline, estimate, notes and tags
 func (t TaskItem) Description() string {
-	return ""
+	var parts []string
+	if t.blocked && !t.task.Done {
+		parts = append(parts, "Blocked")
+	}
+	if t.task.Priority != 0 {
+		parts = append(parts, fmt.Sprintf("Priority %d", t.task.Priority))
+	}
+	if t.task.Deadline != nil {
+		due := "Due " + t.task.Deadline.Format("2006-01-02")
+		if !t.task.Done && domain.IsOverdue(t.task.Deadline, time.Now()) {
+			due += " (overdue)"
+		}
+		parts = append(parts, due)
+	}
+	if t.task.Estimate != 0 {
+		parts = append(parts, "Est "+domain.FormatEstimate(t.task.Estimate))
+	}
+	if t.task.Recur != nil {
+		parts = append(parts, "Repeats "+t.task.Recur.String())
+	}
+	if len(t.task.TimeEntries) > 0 {
+		logged := "Logged " + domain.FormatDuration(t.task.TimeSpent(time.Now()))
+		if t.task.Running() != nil {
+			logged += " ⏱"
+		}
+		parts = append(parts, logged)
+	}
+	if t.task.Notes != "" {
+		note, _, more := strings.Cut(t.task.Notes, "\n")
+		if more {
+			note += " …"
+		}
+		parts = append(parts, "Notes: "+note)
+	}
+	if len(t.task.Tags) > 0 {
+		parts = append(parts, domain.HashTags(t.task.Tags))
+	}
+	return strings.Join(parts, " · ")
 }
 
 // listKeyMap defines keys for the list
@@ -1,21 +1,43 @@
 package tui
 
 import (
+	"time"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"quest_line/domain"
 )
 
 func (m RootModel) Init() tea.Cmd {
+	if m.ticking {
+		return tickTimer()
+	}
 	return nil
 }
 
+// Update handles a message, then announces any achievements it unlocked
 func (m *RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if toast := m.queueToasts(); toast != nil {
+		return model, tea.Batch(cmd, toast)
+	}
+	return model, cmd
+}
+
+func (m *RootModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		// Update screen models with new size if needed
-		// For now, assume fixed size
+		// The board lays its columns out to fit; other screens use a fixed size
+		m.width, m.height = msg.Width, msg.Height
+		m.board.width, m.board.height = msg.Width, msg.Height
+		m.calendar.width, m.calendar.height = msg.Width, msg.Height
+		m.timeline.width, m.timeline.height = msg.Width, msg.Height
+		m.stats.width = msg.Width
+		m.activity.height = msg.Height
 	case tea.KeyMsg:
+		// A new key press dismisses the last error and status
+		m.errorMsg = ""
+		m.statusMsg = ""
 		// Handle form view
 		if m.inForm {
 			return m.handleFormInput(msg)
@@ -24,9 +46,15 @@ func (m *RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.pendingDelete {
 			return m.handleDeleteConfirmation(msg)
 		}
+		// Handle the search overlay, which takes all typing
+		if m.currentView == ViewSearch {
+			return m.handleSearchInput(msg)
+		}
 		// Global quit handler
 		if key.Matches(msg, m.keymap.Quit) {
-			m.saveProjects()
+			// Written by Flush once the program exits, after any saves
+			// still queued
+			m.queueSave()
 			return m, tea.Quit
 		}
 		// Help toggle
@@ -34,6 +62,19 @@ func (m *RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.help.ToggleHelp()
 			return m, nil
 		}
+		if key.Matches(msg, m.keymap.Search) {
+			m.searchReturn = m.currentView
+			m.search = NewSearchModel(m.store.Projects())
+			m.currentView = ViewSearch
+			return m, nil
+		}
+		// Undo and redo work in every view
+		if key.Matches(msg, m.keymap.Undo) {
+			return m, m.replay("Undid", m.store.Undo)
+		}
+		if key.Matches(msg, m.keymap.Redo) {
+			return m, m.replay("Redid", m.store.Redo)
+		}
 		// Handle view-specific keys
 		return m.handleViewSpecificInput(msg)
 	case SaveCompleteMsg:
@@ -45,10 +86,26 @@ func (m *RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update screen models with data changed
 		m.updateScreenModels()
 		return m, nil
+	case ItemToggleMsg:
+		m.setError(m.store.ToggleTask(msg.TaskID))
+		m.updateScreenModels()
+		return m, m.saveProjectsCmd()
 	case DataChangedMsg:
-		m.projects = msg.Projects
+		m.store = domain.NewStore(msg.Projects)
 		m.updateScreenModels()
 		return m, nil
+	case TimerTickMsg:
+		if hasRunningTimer(m.store.Projects()) {
+			return m, tickTimer()
+		}
+		m.ticking = false
+		return m, nil
+	case ToastExpiredMsg:
+		if msg.Count > len(m.toasts) {
+			msg.Count = len(m.toasts)
+		}
+		m.toasts = m.toasts[msg.Count:]
+		return m, nil
 	}
 	return m, nil
 }
@@ -59,7 +116,7 @@ func (m *RootModel) handleFormInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	if msg.String() == "enter" {
+	if msg.String() == "enter" && !m.form.InNotes() {
 		if m.form.focusIdx < len(m.form.inputs)-1 {
 			m.form.nextInput()
 			return m, nil
@@ -80,6 +137,37 @@ func (m *RootModel) handleFormInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m *RootModel) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.currentView = m.searchReturn
+		return m, nil
+	case "enter":
+		if result, ok := m.search.Selected(); ok {
+			m.openSearchResult(result)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	return m, cmd
+}
+
+// openSearchResult jumps to the quest detail for a quest or task match, or
+// to the dashboard of a matched project
+func (m *RootModel) openSearchResult(result domain.SearchResult) {
+	if result.QuestID == "" {
+		m.selectedProjectID = result.ProjectID
+		m.navigateTo(ViewDashboard)
+		return
+	}
+	m.openQuest(result.QuestID)
+	m.navigateTo(ViewQuestDetail)
+	if result.TaskID != "" {
+		m.taskList.SelectItem(result.TaskID)
+	}
+}
+
 func (m *RootModel) handleDeleteConfirmation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y", "Y":
@@ -97,9 +185,8 @@ func (m *RootModel) handleViewSpecificInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 	switch m.currentView {
 	case ViewProjectSelection:
 		if msg.String() == "enter" {
-			selectedIdx := m.projectSelection.SelectedIndex()
-			if selectedIdx >= 0 && selectedIdx < len(m.projects) {
-				m.selectedProjectIdx = selectedIdx
+			if id := m.projectSelection.SelectedProjectID(); id != "" {
+				m.selectedProjectID = id
 				m.navigateTo(ViewDashboard)
 			}
 			return m, nil
@@ -110,62 +197,77 @@ func (m *RootModel) handleViewSpecificInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 	case ViewDashboard:
 		switch {
 		case key.Matches(msg, m.keymap.Create):
-			if m.selectedProjectIdx >= 0 {
+			if m.selectedProjectID != "" {
 				m.startCreateQuest()
 			} else {
 				m.startCreateProject()
 			}
 			return m, nil
 		case key.Matches(msg, m.keymap.Edit):
-			selectedIdx := m.dashboard.SelectedIndex()
-			var activeQuests []domain.Quest
-			if m.selectedProjectIdx >= 0 {
-				activeQuests = domain.DailyPlannerForProject(m.projects, m.selectedProjectIdx)
-			} else {
-				activeQuests = domain.DailyPlanner(m.projects)
-			}
-			if selectedIdx >= 0 && selectedIdx < len(activeQuests) {
-				questID := activeQuests[selectedIdx].ID
-				pIdx, qIdx := domain.FindQuestIndices(m.projects, questID)
-				if pIdx >= 0 && qIdx >= 0 {
-					m.selectedProjectIdx = pIdx
-					m.selectedQuestIdx = qIdx
-					m.startEditQuest()
-				}
+			if id := m.dashboard.SelectedQuestID(); id != "" {
+				m.openQuest(id)
+				m.startEditQuest()
 			}
 			return m, nil
 		case key.Matches(msg, m.keymap.Delete):
-			selectedIdx := m.dashboard.SelectedIndex()
-			var activeQuests []domain.Quest
-			if m.selectedProjectIdx >= 0 {
-				activeQuests = domain.DailyPlannerForProject(m.projects, m.selectedProjectIdx)
-			} else {
-				activeQuests = domain.DailyPlanner(m.projects)
+			if id := m.dashboard.SelectedQuestID(); id != "" {
+				// Confirm delete
+				m.pendingDelete = true
+				m.deleteType = domain.KindQuest
+				m.deleteID = id
 			}
-			if selectedIdx >= 0 && selectedIdx < len(activeQuests) {
-				questID := activeQuests[selectedIdx].ID
-				pIdx, qIdx := domain.FindQuestIndices(m.projects, questID)
-				if pIdx >= 0 && qIdx >= 0 {
-					// Confirm delete
-					m.pendingDelete = true
-					m.deleteType = "quest"
-					m.deleteIndices = [3]int{pIdx, qIdx, -1}
-				}
+			return m, nil
+		case key.Matches(msg, m.keymap.Complete):
+			return m, m.changeQuestState(m.dashboard.SelectedQuestID(), m.store.CompleteQuest)
+		case key.Matches(msg, m.keymap.Abandon):
+			return m, m.changeQuestState(m.dashboard.SelectedQuestID(), m.store.CancelQuest)
+		case key.Matches(msg, m.keymap.Finished):
+			m.navigateTo(ViewFinished)
+			return m, nil
+		case key.Matches(msg, m.keymap.TagFilter):
+			m.startTagFilter()
+			return m, nil
+		case key.Matches(msg, m.keymap.Projects):
+			m.navigateTo(ViewProjectList)
+			return m, nil
+		case key.Matches(msg, m.keymap.Board):
+			m.openBoard(m.selectedProjectID)
+			return m, nil
+		case key.Matches(msg, m.keymap.Calendar):
+			m.navigateTo(ViewCalendar)
+			return m, nil
+		case key.Matches(msg, m.keymap.Timeline):
+			m.navigateTo(ViewTimeline)
+			return m, nil
+		case key.Matches(msg, m.keymap.Stats):
+			m.navigateTo(ViewStats)
+			return m, nil
+		case key.Matches(msg, m.keymap.Activity):
+			m.openActivity(m.selectedProjectID, "")
+			return m, nil
+		case key.Matches(msg, m.keymap.Character):
+			m.navigateTo(ViewCharacter)
+			return m, nil
+		case key.Matches(msg, m.keymap.Achievements):
+			m.navigateTo(ViewAchievements)
+			return m, nil
+		}
+		if key.Matches(msg, m.keymap.Toggle) {
+			if t, ok := m.dashboard.SelectedTask(); ok {
+				m.setError(m.store.ToggleTask(t.Task.ID))
+				m.updateScreenModels()
+				return m, m.saveProjectsCmd()
 			}
 			return m, nil
 		}
 		if msg.String() == "enter" {
-			selectedIdx := m.dashboard.SelectedIndex()
-			activeQuests := domain.DailyPlanner(m.projects)
-			if selectedIdx >= 0 && selectedIdx < len(activeQuests) {
-				questID := activeQuests[selectedIdx].ID
-				pIdx, qIdx := domain.FindQuestIndices(m.projects, questID)
-				if pIdx >= 0 && qIdx >= 0 {
-					m.selectedProjectIdx = pIdx
-					m.selectedQuestIdx = qIdx
-					m.updateTaskList()
-					m.navigateTo(ViewQuestDetail)
-				}
+			if t, ok := m.dashboard.SelectedTask(); ok {
+				m.openQuest(t.QuestID)
+				m.navigateTo(ViewQuestDetail)
+				m.taskList.SelectItem(t.Task.ID)
+			} else if id := m.dashboard.SelectedQuestID(); id != "" {
+				m.openQuest(id)
+				m.navigateTo(ViewQuestDetail)
 			}
 			return m, nil
 		}
@@ -173,12 +275,30 @@ func (m *RootModel) handleViewSpecificInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		var cmd tea.Cmd
 		m.dashboard, cmd = m.dashboard.Update(msg)
 		return m, cmd
+	case ViewFinished:
+		switch {
+		case key.Matches(msg, m.keymap.Reopen):
+			return m, m.changeQuestState(m.finished.SelectedQuestID(), m.store.ReopenQuest)
+		case key.Matches(msg, m.keymap.Archive):
+			return m, m.changeQuestState(m.finished.SelectedQuestID(), m.store.ArchiveQuest)
+		case key.Matches(msg, m.keymap.Dashboard), key.Matches(msg, m.keymap.Back):
+			m.navigateTo(ViewDashboard)
+			return m, nil
+		}
+		if msg.String() == "enter" {
+			if id := m.finished.SelectedQuestID(); id != "" {
+				m.openQuest(id)
+				m.navigateTo(ViewQuestDetail)
+			}
+			return m, nil
+		}
+		m.finished, cmd = m.finished.Update(msg)
+		return m, cmd
 	case ViewProjectList:
 		switch {
 		case key.Matches(msg, m.keymap.Create):
-			selectedIdx := m.projectList.SelectedIndex()
-			if selectedIdx >= 0 && selectedIdx < len(m.projects) {
-				m.selectedProjectIdx = selectedIdx
+			if id := m.projectList.SelectedProjectID(); id != "" {
+				m.selectedProjectID = id
 				m.startCreateQuest()
 			} else {
 				m.startCreateProject()
@@ -190,6 +310,55 @@ func (m *RootModel) handleViewSpecificInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		case key.Matches(msg, m.keymap.Delete):
 			m.startDeleteProject()
 			return m, nil
+		case key.Matches(msg, m.keymap.AutoComplete):
+			if project := m.projectList.SelectedProject(); project != nil {
+				m.setError(m.store.SetAutoComplete(project.ID, !project.AutoComplete))
+				m.updateScreenModels()
+				return m, m.saveProjectsCmd()
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.ProgressMode):
+			if project := m.projectList.SelectedProject(); project != nil {
+				mode := domain.ProgressByEffort
+				if project.ProgressMode == domain.ProgressByEffort {
+					mode = domain.ProgressByCount
+				}
+				m.setError(m.store.SetProgressMode(project.ID, mode))
+				m.updateScreenModels()
+				return m, m.saveProjectsCmd()
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.Board):
+			if project := m.projectList.SelectedProject(); project != nil {
+				m.openBoard(project.ID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.Calendar):
+			if project := m.projectList.SelectedProject(); project != nil {
+				m.selectedProjectID = project.ID
+			}
+			m.navigateTo(ViewCalendar)
+			return m, nil
+		case key.Matches(msg, m.keymap.Timeline):
+			m.navigateTo(ViewTimeline)
+			return m, nil
+		case key.Matches(msg, m.keymap.Stats):
+			if project := m.projectList.SelectedProject(); project != nil {
+				m.selectedProjectID = project.ID
+			}
+			m.navigateTo(ViewStats)
+			return m, nil
+		case key.Matches(msg, m.keymap.Activity):
+			if project := m.projectList.SelectedProject(); project != nil {
+				m.openActivity(project.ID, "")
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.Character):
+			m.navigateTo(ViewCharacter)
+			return m, nil
+		case key.Matches(msg, m.keymap.Achievements):
+			m.navigateTo(ViewAchievements)
+			return m, nil
 		case key.Matches(msg, m.keymap.Dashboard):
 			m.navigateTo(ViewDashboard)
 			return m, nil
@@ -197,13 +366,10 @@ func (m *RootModel) handleViewSpecificInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		}
 		// Handle selection
 		if msg.String() == "enter" {
-			selectedIdx := m.projectList.SelectedIndex()
-			if selectedIdx >= 0 && selectedIdx < len(m.projects) {
-				m.selectedProjectIdx = selectedIdx
-				project := m.projects[selectedIdx]
+			if project := m.projectList.SelectedProject(); project != nil {
+				m.selectedProjectID = project.ID
 				if len(project.Quests) > 0 {
-					m.selectedQuestIdx = 0
-					m.updateTaskList()
+					m.selectedQuestID = project.Quests[0].ID
 					m.navigateTo(ViewQuestDetail)
 				} else {
 					m.navigateTo(ViewDashboard)
@@ -223,29 +389,241 @@ func (m *RootModel) handleViewSpecificInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		case key.Matches(msg, m.keymap.Create):
 			m.startCreateTask()
 			return m, nil
+		case key.Matches(msg, m.keymap.SubQuest):
+			m.startCreateSubQuest()
+			return m, nil
 		case key.Matches(msg, m.keymap.Edit):
-			m.startEditTask()
+			if id := m.taskList.SelectedSubQuestID(); id != "" {
+				m.startEditSubQuest(id)
+			} else {
+				m.startEditTask()
+			}
 			return m, nil
 		case key.Matches(msg, m.keymap.Delete):
-			m.startDeleteTask()
+			if id := m.taskList.SelectedSubQuestID(); id != "" {
+				m.startDeleteSubQuest(id)
+			} else {
+				m.startDeleteTask()
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.Complete):
+			return m, m.changeQuestState(m.detailQuestID(), m.store.CompleteQuest)
+		case key.Matches(msg, m.keymap.Abandon):
+			return m, m.changeQuestState(m.detailQuestID(), m.store.CancelQuest)
+		case key.Matches(msg, m.keymap.Reopen):
+			return m, m.changeQuestState(m.detailQuestID(), m.store.ReopenQuest)
+		case key.Matches(msg, m.keymap.Block):
+			m.startBlockerPicker()
+			return m, nil
+		case key.Matches(msg, m.keymap.Timer):
+			return m, m.toggleTimer()
+		case key.Matches(msg, m.keymap.Activity):
+			m.openActivity("", m.detailQuestID())
+			return m, nil
+		case key.Matches(msg, m.keymap.Back):
+			m.leaveSubQuest()
 			return m, nil
 		case key.Matches(msg, m.keymap.Dashboard):
 			m.navigateTo(ViewDashboard)
 			return m, nil
 		}
+		if msg.String() == "enter" {
+			if id := m.taskList.SelectedSubQuestID(); id != "" {
+				m.selectedQuestID = id
+				m.updateTaskList()
+				return m, nil
+			}
+		}
 		m.taskList, cmd = m.taskList.Update(msg)
 		return m, cmd
+	case ViewBlockers:
+		switch {
+		case key.Matches(msg, m.keymap.Toggle), msg.String() == "enter":
+			return m, m.toggleBlocker()
+		case key.Matches(msg, m.keymap.Back):
+			m.navigateTo(ViewQuestDetail)
+			return m, nil
+		}
+		m.blockers, cmd = m.blockers.Update(msg)
+		return m, cmd
+	case ViewBoard:
+		switch {
+		case key.Matches(msg, m.keymap.MoveLeft):
+			return m, m.moveCard(-1)
+		case key.Matches(msg, m.keymap.MoveRight):
+			return m, m.moveCard(1)
+		case key.Matches(msg, m.keymap.Dashboard), key.Matches(msg, m.keymap.Back):
+			m.navigateTo(ViewDashboard)
+			return m, nil
+		case key.Matches(msg, m.keymap.Projects):
+			m.navigateTo(ViewProjectList)
+			return m, nil
+		}
+		if msg.String() == "enter" {
+			if id := m.board.SelectedQuestID(); id != "" {
+				m.openQuest(id)
+				m.navigateTo(ViewQuestDetail)
+			}
+			return m, nil
+		}
+		m.board, cmd = m.board.Update(msg)
+		return m, cmd
+	case ViewCalendar:
+		return m, m.handleCalendarInput(msg)
+	case ViewTimeline:
+		switch {
+		case key.Matches(msg, m.keymap.Dashboard), key.Matches(msg, m.keymap.Back):
+			m.navigateTo(ViewDashboard)
+			return m, nil
+		case key.Matches(msg, m.keymap.Projects):
+			m.navigateTo(ViewProjectList)
+			return m, nil
+		}
+		if msg.String() == "enter" {
+			if id := m.timeline.SelectedQuestID(); id != "" {
+				m.openQuest(id)
+				m.navigateTo(ViewQuestDetail)
+			}
+			return m, nil
+		}
+		m.timeline, cmd = m.timeline.Update(msg)
+		return m, cmd
+	case ViewActivity:
+		switch {
+		case key.Matches(msg, m.keymap.Back):
+			m.navigateTo(m.activityReturn)
+			return m, nil
+		case key.Matches(msg, m.keymap.Dashboard):
+			m.navigateTo(ViewDashboard)
+			return m, nil
+		case key.Matches(msg, m.keymap.Projects):
+			m.navigateTo(ViewProjectList)
+			return m, nil
+		}
+		m.activity, cmd = m.activity.Update(msg)
+		return m, cmd
+	case ViewStats:
+		switch {
+		case key.Matches(msg, m.keymap.Dashboard), key.Matches(msg, m.keymap.Back):
+			m.navigateTo(ViewDashboard)
+		case key.Matches(msg, m.keymap.Projects):
+			m.navigateTo(ViewProjectList)
+		}
+		return m, nil
+	case ViewCharacter, ViewAchievements:
+		switch {
+		case key.Matches(msg, m.keymap.Dashboard), key.Matches(msg, m.keymap.Back):
+			m.navigateTo(ViewDashboard)
+		case key.Matches(msg, m.keymap.Projects):
+			m.navigateTo(ViewProjectList)
+		case key.Matches(msg, m.keymap.Character):
+			m.navigateTo(ViewCharacter)
+		case key.Matches(msg, m.keymap.Achievements):
+			m.navigateTo(ViewAchievements)
+		}
+		return m, nil
 	}
 	return m, nil
 }
 
-func (m *RootModel) updateScreenModels() {
-	m.dashboard = NewDashboardModel(m.projects, m.selectedProjectIdx, m.keymap)
-	m.projectList = NewProjectListModel(m.projects, m.keymap)
-	if m.selectedQuestIdx >= 0 {
-		m.taskList = NewQuestDetailModel(m.projects, m.selectedProjectIdx, m.selectedQuestIdx, m.keymap)
+// startBlockerPicker edits the blockers of the selected task, or else of
+// the selected sub-quest or the quest itself
+func (m *RootModel) startBlockerPicker() {
+	if id := m.taskList.SelectedTaskID(); id != "" {
+		m.blockers = NewBlockerPickerModel(m.store, domain.KindTask, id)
+	} else if id := m.detailQuestID(); id != "" {
+		m.blockers = NewBlockerPickerModel(m.store, domain.KindQuest, id)
 	} else {
-		m.taskList = NewQuestDetailModel(m.projects, -1, -1, m.keymap)
+		return
+	}
+	m.currentView = ViewBlockers
+}
+
+// toggleBlocker adds or removes the blocker under the picker's cursor
+func (m *RootModel) toggleBlocker() tea.Cmd {
+	blockerID, blocking := m.blockers.Selected()
+	if blockerID == "" {
+		return nil
+	}
+	add, remove := m.store.AddQuestBlocker, m.store.RemoveQuestBlocker
+	if m.blockers.kind == domain.KindTask {
+		add, remove = m.store.AddTaskBlocker, m.store.RemoveTaskBlocker
+	}
+	var err error
+	if blocking {
+		err = remove(m.blockers.itemID, blockerID)
+	} else {
+		err = add(m.blockers.itemID, blockerID)
+	}
+	if err != nil {
+		m.setError(err)
+		return nil
+	}
+	m.updateScreenModels()
+	return m.saveProjectsCmd()
+}
+
+func (m *RootModel) updateScreenModels() {
+	projects := m.store.Projects()
+	m.dashboard = NewDashboardModel(projects, m.store.XPLog(), m.selectedProjectID, m.tagFilter, m.keymap)
+	// Keep the finished list cursor, clamped to what is left
+	finishedIdx := m.finished.selectedIdx
+	m.finished = NewFinishedModel(projects, m.selectedProjectID, m.keymap)
+	if n := len(m.finished.finishedQuests()); finishedIdx >= n {
+		finishedIdx = n - 1
+	}
+	if finishedIdx > 0 {
+		m.finished.selectedIdx = finishedIdx
+	}
+	m.projectList = NewProjectListModel(projects, m.keymap)
+	m.character = NewCharacterModel(projects, m.store.XPLog())
+	m.achievements = NewAchievementsModel(projects, m.store.XPLog())
+	// Keep the calendar on the same day and item
+	calendar := NewCalendarModel(projects, m.selectedProjectID, m.width, m.height)
+	if !m.calendar.day.IsZero() {
+		calendar.calendarCursor = m.calendar.calendarCursor
+		if n := len(calendar.items[calendar.day]); calendar.item >= n {
+			calendar.item = n - 1
+			calendar.inList = n > 0
+		}
+	}
+	m.calendar = calendar
+	// Keep the timeline scrolled to the same week and on the same quest
+	timeline := NewTimelineModel(projects, m.width, m.height)
+	timeline.offset = m.timeline.offset
+	if id := m.timeline.SelectedQuestID(); id != "" {
+		for i, row := range timeline.bars {
+			if timeline.rows[row].bar.QuestID == id {
+				timeline.selected = i
+			}
+		}
+	}
+	m.timeline = timeline
+	m.stats = NewStatsModel(projects, m.selectedProjectID, m.width)
+	// Keep the board cursor while the same project is shown
+	board := NewBoardModel(projects, m.selectedProjectID, m.width, m.height)
+	if m.board.projectID == m.selectedProjectID {
+		board.col, board.row = m.board.col, m.board.row
+	}
+	board.clamp()
+	m.board = board
+	// Keep the cursor in place when the same quest is rebuilt
+	cursor := -1
+	if m.taskList.questID == m.selectedQuestID {
+		cursor = m.taskList.taskList.Index()
+	}
+	m.taskList = NewQuestDetailModel(m.store, m.selectedQuestID, m.keymap)
+	if cursor >= 0 {
+		m.taskList.taskList.Select(cursor)
+	}
+	if m.currentView == ViewBlockers {
+		pickerCursor := m.blockers.selectedIdx
+		m.blockers = NewBlockerPickerModel(m.store, m.blockers.kind, m.blockers.itemID)
+		m.blockers.selectedIdx = pickerCursor
+		// The item itself may have been undone away
+		if m.blockers.itemName == "" {
+			m.currentView = ViewQuestDetail
+		}
 	}
 }
 
@@ -258,7 +636,185 @@ func (m *RootModel) navigateTo(view View) {
 }
 
 func (m *RootModel) updateTaskList() {
-	if m.selectedQuestIdx >= 0 {
-		m.taskList = NewQuestDetailModel(m.projects, m.selectedProjectIdx, m.selectedQuestIdx, m.keymap)
+	if m.selectedQuestID != "" {
+		m.taskList = NewQuestDetailModel(m.store, m.selectedQuestID, m.keymap)
+	}
+}
+
+// openQuest selects a quest and the project that owns it
+func (m *RootModel) openQuest(questID string) {
+	project, err := m.store.QuestProject(questID)
+	if err != nil {
+		return
+	}
+	m.selectedProjectID = project.ID
+	m.selectedQuestID = questID
+	m.updateTaskList()
+}
+
+// detailQuestID returns the selected sub-quest in quest detail, or the quest itself
+func (m *RootModel) detailQuestID() string {
+	if id := m.taskList.SelectedSubQuestID(); id != "" {
+		return id
+	}
+	return m.selectedQuestID
+}
+
+// changeQuestState applies a lifecycle action to a quest and saves on success
+func (m *RootModel) changeQuestState(questID string, action func(id string) error) tea.Cmd {
+	if questID == "" {
+		return nil
+	}
+	if err := action(questID); err != nil {
+		m.setError(err)
+		return nil
+	}
+	m.updateScreenModels()
+	return m.saveProjectsCmd()
+}
+
+// openActivity shows the activity log of a quest and what is inside it,
+// or else of a project
+func (m *RootModel) openActivity(projectID, questID string) {
+	entries, err := m.activityLog.Read()
+	if err != nil {
+		m.setError(err)
+		return
+	}
+	var scope string
+	if q, err := m.store.Quest(questID); err == nil {
+		scope = q.Title
+	} else if p, err := m.store.Project(projectID); err == nil {
+		scope = p.Name
+	}
+	m.activity = NewActivityModel(domain.FilterActivity(entries, projectID, questID), scope, m.height)
+	m.activityReturn = m.currentView
+	m.navigateTo(ViewActivity)
+}
+
+// openBoard shows the board of a project
+func (m *RootModel) openBoard(projectID string) {
+	if projectID == "" {
+		m.errorMsg = "Select a project to see its board"
+		return
+	}
+	m.selectedProjectID = projectID
+	m.navigateTo(ViewBoard)
+}
+
+// moveCard moves the card under the board cursor delta columns along,
+// keeping the cursor on it
+func (m *RootModel) moveCard(delta int) tea.Cmd {
+	questID := m.board.SelectedQuestID()
+	column, ok := m.board.NeighbourColumn(delta)
+	if questID == "" || !ok {
+		return nil
+	}
+	if err := m.store.MoveToColumn(questID, column); err != nil {
+		m.setError(err)
+		return nil
+	}
+	m.updateScreenModels()
+	m.board.Follow(questID)
+	return m.saveProjectsCmd()
+}
+
+// handleCalendarInput picks days and items on the calendar and reschedules
+// items, either a day at a time or by picking one up and dropping it on
+// another day
+func (m *RootModel) handleCalendarInput(msg tea.KeyMsg) tea.Cmd {
+	c := &m.calendar
+	switch {
+	case c.moving != "":
+		switch {
+		case msg.String() == "enter":
+			id := c.moving
+			c.moving = ""
+			return m.reschedule(id, c.day)
+		case key.Matches(msg, m.keymap.Back):
+			c.moving = ""
+			return nil
+		}
+	case c.inList:
+		item, ok := c.Selected()
+		switch {
+		case key.Matches(msg, m.keymap.Back):
+			c.inList = false
+			return nil
+		case !ok:
+		case msg.String() == "enter":
+			m.openQuest(item.QuestID)
+			m.navigateTo(ViewQuestDetail)
+			if item.Kind == domain.KindTask {
+				m.taskList.SelectItem(item.ID)
+			}
+			return nil
+		case msg.String() == "r":
+			c.moving, c.inList = item.ID, false
+			return nil
+		case key.Matches(msg, m.keymap.MoveLeft):
+			return m.reschedule(item.ID, item.Due.AddDate(0, 0, -1))
+		case key.Matches(msg, m.keymap.MoveRight):
+			return m.reschedule(item.ID, item.Due.AddDate(0, 0, 1))
+		}
+	default:
+		switch {
+		case msg.String() == "enter":
+			c.EnterList()
+			return nil
+		case key.Matches(msg, m.keymap.Dashboard), key.Matches(msg, m.keymap.Back):
+			m.navigateTo(ViewDashboard)
+			return nil
+		case key.Matches(msg, m.keymap.Projects):
+			m.navigateTo(ViewProjectList)
+			return nil
+		}
+	}
+	m.calendar, _ = m.calendar.Update(msg)
+	return nil
+}
+
+// reschedule moves the deadline of a quest or task to day, keeping the
+// calendar's cursor on it
+func (m *RootModel) reschedule(id string, day time.Time) tea.Cmd {
+	if err := m.store.Reschedule(id, day); err != nil {
+		m.setError(err)
+		return nil
+	}
+	m.updateScreenModels()
+	m.calendar.Follow(id, day)
+	return m.saveProjectsCmd()
+}
+
+// replay runs an undo or redo, then leaves any screen whose item is gone
+func (m *RootModel) replay(verb string, step func() (domain.Change, error)) tea.Cmd {
+	c, err := step()
+	if err != nil {
+		m.setError(err)
+		return nil
+	}
+	m.statusMsg = verb + " " + c.Label
+	tick := m.ensureTicking()
+	if _, err := m.store.Project(m.selectedProjectID); err != nil {
+		m.selectedProjectID = ""
+	}
+	if _, err := m.store.Quest(m.selectedQuestID); err != nil {
+		m.selectedQuestID = ""
+		if m.currentView == ViewQuestDetail || m.currentView == ViewBlockers {
+			m.currentView = ViewDashboard
+		}
+	}
+	m.updateScreenModels()
+	return tea.Batch(m.saveProjectsCmd(), tick)
+}
+
+// leaveSubQuest moves up to the parent quest, or back to the dashboard from a top-level quest
+func (m *RootModel) leaveSubQuest() {
+	parent, err := m.store.QuestParent(m.selectedQuestID)
+	if err != nil || parent == nil {
+		m.navigateTo(ViewDashboard)
+		return
 	}
+	m.selectedQuestID = parent.ID
+	m.updateTaskList()
 }
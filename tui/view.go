@@ -2,6 +2,9 @@ package tui
 
 import (
 	"fmt"
+	"time"
+
+	"quest_line/domain"
 )
 
 // View renders the appropriate screen
@@ -10,42 +13,71 @@ func (m *RootModel) View() string {
 		return appStyle.Render(m.viewDeleteConfirmation())
 	}
 
+	var screen string
 	switch m.currentView {
 	case ViewProjectSelection:
-		return appStyle.Render(m.projectSelection.View() + "\n\n" + m.help.ViewFor(m.currentView))
+		screen = m.projectSelection.View()
 	case ViewDashboard:
-		return appStyle.Render(m.dashboard.View() + "\n\n" + m.help.ViewFor(m.currentView))
+		screen = m.dashboard.View()
+	case ViewFinished:
+		screen = m.finished.View()
+	case ViewSearch:
+		screen = m.search.View()
 	case ViewProjectList:
-		return appStyle.Render(m.projectList.View() + "\n\n" + m.help.ViewFor(m.currentView))
+		screen = m.projectList.View()
 	case ViewQuestDetail:
-		return appStyle.Render(m.taskList.View() + "\n\n" + m.help.ViewFor(m.currentView))
-	case ViewCreateProject, ViewEditProject, ViewCreateQuest, ViewEditQuest, ViewCreateTask, ViewEditTask:
-		return appStyle.Render(m.form.View() + "\n\n" + m.help.ViewFor(m.currentView))
+		screen = m.taskList.View()
+	case ViewBlockers:
+		screen = m.blockers.View()
+	case ViewCharacter:
+		screen = m.character.View()
+	case ViewAchievements:
+		screen = m.achievements.View()
+	case ViewBoard:
+		screen = m.board.View()
+	case ViewCalendar:
+		screen = m.calendar.View()
+	case ViewTimeline:
+		screen = m.timeline.View()
+	case ViewStats:
+		screen = m.stats.View()
+	case ViewActivity:
+		screen = m.activity.View()
+	case ViewCreateProject, ViewEditProject, ViewCreateQuest, ViewEditQuest, ViewCreateTask, ViewEditTask, ViewCreateSubQuest, ViewEditSubQuest, ViewTagFilter:
+		screen = m.form.View()
 	default:
 		return appStyle.Render("Unknown view")
 	}
+	for _, toast := range m.toasts {
+		screen += "\n" + toastStyle.Render(toast)
+	}
+	if timer, ok := domain.RunningTimer(m.store.Projects()); ok {
+		elapsed := timer.Task.Running().Duration(time.Now())
+		screen += "\n" + timerStyle.Render(fmt.Sprintf("⏱ %s (%s) %s", timer.Task.Description, timer.QuestTitle, domain.FormatClock(elapsed)))
+	}
+	if m.errorMsg != "" {
+		screen += "\n" + errorStyle.Render(m.errorMsg)
+	} else if m.statusMsg != "" {
+		screen += "\n" + m.statusMsg
+	}
+	return appStyle.Render(screen + "\n\n" + m.help.ViewFor(m.currentView))
 }
 
 // viewDeleteConfirmation shows the delete confirmation prompt
 func (m *RootModel) viewDeleteConfirmation() string {
 	var itemName string
 	switch m.deleteType {
-	case "project":
-		if m.deleteIndices[0] >= 0 && m.deleteIndices[0] < len(m.projects) {
-			itemName = m.projects[m.deleteIndices[0]].Name
+	case domain.KindProject:
+		if p, err := m.store.Project(m.deleteID); err == nil {
+			itemName = p.Name
 		}
-	case "quest":
-		if m.selectedProjectIdx >= 0 && m.deleteIndices[1] >= 0 &&
-			m.selectedProjectIdx < len(m.projects) &&
-			m.deleteIndices[1] < len(m.projects[m.selectedProjectIdx].Quests) {
-			itemName = m.projects[m.selectedProjectIdx].Quests[m.deleteIndices[1]].Title
+	case domain.KindQuest:
+		if q, err := m.store.Quest(m.deleteID); err == nil {
+			itemName = q.Title
 		}
-	case "task":
-		if m.selectedProjectIdx >= 0 && m.selectedQuestIdx >= 0 && m.deleteIndices[2] >= 0 &&
-			m.selectedProjectIdx < len(m.projects) &&
-			m.selectedQuestIdx < len(m.projects[m.selectedProjectIdx].Quests) &&
-			m.deleteIndices[2] < len(m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks) {
-			itemName = m.projects[m.selectedProjectIdx].Quests[m.selectedQuestIdx].Tasks[m.deleteIndices[2]].Description
+	case domain.KindTask:
+		if t, err := m.store.Task(m.deleteID); err == nil {
+			itemName = t.Description
 		}
 	}
 